@@ -0,0 +1,59 @@
+// Command storage-s3gateway serves a Storager or Servicer via S3 compatible API with pkg/s3gateway.
+//
+// Usage:
+//
+//	storage-s3gateway [-addr :9000] [-bucket storage] [-access-key key -secret-key secret] <config string>
+//
+// If config string opens a Storager, it will be served as the bucket specified by -bucket, otherwise every
+// storager in the opened Servicer will be served as a bucket. For example:
+//
+//	storage-s3gateway -addr 127.0.0.1:9000 -access-key test -secret-key test_secret fs:///tmp/data
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/Xuanwo/storage/coreutils"
+	"github.com/Xuanwo/storage/pkg/s3gateway"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "address to listen on")
+	bucket := flag.String("bucket", "storage", "bucket name to serve storager as")
+	accessKey := flag.String("access-key", "", "access key to verify signature, signature is not required if empty")
+	secretKey := flag.String("secret-key", "", "secret key to verify signature")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <config string>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	srv, store, err := coreutils.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("open: %v", err)
+	}
+
+	var verifier *s3gateway.Verifier
+	if *accessKey != "" {
+		verifier = s3gateway.NewVerifier(*accessKey, *secretKey)
+	}
+
+	var g *s3gateway.Gateway
+	if store != nil {
+		g = s3gateway.New(store, *bucket, verifier)
+	} else {
+		g = s3gateway.NewService(srv, verifier)
+	}
+
+	log.Printf("serving %s on %s", g, *addr)
+	log.Fatal(http.ListenAndServe(*addr, g))
+}
@@ -0,0 +1,49 @@
+// Command storage-server serves a Storager over HTTP via pkg/server.
+//
+// Usage:
+//
+//	storage-server [-addr :8080] [-secret secret] <config string>
+//
+// For example:
+//
+//	storage-server -addr 127.0.0.1:8080 -secret test_secret fs:///tmp/data
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/Xuanwo/storage/coreutils"
+	"github.com/Xuanwo/storage/pkg/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	secret := flag.String("secret", "", "secret to validate signed urls, signature is not required if empty")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <config string>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	store, err := coreutils.OpenStorager(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("open storager: %v", err)
+	}
+
+	var signer *server.Signer
+	if *secret != "" {
+		signer = server.NewSigner([]byte(*secret))
+	}
+
+	log.Printf("serving %s on %s", store, *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.New(store, signer)))
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/coreutils"
+	"github.com/Xuanwo/storage/pkg/backup"
+	"github.com/Xuanwo/storage/pkg/segment"
+	"github.com/Xuanwo/storage/pkg/snapshot"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/services/shard"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func runLs(fs *flag.FlagSet, args []string) error {
+	recursive := fs.Bool("R", false, "list recursively")
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fileFn := func(o *types.Object) {
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", o.Size, o.UpdatedAt.Format(time.RFC3339), o.Name)
+	}
+	dirFn := func(o *types.Object) {
+		_, _ = fmt.Fprintf(w, "DIR\t\t%s/\n", o.Name)
+	}
+
+	if !*recursive {
+		return store.List(fs.Arg(1), pairs.WithFileFunc(fileFn), pairs.WithDirFunc(dirFn))
+	}
+
+	files, dirs, err := listAll(store, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	for _, v := range dirs {
+		dirFn(v)
+	}
+	for _, v := range files {
+		fileFn(v)
+	}
+	return nil
+}
+
+func runStat(fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	o, err := store.Stat(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	_, _ = fmt.Fprintf(w, "name:\t%s\n", o.Name)
+	_, _ = fmt.Fprintf(w, "type:\t%s\n", o.Type)
+	_, _ = fmt.Fprintf(w, "size:\t%d\n", o.Size)
+	_, _ = fmt.Fprintf(w, "updated_at:\t%s\n", o.UpdatedAt.Format(time.RFC3339))
+
+	keys := make([]string, 0, len(o.Metadata))
+	for k := range o.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(w, "%s:\t%v\n", k, o.Metadata[k])
+	}
+	return nil
+}
+
+func runCat(fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	r, err := store.Read(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(os.Stdout, r)
+	return err
+}
+
+func runPut(fs *flag.FlagSet, args []string) error {
+	contentType := fs.String("content-type", "", "content type of the object")
+	detect := fs.Bool("detect", false, "detect content type from content if -content-type not given")
+	_ = fs.Parse(args)
+	if fs.NArg() != 3 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader
+	var size int64
+	if fs.Arg(1) == "-" {
+		// Most services require size while writing, so we have to read stdin into memory.
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		r, size = bytes.NewReader(b), int64(len(b))
+	} else {
+		f, err := os.Open(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		r, size = f, fi.Size()
+	}
+
+	ps := []*types.Pair{pairs.WithSize(size)}
+	if *contentType != "" {
+		ps = append(ps, pairs.WithContentType(*contentType))
+	}
+	if *detect {
+		ps = append(ps, pairs.WithDetectContentType(true))
+	}
+	return store.Write(fs.Arg(2), r, ps...)
+}
+
+func runGet(fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+	if fs.NArg() != 3 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	r, err := store.Read(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if fs.Arg(2) == "-" {
+		_, err = io.Copy(os.Stdout, r)
+		return err
+	}
+
+	f, err := os.Create(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func runCp(fs *flag.FlagSet, args []string) error {
+	to := fs.String("to", "", "config string of the destination storager")
+	_ = fs.Parse(args)
+	if fs.NArg() != 3 {
+		return errUsage
+	}
+
+	src, dst, err := openSrcDst(fs.Arg(0), *to)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := src.(storage.Copier); ok && *to == "" {
+		return c.Copy(fs.Arg(1), fs.Arg(2))
+	}
+	_, err = storageutil.CopyObject(src, fs.Arg(1), dst, fs.Arg(2))
+	return err
+}
+
+func runMv(fs *flag.FlagSet, args []string) error {
+	to := fs.String("to", "", "config string of the destination storager")
+	_ = fs.Parse(args)
+	if fs.NArg() != 3 {
+		return errUsage
+	}
+
+	src, dst, err := openSrcDst(fs.Arg(0), *to)
+	if err != nil {
+		return err
+	}
+
+	if m, ok := src.(storage.Mover); ok && *to == "" {
+		return m.Move(fs.Arg(1), fs.Arg(2))
+	}
+	_, err = storageutil.CopyObject(src, fs.Arg(1), dst, fs.Arg(2))
+	if err != nil {
+		return err
+	}
+	return src.Delete(fs.Arg(1))
+}
+
+func runRm(fs *flag.FlagSet, args []string) error {
+	recursive := fs.Bool("r", false, "delete all objects under dir recursively")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if !*recursive {
+		return store.Delete(fs.Arg(1))
+	}
+
+	files, dirs, err := listAll(store, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	for _, v := range files {
+		if err = store.Delete(v.Name); err != nil {
+			return err
+		}
+	}
+	// Delete dirs from the deepest one, prefix based services may not have dirs at all.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		err = store.Delete(dirs[i].Name)
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return err
+		}
+	}
+	err = store.Delete(fs.Arg(1))
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
+
+func runSign(fs *flag.FlagSet, args []string) error {
+	expire := fs.Int("expire", 3600, "expire time in seconds")
+	method := fs.String("method", http.MethodGet, "http method to sign for")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	r, ok := store.(storage.Reacher)
+	if !ok {
+		return fmt.Errorf("%s is not a Reacher: %w", store, types.ErrNotSupported)
+	}
+
+	url, err := r.Reach(fs.Arg(1), pairs.WithExpire(*expire), pairs.WithMethod(*method))
+	if err != nil {
+		return err
+	}
+	fmt.Println(url)
+	return nil
+}
+
+func runDu(fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var size, count int64
+	if s, ok := store.(storage.Statistician); ok && fs.Arg(1) == "" {
+		m, err := s.Statistical()
+		if err != nil {
+			return err
+		}
+		size, _ = m.GetSize()
+		count, _ = m.GetCount()
+	} else {
+		// Count by ourselves while storager doesn't support Statistical.
+		files, _, err := listAll(store, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		for _, v := range files {
+			size += v.Size
+		}
+		count = int64(len(files))
+	}
+
+	fmt.Printf("%d\t%d\t%s\n", size, count, fs.Arg(1))
+	return nil
+}
+
+func runMb(fs *flag.FlagSet, args []string) error {
+	location := fs.String("location", "", "location of the storager")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	srv, err := coreutils.OpenServicer(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ps := make([]*types.Pair, 0)
+	if *location != "" {
+		ps = append(ps, pairs.WithLocation(*location))
+	}
+	_, err = srv.Create(fs.Arg(1), ps...)
+	return err
+}
+
+func runRb(fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	srv, err := coreutils.OpenServicer(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return srv.Delete(fs.Arg(1))
+}
+
+func runSegments(fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+	if fs.NArg() < 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	s, ok := store.(storage.Segmenter)
+	if !ok {
+		return fmt.Errorf("%s is not a Segmenter: %w", store, types.ErrNotSupported)
+	}
+
+	switch {
+	case fs.Arg(0) == "ls" && fs.NArg() <= 3:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		defer w.Flush()
+
+		return s.ListSegments(fs.Arg(2), pairs.WithSegmentFunc(func(seg *segment.Segment) {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", seg.ID, seg.Path)
+		}))
+	case fs.Arg(0) == "abort" && fs.NArg() == 3:
+		// Segments are maintained by storager, so we need to list them before abort.
+		err = s.ListSegments("")
+		if err != nil {
+			return err
+		}
+		return s.AbortSegment(fs.Arg(2))
+	default:
+		return errUsage
+	}
+}
+
+func runRebalance(fs *flag.FlagSet, args []string) error {
+	path := fs.String("path", "", "only rebalance objects under path")
+	vnodes := fs.Int("vnodes", shard.DefaultVirtualNodes, "virtual nodes for every shard")
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		return errUsage
+	}
+
+	// Shards are identified by their position, so configs must keep the same order as in use.
+	shards := make([]storage.Storager, fs.NArg())
+	for i, v := range fs.Args() {
+		store, err := coreutils.OpenStorager(v)
+		if err != nil {
+			return err
+		}
+		shards[i] = store
+	}
+
+	store := shard.New()
+	err := store.Init(pairs.WithStoragers(shards), pairs.WithVirtualNodes(*vnodes))
+	if err != nil {
+		return err
+	}
+	return store.Rebalance(*path, pairs.WithFileFunc(func(o *types.Object) {
+		fmt.Println(o.Name)
+	}))
+}
+
+func runSnapshot(fs *flag.FlagSet, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	from := fs.String("from", "", "config of copy source, required by restore")
+	src := fs.String("src", "", "path in copy source, defaults to the restored path")
+	_ = fs.Parse(args[1:])
+	if fs.NArg() != 3 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		m, err := snapshot.Create(store, fs.Arg(2), store, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d files recorded\n", len(m))
+		return nil
+	case "diff":
+		old, err := snapshot.Load(store, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		new, err := snapshot.Load(store, fs.Arg(2))
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		defer w.Flush()
+		for _, c := range snapshot.Diff(old, new) {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", c.Type, c.Path())
+		}
+		return nil
+	case "restore":
+		if *from == "" {
+			return errUsage
+		}
+		m, err := snapshot.Load(store, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+
+		// Reuse store for the same config, so that restoring a path from itself could be checked.
+		source := store
+		if *from != fs.Arg(0) {
+			source, err = coreutils.OpenStorager(*from)
+			if err != nil {
+				return err
+			}
+		}
+		srcPath := fs.Arg(2)
+		if *src != "" {
+			srcPath = *src
+		}
+		return snapshot.Restore(store, fs.Arg(2), m, source, srcPath)
+	default:
+		return errUsage
+	}
+}
+
+func runBackup(fs *flag.FlagSet, args []string) error {
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	srv, err := coreutils.OpenServicer(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	store, err := coreutils.OpenStorager(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return backup.Backup(store, srv)
+}
+
+func runRestore(fs *flag.FlagSet, args []string) error {
+	create := fs.Bool("create", false, "create storagers with their original location")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+
+	store, err := coreutils.OpenStorager(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	srv, err := coreutils.OpenServicer(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return backup.Restore(srv, store, *create)
+}
+
+// openSrcDst will open src storager and dst storager, dst will be the same as src if to is empty.
+func openSrcDst(from, to string) (src, dst storage.Storager, err error) {
+	src, err = coreutils.OpenStorager(from)
+	if err != nil {
+		return
+	}
+	if to == "" {
+		return src, src, nil
+	}
+	dst, err = coreutils.OpenStorager(to)
+	return
+}
+
+// listAll will list all files and dirs under path recursively, parent dirs will be placed before
+// their children.
+func listAll(store storage.Storager, path string) (files, dirs []*types.Object, err error) {
+	err = storageutil.Walk(store, path, func(o *types.Object) {
+		files = append(files, o)
+	}, func(o *types.Object) {
+		dirs = append(dirs, o)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, dirs, nil
+}
@@ -0,0 +1,99 @@
+// Command storage is a command-line tool to operate all supported services via the unified API.
+//
+// Usage:
+//
+//	storage <command> [flags] <config string> [args]
+//
+// Config string decides which storager will be operated on, and all paths are relative to its work dir.
+// For example:
+//
+//	storage ls -R qingstor://hmac:<access_key>:<secret_key>@https:qingstor.com:443/<bucket>/<prefix>
+//	storage cp -to fs:///tmp/backup s3://hmac:<access_key>:<secret_key>/<bucket> a.txt a.txt
+//
+// Run "storage help" to see all available commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// errUsage will be returned by commands while args are invalid.
+var errUsage = errors.New("invalid usage")
+
+// command is a sub command of storage.
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(fs *flag.FlagSet, args []string) error
+}
+
+var commands = []*command{
+	{"ls", "[-R] <config> [path]", "List objects under path", runLs},
+	{"stat", "<config> <path>", "Show object's metadata", runStat},
+	{"cat", "<config> <path>", "Print object's content to stdout", runCat},
+	{"put", "[-content-type type] [-detect] <config> <local file> <path>", "Upload local file, \"-\" means stdin", runPut},
+	{"get", "<config> <path> <local file>", "Download object to local file, \"-\" means stdout", runGet},
+	{"cp", "[-to config] <config> <src> <dst>", "Copy object, to another storager if -to given", runCp},
+	{"mv", "[-to config] <config> <src> <dst>", "Move object, to another storager if -to given", runMv},
+	{"rm", "[-r] <config> <path>", "Delete object, or all objects under dir if -r given", runRm},
+	{"sign", "[-expire seconds] [-method method] <config> <path>", "Generate a signed url (Reach)", runSign},
+	{"du", "<config> [path]", "Show total size and count of objects", runDu},
+	{"mb", "[-location location] <config> <name>", "Create a storager in servicer", runMb},
+	{"rb", "<config> <name>", "Delete a storager in servicer", runRb},
+	{"segments", "ls <config> [path] | abort <config> <id>", "List or abort segments", runSegments},
+	{"rebalance", "[-path path] [-vnodes n] <config>...", "Move objects into their shards after shards added", runRebalance},
+	{"backup", "<service config> <config>", "Back up all storagers in service incrementally", runBackup},
+	{"restore", "[-create] <config> <service config>", "Restore backed up storagers into service", runRestore},
+	{"snapshot", "create <config> <path> <manifest> | diff <config> <old> <new> | restore -from <config> [-src path] <config> <manifest> <path>", "Create, diff or restore snapshot manifests", runSnapshot},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for _, v := range commands {
+		if v.name == os.Args[1] {
+			cmd = v
+			break
+		}
+	}
+	if cmd == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "storage: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "Usage: storage %s %s\n\n%s.\n", cmd.name, cmd.args, cmd.short)
+		fs.PrintDefaults()
+	}
+
+	err := cmd.run(fs, os.Args[2:])
+	if errors.Is(err, errUsage) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "storage %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	_, _ = fmt.Fprintf(os.Stderr, "Usage: storage <command> [flags] <config string> [args]\n\nCommands:\n")
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, v := range commands {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", v.name, v.short)
+	}
+	_ = w.Flush()
+	_, _ = fmt.Fprintf(os.Stderr, "\nRun \"storage <command> -h\" for more information about a command.\n")
+}
@@ -8,9 +8,16 @@ import (
 	"github.com/Xuanwo/storage/pkg/config"
 	"github.com/Xuanwo/storage/services/azblob"
 	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/services/ftp"
+	"github.com/Xuanwo/storage/services/http"
 	"github.com/Xuanwo/storage/services/oss"
 	"github.com/Xuanwo/storage/services/qingstor"
 	"github.com/Xuanwo/storage/services/s3"
+	"github.com/Xuanwo/storage/services/sftp"
+	"github.com/Xuanwo/storage/services/tar"
+	"github.com/Xuanwo/storage/services/webdav"
+	"github.com/Xuanwo/storage/services/webhdfs"
+	"github.com/Xuanwo/storage/services/zip"
 	"github.com/Xuanwo/storage/types/pairs"
 )
 
@@ -88,6 +95,14 @@ func Open(cfg string) (srv storage.Servicer, store storage.Storager, err error)
 			return
 		}
 		return
+	case http.Type:
+		store = http.New()
+		err = store.Init(append(opt, pairs.WithWorkDir("/"+namespace))...)
+		if err != nil {
+			err = fmt.Errorf(errorMessage, cfg, err)
+			return
+		}
+		return
 	case oss.Type:
 		srv, err = oss.New(opt...)
 		if err != nil {
@@ -132,6 +147,66 @@ func Open(cfg string) (srv storage.Servicer, store storage.Storager, err error)
 			return
 		}
 		return
+	case ftp.Type:
+		store = ftp.New()
+		// User's login dir will be used if namespace not given.
+		if namespace != "" {
+			opt = append(opt, pairs.WithWorkDir("/"+namespace))
+		}
+		err = store.Init(opt...)
+		if err != nil {
+			err = fmt.Errorf(errorMessage, cfg, err)
+			return
+		}
+		return
+	case sftp.Type:
+		store = sftp.New()
+		// User's login dir will be used if namespace not given.
+		if namespace != "" {
+			opt = append(opt, pairs.WithWorkDir("/"+namespace))
+		}
+		err = store.Init(opt...)
+		if err != nil {
+			err = fmt.Errorf(errorMessage, cfg, err)
+			return
+		}
+		return
+	case tar.Type:
+		store = tar.New()
+		err = store.Init(pairs.WithPath(fs.ParseNamespace(namespace)))
+		if err != nil {
+			err = fmt.Errorf(errorMessage, cfg, err)
+			return
+		}
+		return
+	case webdav.Type:
+		store = webdav.New()
+		err = store.Init(append(opt, pairs.WithWorkDir("/"+namespace))...)
+		if err != nil {
+			err = fmt.Errorf(errorMessage, cfg, err)
+			return
+		}
+		return
+	case webhdfs.Type:
+		store = webhdfs.New()
+		// Root dir will be used if namespace not given.
+		if namespace != "" {
+			opt = append(opt, pairs.WithWorkDir("/"+namespace))
+		}
+		err = store.Init(opt...)
+		if err != nil {
+			err = fmt.Errorf(errorMessage, cfg, err)
+			return
+		}
+		return
+	case zip.Type:
+		store = zip.New()
+		err = store.Init(pairs.WithPath(fs.ParseNamespace(namespace)))
+		if err != nil {
+			err = fmt.Errorf(errorMessage, cfg, err)
+			return
+		}
+		return
 	default:
 		err = fmt.Errorf(errorMessage, cfg, ErrServiceNotSupported)
 		return nil, nil, err
@@ -41,5 +41,11 @@ The most common case to use a Storager service could be following:
 Notes
 
 - Storage uses error wrapping added by go 1.13, go version before 1.13 could be behaved as unexpected.
+
+- All errors returned by services are *types.Error which carries operation, path and storager, use errors.As to
+extract them and errors.Is to check against sentinel errors in types package.
+
+- Pairs not supported by a service will be ignored silently, check the service's meta.json before relying on
+preconditions like if_match and if_none_match, a failed precondition will return types.ErrPreconditionFailed.
 */
 package storage
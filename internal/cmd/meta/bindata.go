@@ -1,6 +1,6 @@
 // Code generated by go-bindata. DO NOT EDIT.
 // sources:
-// meta.tmpl (3.26kB)
+// meta.tmpl (3.292kB)
 
 package main
 
@@ -10,7 +10,6 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -20,7 +19,7 @@ import (
 func bindataRead(data []byte, name string) ([]byte, error) {
 	gz, err := gzip.NewReader(bytes.NewBuffer(data))
 	if err != nil {
-		return nil, fmt.Errorf("read %q: %v", name, err)
+		return nil, fmt.Errorf("read %q: %w", name, err)
 	}
 
 	var buf bytes.Buffer
@@ -28,7 +27,7 @@ func bindataRead(data []byte, name string) ([]byte, error) {
 	clErr := gz.Close()
 
 	if err != nil {
-		return nil, fmt.Errorf("read %q: %v", name, err)
+		return nil, fmt.Errorf("read %q: %w", name, err)
 	}
 	if clErr != nil {
 		return nil, err
@@ -69,7 +68,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _metaTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x55\x5b\x6b\xdb\x30\x14\x7e\xd7\xaf\x38\x0b\x61\x24\xc5\x93\xdf\x5b\xf2\xd4\x16\x36\xc6\xda\xb2\x96\x31\x28\xa5\xa8\xf6\xb1\x2b\x6c\x4b\x9e\x2c\xbb\x0b\x9e\xff\xfb\xd0\x25\x8e\x13\x72\xdd\xca\x60\xd0\xbe\xb8\x39\x57\x7d\x47\xdf\xf9\x14\x86\x70\x2e\x63\x84\x14\x05\x2a\xa6\x31\x86\xa7\x39\xa4\xb2\xff\x0d\x0d\x67\xc0\x85\x46\x25\x58\x1e\x46\x45\x1c\x16\xa8\xd9\x19\x5c\x5c\xc3\xd5\xf5\x1d\x5c\x5e\x7c\xba\xa3\xa4\x64\x51\xc6\x52\x84\xb6\x05\x7a\xc5\x0a\x84\xae\x23\x84\x17\xa5\x54\x1a\x26\x04\x00\x60\x94\x72\xfd\x5c\x3f\xd1\x48\x16\xe1\xf7\x9a\x89\x17\x19\x56\x5a\x2a\x96\xe2\x68\x8f\x3f\xd4\xf3\x12\xab\xc3\xa2\xc2\x92\x71\xb5\x3f\xb6\xcc\xd2\xb0\xc2\xb4\x40\xa1\x0f\x8a\x45\x11\x97\x92\x1f\x18\x1c\x29\x8c\x51\x68\xce\xf2\x11\x99\x12\xd2\x30\x05\x8f\xb0\x34\xd2\x1b\x25\x1b\x1e\xa3\xf2\x9e\x45\xed\x75\xbb\x3f\x1f\xbd\x75\xdf\x85\xd5\x35\xa2\xb7\xee\xab\x08\x09\x43\xb8\x9b\x97\x08\xbc\x02\xfd\x8c\x60\xc6\x00\x89\x54\x2b\x57\x11\x49\x51\x69\x17\x36\x83\xd1\xc0\x33\x22\xa4\x6d\x61\x7c\xc1\x34\x83\xd3\x19\x50\x7b\x6f\xa6\x13\xcb\x73\xf9\x82\xb1\x6f\x73\x63\xa6\x0a\x33\x28\x58\x79\x5f\x69\xc5\x45\xfa\x30\xf8\xb7\xd2\xaa\x8e\x74\xdb\xb5\xa4\x6d\x3f\x80\x62\x22\x45\x18\x67\x01\x8c\x1b\x5b\xd3\xd7\x30\xa5\xed\xf4\x4c\xc3\xcc\xf4\x3e\x85\xd6\x5a\x86\x59\x38\x0f\x60\xfc\x68\xf2\xc6\xcd\x22\xc3\x67\x19\xa7\xcd\xea\xfb\x75\x41\x9f\x8f\x22\x5e\x84\x77\x01\x19\x58\xd6\xf0\xa0\x6a\x78\xf4\x97\x78\x5c\x8d\xed\x78\x5e\x03\xd3\x21\xb8\xf6\x4d\xdb\x52\xc1\x2c\x84\xb7\xb9\x83\xfe\x82\x88\x15\x98\x9f\xb3\x0a\xbb\xce\xf7\x3d\xf0\x22\x3e\xb2\xca\xd6\xc0\xf9\x5a\x95\x27\x29\x73\x5f\x61\x93\xdb\x39\xb8\x88\xf1\xa7\xe3\x1a\x35\x54\xfc\xc2\x4a\x17\xec\xab\xaf\x82\x4b\x6a\x11\x41\xc9\x54\x85\x03\x0e\x6e\x40\x30\x91\xa5\xae\x80\x52\x7a\x62\x05\x80\x9a\xb0\x29\x4c\x4e\x76\xe3\x0e\x00\x95\x92\x6a\xea\x81\x2b\xac\xea\x5c\x1b\xb0\xef\x77\xe7\xb5\x1d\xb1\x09\x0d\xcb\x6b\xac\x4c\x42\xc1\x32\x9c\x0c\xc8\x63\x95\x32\x61\x11\xb6\xdd\xd4\x86\x9a\x5d\x7c\x0c\xc0\xde\x8e\x1b\xae\x3d\xf1\x92\x2a\x3c\x31\x7e\x99\x99\x80\x0d\x6b\x77\xbf\x24\xd8\xc3\x19\xbc\x93\xd9\x20\xd5\xfc\x45\x52\x68\x2e\x6a\xec\x8d\xdd\x9f\x54\xbe\x6f\xe8\x67\x9c\x1f\xdb\xc0\x8d\xc1\xe7\xc2\x0c\x1a\xfa\xcd\x58\x1c\x5d\x49\x7f\xab\x3c\x19\x90\xc8\x6c\x63\x03\x83\x31\xf5\x56\x99\x0d\x89\xd4\x93\x61\x33\x35\x15\xfe\xa8\xb9\xc2\x78\x95\xa1\x8d\x85\x3b\x5b\x1c\xcc\xbe\x06\x74\x23\x29\x1f\x56\x4e\xd7\x57\xf3\x75\x78\xb2\x36\x08\x85\xba\x56\x02\x04\xcf\x03\x70\x44\xbb\xc2\x97\x4b\xa5\xcc\x20\xbf\xfa\xe4\xc9\x8e\x76\x53\xb2\x9c\xdc\xda\x5e\xf3\x04\xd6\x5a\x19\x32\xd2\xad\xcb\x36\x03\xad\x06\xb7\xe1\xc3\xb7\xc5\x36\x74\xb2\x7b\xf7\x76\x9c\xcc\x83\x76\x1d\x02\x03\x9e\x74\x43\x0d\xda\xab\x8f\x4b\x05\x72\xb6\xff\x55\x81\x96\xaf\xc6\xb1\x0a\xb4\x15\xf7\x1e\x05\xda\x9a\xf7\xaf\x14\x68\xf0\x50\xbe\xb2\x02\x6d\xa9\xfc\xa6\x40\x6f\x0a\x74\xac\x02\xfd\x0e\x00\x00\xff\xff\x1e\x09\x58\x54\xbc\x0c\x00\x00")
+var _metaTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x55\x5d\x6b\xdb\x3c\x14\xbe\xd7\xaf\x38\x6f\x08\x2f\x49\xf1\xe4\xfb\x96\x5c\x35\x85\x8d\xb1\xb6\xac\x65\x0c\x4a\x09\xaa\x7d\xe2\x0a\xdb\x92\x27\xc9\xee\x82\xe7\xff\x3e\x64\xc9\x89\xeb\xe5\xab\x5b\x19\x0c\x4a\x2e\xec\x1c\x9d\xf3\x1c\x3d\xe7\xe3\x71\x18\xc2\xb9\x8c\x11\x12\x14\xa8\x98\xc1\x18\x1e\x56\x90\xc8\xf5\x7f\xa8\x38\x03\x2e\x0c\x2a\xc1\xb2\x30\xca\xe3\x30\x47\xc3\xce\x60\x7e\x05\x97\x57\xb7\x70\x31\xff\x70\x4b\x49\xc1\xa2\x94\x25\x08\x75\x0d\xf4\x92\xe5\x08\x4d\x43\x08\xcf\x0b\xa9\x0c\x4c\x08\x00\xc0\xc8\xf0\x1c\x47\xc4\xbd\x27\xdc\x3c\x96\x0f\x34\x92\x79\xf8\xb5\x64\xe2\x49\x86\xda\x48\xc5\x12\x1c\x1d\x38\x0f\xcd\xaa\x40\x7d\x9c\x57\x58\x30\xae\x0e\xfb\x16\x69\x12\x6a\x4c\x72\x14\xe6\x28\x5f\x14\x71\x21\xf9\x91\xce\x91\xc2\x18\x85\xe1\x2c\x1b\x91\x29\x21\x15\x53\xb0\x80\x8d\x91\x5e\x2b\x59\xf1\x18\x95\x3f\xe9\xb0\x87\x76\x7f\x3f\x7a\xe3\x9e\x9d\xd5\x25\xa2\x37\xee\xd9\x39\xdb\x42\xd3\x79\xa9\x98\xe1\x52\x10\x12\x86\x70\xbb\x2a\x10\xb8\x06\xf3\x88\x60\x0b\x08\x4b\xa9\x9e\xb5\x2a\x92\x42\x1b\xe7\x36\x83\x51\xef\x64\x44\x48\x5d\xc3\x78\xce\x0c\x83\xd3\x19\xd0\xb6\xaf\x36\x0d\xcb\x32\xf9\x84\xb1\x4f\x7d\x6d\x2b\x0d\x33\xc8\x59\x71\xa7\x8d\xe2\x22\xb9\xef\xbd\x6a\xa3\xca\xc8\xd4\x4d\x4d\xea\xfa\x1d\x28\x26\x12\x84\x71\x1a\xc0\xb8\x6a\x31\x3d\x86\x85\x6e\x2b\x6a\x13\xa6\x36\xf7\x29\xd4\x6d\x43\xfa\x51\xb8\x0a\x60\xbc\xb0\x71\xe3\xaa\x8b\xf0\x51\xf6\xb0\x8d\x5a\xe7\x6b\x82\x75\x3c\x8a\xb8\x73\x6f\x02\xd2\xb3\x0c\xf8\xa0\xaa\x78\xf4\x87\x7c\x1c\x46\x97\xee\x57\x3e\xaf\xc1\xe9\x18\x5e\x87\xaa\xdd\x8e\x82\x5d\x12\x6f\x73\x17\xfd\x01\x11\xcb\x31\x3b\x67\x1a\x9b\xc6\xe7\x3d\xb2\x11\xef\x99\x6e\x31\x70\x35\x40\x79\x90\x32\xf3\x08\xdb\x8e\xdd\x01\x17\x31\x7e\x77\xb3\x46\xed\x28\x7e\x62\x85\x73\xf6\xe8\xcf\xc9\x2d\x4b\x11\x41\xc1\x94\x46\x7f\x7b\xdb\xb3\x2d\x0c\x26\xb2\x30\x1a\x28\xa5\x27\x96\xae\xa6\xd6\x6d\x0a\x93\x93\xfd\xbc\x03\x40\xa5\xa4\x9a\x7a\xe2\x0a\x75\x99\x19\x5b\xbf\xff\xf7\xc7\xd5\x8d\x53\xb8\x8a\x65\x25\x6a\x1b\x90\xb3\x14\x27\xbd\xe1\x69\x95\x74\xc9\x22\xac\x9b\x69\xeb\x6a\x77\x71\x11\x40\xbb\x0b\xae\x5b\xed\x8d\x37\xa3\xc2\x97\xb0\x08\x40\xa6\xd6\x61\xcb\xda\xdd\x6d\x06\xec\xfe\x0c\xfe\x93\xa9\xbf\x73\xf7\x8b\xa4\x30\x5c\x94\xb8\x36\x36\xbf\x83\x7c\x57\xd1\x8f\xb8\x7a\x69\x02\x57\x06\x1f\x0b\x33\xa8\xe8\x17\x6b\x69\xc3\x7d\xa5\x6c\x57\xf9\xb2\x37\x44\x56\x5d\x2a\xe8\x95\x69\x6d\x95\x69\x7f\x90\xd6\xc3\xb0\x7d\x34\x15\x7e\x2b\xb9\xc2\xf8\xf9\x84\x56\x2d\xdd\x59\x77\x31\xdb\x4c\x4d\xb7\x0e\xe5\xfd\x1a\x96\x2f\x7b\x68\x1e\x87\x2f\x07\x85\x50\x68\x4a\x25\x40\xf0\x2c\x68\x25\x56\xd3\x4b\x7c\xba\x50\xca\x16\xf2\xb3\x0f\x9e\xec\x49\x37\xf5\x35\x19\x70\xf3\xb9\x06\xa9\xec\x30\xd2\x9d\xcb\x36\x03\xa3\x4a\x1c\xba\xef\xf2\xad\xe8\x64\xff\xee\xed\xb9\x99\x27\xed\xb6\x23\xb0\xe4\x49\xd3\xd7\xa0\x83\xfa\xb8\x51\x20\xa7\x99\xff\xaa\x02\x6d\xbe\x1a\x2f\x55\xa0\x9d\xbc\x0f\x28\xd0\xce\xb8\xbf\xa5\x40\xbd\x0f\xe5\x2b\x2b\xd0\x0e\xe4\x37\x05\x7a\x53\xa0\x97\x2a\xd0\xcf\x01\x00\x59\x23\x5b\x3a\xdc\x0c\x00\x00")
 
 func metaTmplBytes() ([]byte, error) {
 	return bindataRead(
@@ -85,7 +84,7 @@ func metaTmpl() (*asset, error) {
 	}
 
 	info := bindataFileInfo{name: "meta.tmpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x39, 0x79, 0x42, 0xde, 0x86, 0x99, 0xe5, 0x5c, 0xd7, 0x5f, 0xdb, 0x1f, 0xaa, 0x9a, 0xc7, 0xc4, 0xd6, 0x9c, 0xbd, 0xe1, 0xf2, 0xe, 0xc8, 0xe1, 0xee, 0xd5, 0x93, 0xb2, 0xbb, 0xaf, 0xaa, 0x12}}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7c, 0x99, 0xa2, 0xde, 0x76, 0x4b, 0x1e, 0x49, 0xb2, 0xd8, 0x70, 0x3a, 0xfe, 0x64, 0xbb, 0x5, 0x88, 0x9e, 0x21, 0xd8, 0x19, 0xfb, 0xe5, 0xb6, 0xd6, 0x83, 0x86, 0x2a, 0xee, 0x31, 0x9d, 0x55}}
 	return a, nil
 }
 
@@ -183,15 +182,20 @@ var _bindata = map[string]func() (*asset, error){
 	"meta.tmpl": metaTmpl,
 }
 
+// AssetDebug is true if the assets were built with the debug flag enabled.
+const AssetDebug = false
+
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"},
 // AssetDir("data/img") would return []string{"a.png", "b.png"},
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
@@ -224,7 +228,7 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"meta.tmpl": &bintree{metaTmpl, map[string]*bintree{}},
+	"meta.tmpl": {metaTmpl, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -241,7 +245,7 @@ func RestoreAsset(dir, name string) error {
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	err = os.WriteFile(_filePath(dir, name), data, info.Mode())
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,6 @@
 // Code generated by go-bindata. DO NOT EDIT.
 // sources:
-// pair.tmpl (657B)
+// pair.tmpl (669B)
 
 package main
 
@@ -10,7 +10,6 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -20,7 +19,7 @@ import (
 func bindataRead(data []byte, name string) ([]byte, error) {
 	gz, err := gzip.NewReader(bytes.NewBuffer(data))
 	if err != nil {
-		return nil, fmt.Errorf("read %q: %v", name, err)
+		return nil, fmt.Errorf("read %q: %w", name, err)
 	}
 
 	var buf bytes.Buffer
@@ -28,7 +27,7 @@ func bindataRead(data []byte, name string) ([]byte, error) {
 	clErr := gz.Close()
 
 	if err != nil {
-		return nil, fmt.Errorf("read %q: %v", name, err)
+		return nil, fmt.Errorf("read %q: %w", name, err)
 	}
 	if clErr != nil {
 		return nil, err
@@ -69,7 +68,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _pairTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\x41\x8f\xda\x30\x14\x84\xef\xfe\x15\xa3\x08\x55\x50\xd1\xf8\x4e\xc5\xa1\x82\x1e\xaa\x4a\xa5\x07\xd4\xee\xf5\x91\xbc\x35\x56\x1c\xdb\x72\x9c\xa0\x28\xeb\xff\xbe\x4a\xd8\x65\xf7\xc0\x2e\xf8\xe6\x99\x79\xe3\xf7\x59\x4a\x6c\x5c\xc9\x50\x6c\x39\x50\xe4\x12\x87\x1e\xca\x5d\xee\xd0\x36\x72\xb0\x64\x64\x51\x97\xd2\x93\x0e\xcd\x77\x6c\x77\xf8\xb3\xdb\xe3\xe7\xf6\xd7\x3e\x17\x9e\x8a\x8a\x14\x63\xf2\x84\xd0\xb5\x77\x21\x62\x2e\x00\x20\x53\x3a\x1e\xdb\x43\x5e\xb8\x5a\x3e\xb4\x64\x4f\x4e\x36\xd1\x05\x52\x9c\xdd\xf0\xa5\xaf\x94\x6c\x58\xd5\x6c\xe3\x5d\x59\xb6\xa5\x77\xfa\xce\x70\x11\xb8\x64\x1b\x35\x99\x9b\xf1\xd8\x7b\x6e\x32\xb1\x10\x42\x4a\xfc\x30\x06\xd4\x91\x36\x74\x30\x2f\xc4\xb9\x28\x9c\x6d\x46\xe0\x61\xf8\x86\x40\x56\x31\x66\xd5\x12\xb3\x0e\xab\x35\xf2\x2d\x45\x42\x4a\xd3\x2b\xc3\x80\x59\x85\x27\x14\x54\xb3\xd9\x50\xc3\x48\x09\x6b\x64\x67\x3d\xa5\x6c\xaa\x60\x5b\x8e\x03\x0b\xf1\x79\xa1\x94\xf8\xaf\xe3\xf1\x6a\xe7\x49\x8f\x7b\x7a\x6f\x7a\xbc\x76\xa3\x23\xd3\x32\xa2\xc3\xce\x47\xed\x6c\x23\x1e\x5b\x5b\x7c\x58\x31\xef\xa6\xc9\x0e\x29\x2d\xf0\x75\xfa\x83\xfc\x2f\xe9\x80\x61\x22\x09\x1c\xdb\x60\xf1\xe5\xcd\x38\xeb\xe3\xf9\xcd\xfd\xea\x2a\xea\xf2\x12\xf9\x37\xee\xb2\x42\x77\x56\x92\x48\xef\xc0\x9f\x03\x00\x00\xff\xff\x66\x1e\xf8\x79\x91\x02\x00\x00")
+var _pairTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\x41\x8f\xda\x30\x14\x84\xef\xfe\x15\xa3\x08\x55\x50\x41\x7c\xa7\xe2\x50\x41\x0f\x55\xa5\xd2\x03\x6a\x7b\x7d\x24\xaf\xc6\x8a\x63\x5b\x8e\x13\x14\xa5\xfe\xef\x55\x92\x2e\x7b\x61\x17\x94\x4b\x3c\x33\xef\xf3\x1b\x4b\x89\xbd\x2b\x19\x8a\x2d\x07\x8a\x5c\xe2\xdc\x43\xb9\xdb\x19\xda\x46\x0e\x96\x8c\x2c\xea\x52\x7a\xd2\xa1\xf9\x84\xc3\x11\xdf\x8f\x27\x7c\x39\x7c\x3d\xe5\xc2\x53\x51\x91\x62\x4c\x9e\x10\xba\xf6\x2e\x44\x2c\x05\x00\x64\x51\xd7\x9c\x89\xf9\x5f\xe9\x78\x69\xcf\x79\xe1\x6a\xf9\xbb\x25\x7b\x75\xb2\x89\x2e\x90\xe2\xec\x81\x2f\x7d\xa5\x64\xc3\xaa\x66\x1b\x9f\xca\xb2\x2d\xbd\xd3\x4f\x86\x8b\xc0\x25\xdb\xa8\xc9\x3c\x8c\xc7\xde\x73\x93\x89\x95\x10\x52\xe2\xb3\x31\xa0\x8e\xb4\xa1\xb3\xf9\xdf\x3e\x17\x85\xb3\xcd\x58\x7e\x18\x36\x08\x64\x15\x63\x51\xad\xb1\xe8\xb0\xdd\x21\x3f\x50\x24\xa4\x34\xdd\x32\x0c\x58\x54\xf8\x8b\x82\x6a\x36\x7b\x6a\x18\x29\x61\x87\x6c\xd6\x53\xca\x26\x04\xdb\x72\x1c\x58\x89\xf7\x81\x52\xe2\x97\x8e\x97\xbb\xcc\xab\x1e\xf7\xf4\xde\xf4\x78\x61\xa3\x23\xd3\x32\xa2\xc3\xd1\x47\xed\x6c\x23\xfe\xb4\xb6\x78\x13\xb1\xec\xa6\xc9\x0e\x29\xad\xf0\x71\x7a\x83\xfc\x07\xe9\x80\x61\x6a\x12\x38\xb6\xc1\xe2\xc3\xab\x31\xeb\xe3\xf7\x8d\xfb\x2d\xee\x31\xd7\xb7\xc8\xcf\x71\x97\x2d\xba\x59\x49\x22\x89\x61\xd8\x80\x6d\x89\x94\xfe\x0d\x00\x8d\x2c\x75\xf8\x9d\x02\x00\x00")
 
 func pairTmplBytes() ([]byte, error) {
 	return bindataRead(
@@ -85,7 +84,7 @@ func pairTmpl() (*asset, error) {
 	}
 
 	info := bindataFileInfo{name: "pair.tmpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x68, 0xbd, 0x2b, 0x15, 0x76, 0xb2, 0x2d, 0x4a, 0x66, 0x3e, 0xf0, 0xe1, 0x1e, 0xb9, 0xf0, 0xa5, 0xac, 0xa5, 0x24, 0x8c, 0xce, 0xce, 0x3, 0xc2, 0x97, 0x36, 0xe0, 0x56, 0x27, 0x2e, 0x75, 0xb5}}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8b, 0x4d, 0x9c, 0x6, 0x22, 0x59, 0xb8, 0x7f, 0xb7, 0xb3, 0x3a, 0xa, 0x9f, 0xb0, 0x18, 0xc2, 0x98, 0x6d, 0x9e, 0x3d, 0x98, 0x54, 0x20, 0x49, 0xb4, 0x37, 0x13, 0xbb, 0x77, 0x51, 0xe0, 0x27}}
 	return a, nil
 }
 
@@ -183,15 +182,20 @@ var _bindata = map[string]func() (*asset, error){
 	"pair.tmpl": pairTmpl,
 }
 
+// AssetDebug is true if the assets were built with the debug flag enabled.
+const AssetDebug = false
+
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"},
 // AssetDir("data/img") would return []string{"a.png", "b.png"},
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
@@ -224,7 +228,7 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"pair.tmpl": &bintree{pairTmpl, map[string]*bintree{}},
+	"pair.tmpl": {pairTmpl, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -241,7 +245,7 @@ func RestoreAsset(dir, name string) error {
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	err = os.WriteFile(_filePath(dir, name), data, info.Mode())
 	if err != nil {
 		return err
 	}
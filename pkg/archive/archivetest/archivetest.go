@@ -0,0 +1,165 @@
+// Package archivetest provides shared tests for read only archive based storagers like tar and zip.
+package archivetest
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+var (
+	// Content is the content of every file in test archive.
+	Content = []byte("0123456789")
+	// ModTime is the modified time of every entry in test archive.
+	ModTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+// Storager is the storager under test.
+type Storager interface {
+	storage.Storager
+	storage.Statistician
+}
+
+// TestStorager will test an initiated store which is opened on an archive with following entries:
+//
+//	a/
+//	a/b/test_file
+//	c
+//
+// Both files should hold Content and be modified at ModTime.
+func TestStorager(t *testing.T, store Storager) {
+	t.Run("stat", func(t *testing.T) {
+		o, err := store.Stat("a/b/test_file")
+		assert.NoError(t, err)
+		assert.Equal(t, "a/b/test_file", o.Name)
+		assert.Equal(t, types.ObjectTypeFile, o.Type)
+		assert.Equal(t, int64(len(Content)), o.Size)
+		assert.True(t, ModTime.Equal(o.UpdatedAt))
+
+		o, err = store.Stat("a/b")
+		assert.NoError(t, err)
+		assert.Equal(t, types.ObjectTypeDir, o.Type)
+
+		_, err = store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			pairs  []*types.Pair
+			expect string
+		}{
+			{"full", nil, "0123456789"},
+			{"offset", []*types.Pair{pairs.WithOffset(7)}, "789"},
+			{"size", []*types.Pair{pairs.WithSize(3)}, "012"},
+			{"offset and size", []*types.Pair{pairs.WithOffset(2), pairs.WithSize(3)}, "234"},
+			{"offset out of range", []*types.Pair{pairs.WithOffset(20)}, ""},
+		}
+
+		for _, name := range []string{"a/b/test_file", "c"} {
+			for _, tt := range cases {
+				t.Run(name+" "+tt.name, func(t *testing.T) {
+					r, err := store.Read(name, tt.pairs...)
+					if !assert.NoError(t, err) {
+						return
+					}
+					defer r.Close()
+
+					b, err := ioutil.ReadAll(r)
+					assert.NoError(t, err)
+					assert.Equal(t, tt.expect, string(b))
+				})
+			}
+		}
+
+		_, err := store.Read("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		_, err = store.Read("a")
+		assert.True(t, errors.Is(err, types.ErrInvalidPath))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := make([]string, 0), make([]string, 0)
+		err := store.List("",
+			pairs.WithFileFunc(func(o *types.Object) {
+				files = append(files, o.Name)
+			}),
+			pairs.WithDirFunc(func(o *types.Object) {
+				dirs = append(dirs, o.Name)
+			}),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"c"}, files)
+		assert.Equal(t, []string{"a"}, dirs)
+
+		files = files[:0]
+		err = store.List("a/b", pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a/b/test_file"}, files)
+
+		err = store.List("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("statistical", func(t *testing.T) {
+		m, err := store.Statistical()
+		assert.NoError(t, err)
+
+		size, ok := m.GetSize()
+		assert.True(t, ok)
+		assert.Equal(t, int64(2*len(Content)), size)
+		count, ok := m.GetCount()
+		assert.True(t, ok)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		err := store.Write("c", bytes.NewReader(Content))
+		assert.True(t, errors.Is(err, types.ErrReadOnly))
+
+		err = store.Delete("c")
+		assert.True(t, errors.Is(err, types.ErrReadOnly))
+	})
+}
+
+// TestInit will test Init of stores created by fn, path is the local archive described in TestStorager
+// and notExist is a local path which doesn't exist.
+func TestInit(t *testing.T, fn func() Storager, path, notExist string) {
+	t.Run("work dir", func(t *testing.T) {
+		store := fn()
+		err := store.Init(pairs.WithPath(path), pairs.WithWorkDir("a"))
+		assert.NoError(t, err)
+
+		o, err := store.Stat("b/test_file")
+		assert.NoError(t, err)
+		assert.Equal(t, "b/test_file", o.Name)
+
+		m, err := store.Statistical()
+		assert.NoError(t, err)
+		count, _ := m.GetCount()
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("not exist", func(t *testing.T) {
+		store := fn()
+		err := store.Init(pairs.WithPath(notExist))
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("path required", func(t *testing.T) {
+		store := fn()
+		err := store.Init()
+		assert.True(t, errors.Is(err, types.ErrPairRequired))
+	})
+}
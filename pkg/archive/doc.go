@@ -0,0 +1,10 @@
+/*
+Package archive provided tar and zip archive support for storagers.
+
+Index maintains the dir tree of entries in an archive, so that List and Stat could be served from memory. ReaderAt
+reads an archive stored in any Storager via ranged Read, so that only the needed bytes will be fetched.
+
+Export and ExportTo stream a Storager's subtree as tar, tar.gz or zip archive, and Extract writes an archive
+stream back into a Storager.
+*/
+package archive
@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// Format is the format of archive.
+type Format string
+
+// All supported formats.
+const (
+	FormatTar   Format = "tar"
+	FormatTarGz Format = "tar.gz"
+	FormatZip   Format = "zip"
+)
+
+// DefaultPartSize is the part size used by ExportTo while writing into a Segmenter.
+const DefaultPartSize = 64 * 1024 * 1024
+
+// ErrFormatNotSupported will be returned when archive format is not supported.
+var ErrFormatNotSupported = fmt.Errorf("archive format: %w", types.ErrNotSupported)
+
+// writer is the common writer for different formats.
+type writer interface {
+	// WriteDir will add a dir entry with name.
+	WriteDir(name string, o *types.Object) error
+	// WriteFile will add a file entry with name, and copy its content from r.
+	WriteFile(name string, o *types.Object, r io.Reader) error
+	// Close will flush the archive, the underlying writer will not be closed.
+	Close() error
+}
+
+// Export will walk path in store and write all files and dirs under it into w as an archive in format.
+//
+// Entries' names will be relative to path, and their mtimes come from objects' UpdatedAt. Sizes in tar headers
+// come from objects' Size, so content which doesn't match Size will be treated as error. Content will be
+// streamed from store, so nothing will be buffered on local disk.
+func Export(w io.Writer, store storage.Storager, path string, format Format) (err error) {
+	aw, err := newWriter(w, format)
+	if err != nil {
+		return
+	}
+
+	err = exportDir(aw, store, path, strings.Trim(path, "/"))
+	if err != nil {
+		_ = aw.Close()
+		return
+	}
+	return aw.Close()
+}
+
+// ExportTo will export path in src as an archive in format into dstPath in dst.
+//
+// Archive will be uploaded in parts of DefaultPartSize if dst is a Segmenter, otherwise it will be sent to
+// Write without size, which requires dst to support Write with unknown size.
+func ExportTo(dst storage.Storager, dstPath string, src storage.Storager, srcPath string, format Format) (err error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(Export(pw, src, srcPath, format))
+	}()
+	defer pr.Close()
+
+	s, ok := dst.(storage.Segmenter)
+	if !ok {
+		return dst.Write(dstPath, pr)
+	}
+	return writeSegments(s, dstPath, pr, DefaultPartSize)
+}
+
+// writeSegments will write r into path of s in parts of partSize.
+func writeSegments(s storage.Segmenter, path string, r io.Reader, partSize int64) (err error) {
+	id, err := s.InitSegment(path, pairs.WithPartSize(partSize))
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = s.AbortSegment(id)
+		}
+	}()
+
+	buf := make([]byte, partSize)
+	var offset int64
+	for {
+		n, rerr := io.ReadFull(r, buf)
+		if rerr != nil && rerr != io.EOF && rerr != io.ErrUnexpectedEOF {
+			return rerr
+		}
+		// Empty archive still needs one part.
+		if n > 0 || offset == 0 {
+			err = s.WriteSegment(id, offset, int64(n), bytes.NewReader(buf[:n]))
+			if err != nil {
+				return
+			}
+			offset += int64(n)
+		}
+		if rerr != nil {
+			break
+		}
+	}
+	return s.CompleteSegment(id)
+}
+
+// exportDir will write all objects under dir into aw, prefix will be trimmed from objects' names.
+func exportDir(aw writer, store storage.Storager, dir, prefix string) (err error) {
+	files, dirs := make([]*types.Object, 0), make([]*types.Object, 0)
+	err = store.List(dir,
+		pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o)
+		}),
+		pairs.WithDirFunc(func(o *types.Object) {
+			dirs = append(dirs, o)
+		}),
+	)
+	if err != nil {
+		return
+	}
+
+	for _, o := range files {
+		err = exportFile(aw, store, o, entryName(prefix, o.Name))
+		if err != nil {
+			return
+		}
+	}
+	for _, o := range dirs {
+		err = aw.WriteDir(entryName(prefix, o.Name), o)
+		if err != nil {
+			return
+		}
+		err = exportDir(aw, store, o.Name, prefix)
+		if err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+func exportFile(aw writer, store storage.Storager, o *types.Object, name string) (err error) {
+	r, err := store.Read(o.Name)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	return aw.WriteFile(name, o, r)
+}
+
+// entryName will convert object name into entry name which is relative to prefix.
+func entryName(prefix, name string) string {
+	name = storageutil.CleanPath(name)
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+}
+
+func newWriter(w io.Writer, format Format) (writer, error) {
+	switch format {
+	case FormatTar:
+		return &tarWriter{w: tar.NewWriter(w)}, nil
+	case FormatTarGz:
+		gw := gzip.NewWriter(w)
+		return &tarWriter{w: tar.NewWriter(gw), gw: gw}, nil
+	case FormatZip:
+		return &zipWriter{w: zip.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrFormatNotSupported, format)
+	}
+}
+
+type tarWriter struct {
+	w  *tar.Writer
+	gw *gzip.Writer
+}
+
+func (t *tarWriter) WriteDir(name string, o *types.Object) error {
+	return t.w.WriteHeader(&tar.Header{
+		Name:     name + "/",
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+		ModTime:  modTime(o),
+	})
+}
+
+func (t *tarWriter) WriteFile(name string, o *types.Object, r io.Reader) error {
+	err := t.w.WriteHeader(&tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     o.Size,
+		ModTime:  modTime(o),
+	})
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(t.w, io.LimitReader(r, o.Size))
+	if err != nil {
+		return err
+	}
+	if n != o.Size {
+		return fmt.Errorf("export %s: expected %d bytes but got %d: %w", name, o.Size, n, io.ErrUnexpectedEOF)
+	}
+	// Size in tar header can't be changed, so content longer than Size should be treated as error too.
+	if m, _ := r.Read(make([]byte, 1)); m > 0 {
+		return fmt.Errorf("export %s: content is longer than %d bytes", name, o.Size)
+	}
+	return nil
+}
+
+func (t *tarWriter) Close() error {
+	err := t.w.Close()
+	if t.gw == nil {
+		return err
+	}
+	if gerr := t.gw.Close(); err == nil {
+		err = gerr
+	}
+	return err
+}
+
+type zipWriter struct {
+	w *zip.Writer
+}
+
+func (z *zipWriter) WriteDir(name string, o *types.Object) error {
+	_, err := z.w.CreateHeader(&zip.FileHeader{
+		Name:     name + "/",
+		Method:   zip.Store,
+		Modified: modTime(o),
+	})
+	return err
+}
+
+func (z *zipWriter) WriteFile(name string, o *types.Object, r io.Reader) error {
+	w, err := z.w.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime(o),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sizes will be recorded by zip writer, so we don't need to rely on object's Size.
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (z *zipWriter) Close() error {
+	return z.w.Close()
+}
+
+// modTime will return object's UpdatedAt, current time will be used if not set.
+func modTime(o *types.Object) time.Time {
+	if o.UpdatedAt.IsZero() {
+		return time.Now()
+	}
+	return o.UpdatedAt
+}
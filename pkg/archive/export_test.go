@@ -0,0 +1,289 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+var testFiles = map[string]string{
+	"src/a":        "content a",
+	"src/b/c":      "content c",
+	"src/b/d/e":    "content e",
+	"src/empty/f":  "",
+	"not_exported": "not exported",
+}
+
+func newTestStorager(t *testing.T) (string, storage.Storager) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, store
+}
+
+func writeTestFiles(t *testing.T, dir string) {
+	for name, content := range testFiles {
+		p := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(p, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertExtracted(t *testing.T, dir string) {
+	for name, content := range map[string]string{
+		"a":       "content a",
+		"b/c":     "content c",
+		"b/d/e":   "content e",
+		"empty/f": "",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, content, string(b))
+		}
+	}
+	_, err := os.Stat(filepath.Join(dir, "not_exported"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExportAndExtract(t *testing.T) {
+	for _, format := range []Format{FormatTar, FormatTarGz, FormatZip} {
+		t.Run(string(format), func(t *testing.T) {
+			srcDir, src := newTestStorager(t)
+			defer os.RemoveAll(srcDir)
+			writeTestFiles(t, srcDir)
+
+			buf := &bytes.Buffer{}
+			err := Export(buf, src, "src", format)
+			assert.NoError(t, err)
+
+			dstDir, dst := newTestStorager(t)
+			defer os.RemoveAll(dstDir)
+
+			err = Extract(dst, "dst", buf, format)
+			assert.NoError(t, err)
+			assertExtracted(t, filepath.Join(dstDir, "dst"))
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		err := Export(&bytes.Buffer{}, nil, "", "rar")
+		assert.True(t, errors.Is(err, types.ErrNotSupported))
+
+		err = Extract(nil, "", &bytes.Buffer{}, "rar")
+		assert.True(t, errors.Is(err, types.ErrNotSupported))
+	})
+}
+
+func TestExport_Tar(t *testing.T) {
+	dir, store := newTestStorager(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir)
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := os.Chtimes(filepath.Join(dir, "src", "a"), modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = Export(buf, store, "src", FormatTar)
+	assert.NoError(t, err)
+
+	headers := make(map[string]*tar.Header)
+	tr := tar.NewReader(buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		headers[hdr.Name] = hdr
+	}
+
+	assert.Len(t, headers, 7)
+	assert.Equal(t, byte(tar.TypeDir), headers["b/"].Typeflag)
+	assert.Equal(t, byte(tar.TypeDir), headers["b/d/"].Typeflag)
+	assert.Equal(t, int64(len("content a")), headers["a"].Size)
+	assert.True(t, modTime.Equal(headers["a"].ModTime))
+}
+
+// sizeStorager will return a wrong size in List.
+type sizeStorager struct {
+	storage.Storager
+}
+
+func (s *sizeStorager) List(path string, ps ...*types.Pair) error {
+	opt := make([]*types.Pair, 0, len(ps))
+	for _, v := range ps {
+		if v.Key != pairs.FileFunc {
+			opt = append(opt, v)
+			continue
+		}
+		fn := v.Value.(types.ObjectFunc)
+		opt = append(opt, pairs.WithFileFunc(func(o *types.Object) {
+			o.Size++
+			fn(o)
+		}))
+	}
+	return s.Storager.List(path, opt...)
+}
+
+func TestExport_SizeMismatch(t *testing.T) {
+	dir, store := newTestStorager(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir)
+
+	err := Export(ioutil.Discard, &sizeStorager{store}, "src", FormatTar)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+// segmentStorager will implement Segmenter by buffering parts in memory.
+type segmentStorager struct {
+	storage.Storager
+
+	path    string
+	parts   map[int64][]byte
+	aborted bool
+}
+
+func (s *segmentStorager) ListSegments(path string, pairs ...*types.Pair) (err error) {
+	return nil
+}
+
+func (s *segmentStorager) InitSegment(path string, pairs ...*types.Pair) (id string, err error) {
+	s.path, s.parts = path, make(map[int64][]byte)
+	return "test_id", nil
+}
+
+func (s *segmentStorager) WriteSegment(id string, offset, size int64, r io.Reader, pairs ...*types.Pair) (err error) {
+	s.parts[offset], err = ioutil.ReadAll(r)
+	return
+}
+
+func (s *segmentStorager) CompleteSegment(id string, ps ...*types.Pair) (err error) {
+	buf := &bytes.Buffer{}
+	for offset := int64(0); ; {
+		part, ok := s.parts[offset]
+		if !ok {
+			break
+		}
+		buf.Write(part)
+		offset += int64(len(part))
+		if len(part) == 0 {
+			break
+		}
+	}
+	return s.Storager.Write(s.path, buf, pairs.WithSize(int64(buf.Len())))
+}
+
+func (s *segmentStorager) AbortSegment(id string, pairs ...*types.Pair) (err error) {
+	s.aborted = true
+	return nil
+}
+
+func TestExportTo(t *testing.T) {
+	srcDir, src := newTestStorager(t)
+	defer os.RemoveAll(srcDir)
+	writeTestFiles(t, srcDir)
+
+	dstDir, dst := newTestStorager(t)
+	defer os.RemoveAll(dstDir)
+
+	t.Run("write", func(t *testing.T) {
+		err := ExportTo(dst, "test.tar", src, "src", FormatTar)
+		assert.NoError(t, err)
+
+		f, err := os.Open(filepath.Join(dstDir, "test.tar"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		err = Extract(dst, "write", f, FormatTar)
+		assert.NoError(t, err)
+		assertExtracted(t, filepath.Join(dstDir, "write"))
+	})
+
+	t.Run("segment", func(t *testing.T) {
+		s := &segmentStorager{Storager: dst}
+		err := ExportTo(s, "test.zip", src, "src", FormatZip)
+		assert.NoError(t, err)
+		assert.Equal(t, "test.zip", s.path)
+
+		f, err := os.Open(filepath.Join(dstDir, "test.zip"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		err = Extract(dst, "segment", f, FormatZip)
+		assert.NoError(t, err)
+		assertExtracted(t, filepath.Join(dstDir, "segment"))
+	})
+
+	t.Run("multiple parts", func(t *testing.T) {
+		s := &segmentStorager{Storager: dst}
+		err := writeSegments(s, "parts", bytes.NewReader([]byte("0123456789")), 4)
+		assert.NoError(t, err)
+		assert.Len(t, s.parts, 3)
+
+		b, err := ioutil.ReadFile(filepath.Join(dstDir, "parts"))
+		assert.NoError(t, err)
+		assert.Equal(t, "0123456789", string(b))
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		s := &segmentStorager{Storager: dst}
+		err := ExportTo(s, "test.zip", src, "not_exist", FormatZip)
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		assert.True(t, s.aborted)
+	})
+}
+
+func TestExtract_Escape(t *testing.T) {
+	dir, store := newTestStorager(t)
+	defer os.RemoveAll(dir)
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	err := tw.WriteHeader(&tar.Header{Name: "../../escape", Typeflag: tar.TypeReg, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = tw.Write([]byte("x"))
+	_ = tw.Close()
+
+	err = Extract(store, "dst", buf, FormatTar)
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "dst", "escape"))
+	assert.NoError(t, err)
+}
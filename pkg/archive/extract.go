@@ -0,0 +1,120 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// Extract will read an archive in format from r and write all files in it into path in store.
+//
+// Entries' names will be cleaned, so entries like "../a" will never escape from path. Only regular files will
+// be written, and dirs will be created by store while writing files. tar archives will be streamed, but zip
+// archive has to be spilled into a temp file because its central directory is at the end.
+func Extract(store storage.Storager, path string, r io.Reader, format Format) (err error) {
+	switch format {
+	case FormatTar:
+		return extractTar(store, path, r)
+	case FormatTarGz:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		return extractTar(store, path, gr)
+	case FormatZip:
+		return extractZip(store, path, r)
+	default:
+		return fmt.Errorf("%w: %s", ErrFormatNotSupported, format)
+	}
+}
+
+func extractTar(store storage.Storager, p string, r io.Reader) (err error) {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar header: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := extractName(p, hdr.Name)
+		if name == "" {
+			continue
+		}
+		err = store.Write(name, tr, pairs.WithSize(hdr.Size))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(store storage.Storager, p string, r io.Reader) (err error) {
+	f, err := ioutil.TempFile("", "archive")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return
+	}
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return fmt.Errorf("read zip central directory: %w", err)
+	}
+
+	for _, zf := range zr.File {
+		if strings.HasSuffix(zf.Name, "/") || !zf.Mode().IsRegular() {
+			continue
+		}
+		name := extractName(p, zf.Name)
+		if name == "" {
+			continue
+		}
+
+		err = extractZipFile(store, name, zf)
+		if err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+func extractZipFile(store storage.Storager, name string, zf *zip.File) (err error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	return store.Write(name, rc, pairs.WithSize(int64(zf.UncompressedSize64)))
+}
+
+// extractName will clean entry's name and join it with path, "" will be returned for the archive root.
+func extractName(p, name string) string {
+	name = storageutil.CleanPath(name)
+	if name == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path.Join(p, name), "/")
+}
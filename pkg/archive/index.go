@@ -0,0 +1,121 @@
+package archive
+
+import (
+	"path"
+	"sort"
+	"time"
+
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// Entry is a file or dir in archive.
+type Entry struct {
+	// Name is the slash-separated path in archive without leading or trailing "/".
+	Name      string
+	Dir       bool
+	Size      int64
+	UpdatedAt time.Time
+
+	// Value is the format specific data of this entry, it will be nil for implicit dirs.
+	Value interface{}
+}
+
+// Format will convert entry into an object with name relative to the archive root.
+func (e *Entry) Format() *types.Object {
+	o := &types.Object{
+		Name:      e.Name,
+		Type:      types.ObjectTypeFile,
+		Size:      e.Size,
+		UpdatedAt: e.UpdatedAt,
+		Metadata:  make(metadata.Metadata),
+	}
+	if e.Dir {
+		o.Type = types.ObjectTypeDir
+	}
+	return o
+}
+
+// Index is the dir tree of entries in an archive.
+//
+// Index is not safe for concurrent Add, but it's safe to Get and List concurrently after all entries added.
+type Index struct {
+	entries  map[string]*Entry
+	children map[string][]*Entry
+}
+
+// NewIndex will create a new index with only the root dir.
+func NewIndex() *Index {
+	return &Index{
+		entries: map[string]*Entry{
+			"": {Dir: true},
+		},
+		children: make(map[string][]*Entry),
+	}
+}
+
+// Add will add an entry into index, missing parent dirs will be created implicitly.
+//
+// Entry's name will be cleaned, and entry which has the same name with an existing one will replace it,
+// just like extracting the archive.
+func (i *Index) Add(e *Entry) {
+	e.Name = storageutil.CleanPath(e.Name)
+	if e.Name == "" {
+		return
+	}
+
+	if old, ok := i.entries[e.Name]; ok {
+		// Implicit dir should keep its children while replaced by explicit one.
+		*old = *e
+		return
+	}
+	i.entries[e.Name] = e
+
+	parent := path.Dir(e.Name)
+	if parent == "." {
+		parent = ""
+	}
+	if _, ok := i.entries[parent]; !ok {
+		i.Add(&Entry{Name: parent, Dir: true})
+	}
+	i.children[parent] = append(i.children[parent], e)
+}
+
+// Get will return the entry for path p.
+func (i *Index) Get(p string) (*Entry, bool) {
+	e, ok := i.entries[storageutil.CleanPath(p)]
+	return e, ok
+}
+
+// List will return all direct children of dir p sorted by name.
+//
+// false will be returned if p doesn't exist or is not a dir.
+func (i *Index) List(p string) ([]*Entry, bool) {
+	p = storageutil.CleanPath(p)
+	if e, ok := i.entries[p]; !ok || !e.Dir {
+		return nil, false
+	}
+
+	es := make([]*Entry, len(i.children[p]))
+	copy(es, i.children[p])
+	sort.Slice(es, func(x, y int) bool {
+		return es[x].Name < es[y].Name
+	})
+	return es, true
+}
+
+// Walk will call fn for every file under dir p.
+func (i *Index) Walk(p string, fn func(e *Entry)) {
+	es, ok := i.List(p)
+	if !ok {
+		return
+	}
+	for _, e := range es {
+		if e.Dir {
+			i.Walk(e.Name, fn)
+			continue
+		}
+		fn(e)
+	}
+}
@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/types"
+)
+
+func TestIndex(t *testing.T) {
+	now := time.Now()
+
+	i := NewIndex()
+	i.Add(&Entry{Name: "./a/b/c", Size: 1})
+	i.Add(&Entry{Name: "a/d", Size: 2})
+	// Implicit dir will be replaced by explicit one.
+	i.Add(&Entry{Name: "a/b/", Dir: true, UpdatedAt: now})
+	i.Add(&Entry{Name: "e", Size: 3})
+
+	e, ok := i.Get("a/b")
+	assert.True(t, ok)
+	assert.True(t, e.Dir)
+	assert.Equal(t, now, e.UpdatedAt)
+
+	e, ok = i.Get("/a/b/c")
+	assert.True(t, ok)
+	assert.Equal(t, types.ObjectTypeFile, e.Format().Type)
+
+	_, ok = i.Get("x")
+	assert.False(t, ok)
+
+	es, ok := i.List("")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "e"}, names(es))
+
+	es, ok = i.List("a")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a/b", "a/d"}, names(es))
+
+	es, ok = i.List("a/b")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a/b/c"}, names(es))
+
+	_, ok = i.List("e")
+	assert.False(t, ok)
+
+	var size int64
+	i.Walk("a", func(e *Entry) {
+		size += e.Size
+	})
+	assert.Equal(t, int64(3), size)
+}
+
+func names(es []*Entry) []string {
+	s := make([]string, 0, len(es))
+	for _, e := range es {
+		s = append(s, e.Name)
+	}
+	return s
+}
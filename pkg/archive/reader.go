@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// DefaultChunkSize is the default size of data which will be fetched in one Read.
+const DefaultChunkSize = 64 * 1024
+
+// ReaderAt reads the file at path in a Storager via ranged Read.
+//
+// Data will be fetched in chunks and the last chunk will be cached, so that small sequential reads like
+// scanning tar headers or zip central directory will not turn into many requests.
+type ReaderAt struct {
+	store     storage.Storager
+	path      string
+	size      int64
+	chunkSize int64
+
+	mu     sync.Mutex
+	offset int64
+	chunk  []byte
+}
+
+// NewReaderAt will create a ReaderAt for the file at path with size in store.
+func NewReaderAt(store storage.Storager, path string, size int64) *ReaderAt {
+	return &ReaderAt{
+		store:     store,
+		path:      path,
+		size:      size,
+		chunkSize: DefaultChunkSize,
+	}
+}
+
+// Size will return the size of underlying file.
+func (r *ReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt
+func (r *ReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("archive ReadAt [%s]: negative offset %d", r.path, off)
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n < len(p) && off < r.size {
+		if off < r.offset || off >= r.offset+int64(len(r.chunk)) {
+			err = r.fetch(off, int64(len(p)-n))
+			if err != nil {
+				return n, err
+			}
+		}
+
+		c := copy(p[n:], r.chunk[off-r.offset:])
+		n += c
+		off += int64(c)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fetch will fetch the chunk starting at off which has at least want bytes if possible.
+func (r *ReaderAt) fetch(off, want int64) (err error) {
+	size := r.chunkSize
+	if want > size {
+		size = want
+	}
+	if off+size > r.size {
+		size = r.size - off
+	}
+
+	rc, err := r.store.Read(r.path, pairs.WithOffset(off), pairs.WithSize(size))
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	chunk, err := ioutil.ReadAll(io.LimitReader(rc, size))
+	if err != nil {
+		return err
+	}
+	if int64(len(chunk)) < size {
+		return fmt.Errorf("archive ReadAt [%s]: %w", r.path, io.ErrUnexpectedEOF)
+	}
+
+	r.offset, r.chunk = off, chunk
+	return nil
+}
@@ -0,0 +1,86 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// countStorager will count Read calls.
+type countStorager struct {
+	storage.Storager
+	reads int
+}
+
+func (s *countStorager) Read(path string, pairs ...*types.Pair) (io.ReadCloser, error) {
+	s.reads++
+	return s.Storager.Read(path, pairs...)
+}
+
+func TestReaderAt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := bytes.Repeat([]byte("0123456789"), 10)
+	err = ioutil.WriteFile(filepath.Join(dir, "test_file"), content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsStore := fs.New()
+	err = fsStore.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &countStorager{Storager: fsStore}
+
+	r := NewReaderAt(store, "test_file", int64(len(content)))
+	r.chunkSize = 32
+	assert.Equal(t, int64(len(content)), r.Size())
+
+	p := make([]byte, 8)
+	n, err := r.ReadAt(p, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, n)
+	assert.Equal(t, content[10:18], p)
+	assert.Equal(t, 1, store.reads)
+
+	// Read in cached chunk will not fetch data again.
+	n, err = r.ReadAt(p, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, content[20:28], p[:n])
+	assert.Equal(t, 1, store.reads)
+
+	// Read across chunks will fetch next chunk.
+	n, err = r.ReadAt(p, 38)
+	assert.NoError(t, err)
+	assert.Equal(t, content[38:46], p[:n])
+	assert.Equal(t, 2, store.reads)
+
+	// Read larger than chunk size will be fetched at once.
+	large := make([]byte, 64)
+	n, err = r.ReadAt(large, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, content[:64], large[:n])
+	assert.Equal(t, 3, store.reads)
+
+	n, err = r.ReadAt(p, 96)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, content[96:], p[:n])
+
+	_, err = r.ReadAt(p, 100)
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/snapshot"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// Backup will back up all storagers listed from src into dst.
+func Backup(dst storage.Storager, src storage.Servicer) (err error) {
+	stores := make([]storage.Storager, 0)
+	err = src.List(pairs.WithStoragerFunc(func(s storage.Storager) {
+		stores = append(stores, s)
+	}))
+	if err != nil {
+		return err
+	}
+
+	for _, v := range stores {
+		err = BackupStorager(dst, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackupStorager will back up src into dst under its name incrementally.
+//
+// State will only be saved after all changed objects have been copied, so an interrupted backup will be
+// continued by the next one.
+func BackupStorager(dst storage.Storager, src storage.Storager) (err error) {
+	m, err := src.Metadata()
+	if err != nil {
+		return err
+	}
+	if m.Name == "" {
+		return fmt.Errorf("%w: name of %s is empty", types.ErrConfigIncorrect, src)
+	}
+
+	b := &Bucket{Name: m.Name}
+	if v, ok := m.GetLocation(); ok {
+		b.Location = v
+	}
+	err = saveBucket(dst, b)
+	if err != nil {
+		return err
+	}
+
+	prev, err := loadState(dst, b.Name)
+	if err != nil {
+		return err
+	}
+	sortObjects(prev)
+	current, err := snapshot.Take(src, "")
+	if errors.Is(err, types.ErrObjectNotExist) {
+		current, err = snapshot.Manifest{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[string]bool)
+	for _, c := range snapshot.Diff(manifest(prev), current) {
+		changed[c.Path()] = true
+		if c.Type != snapshot.ChangeRemoved {
+			continue
+		}
+		err = dst.Delete(dataPath(b.Name, c.Path()))
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return err
+		}
+	}
+
+	records := make(map[string]*Object, len(prev))
+	for _, v := range prev {
+		records[v.Path] = v
+	}
+
+	objects := make([]*Object, 0, len(current))
+	for _, e := range current {
+		if !changed[e.Path] {
+			o := records[e.Path]
+			o.Entry = *e
+			objects = append(objects, o)
+			continue
+		}
+
+		o, err := storageutil.CopyObject(src, e.Path, dst, dataPath(b.Name, e.Path))
+		if err != nil {
+			return err
+		}
+		objects = append(objects, newObject(e, o))
+	}
+	return saveState(dst, b.Name, objects)
+}
+
+// Restore will restore all storagers backed up in src into dst, storagers will be created with their original
+// location if create is true.
+func Restore(dst storage.Servicer, src storage.Storager, create bool) (err error) {
+	names := make([]string, 0)
+	err = src.List("", pairs.WithDirFunc(func(o *types.Object) {
+		names = append(names, path.Base(strings.Trim(o.Name, "/")))
+	}))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		b, err := loadBucket(src, name)
+		// Dirs without bucket file are not backups.
+		if errors.Is(err, types.ErrObjectNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		ps := make([]*types.Pair, 0, 1)
+		if b.Location != "" {
+			ps = append(ps, pairs.WithLocation(b.Location))
+		}
+
+		var store storage.Storager
+		if create {
+			store, err = dst.Create(b.Name, ps...)
+		} else {
+			store, err = dst.Get(b.Name, ps...)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = RestoreStorager(store, src, b.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreStorager will restore all objects of name backed up in src into dst.
+//
+// Content type, storage class and user metadata will be restored if dst supports them. Storage classes are
+// service specific, so restoring into another kind of service could fail. Objects in dst which are not in backup
+// will not be touched.
+func RestoreStorager(dst storage.Storager, src storage.Storager, name string) (err error) {
+	objects, err := loadState(src, name)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		err = restoreObject(src, dataPath(name, o.Path), dst, o)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreObject(src storage.Storager, srcPath string, dst storage.Storager, o *Object) (err error) {
+	r, err := src.Read(srcPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	ps := []*types.Pair{pairs.WithSize(o.Size)}
+	if o.ContentType != "" {
+		ps = append(ps, pairs.WithContentType(o.ContentType))
+	}
+	if o.StorageClass != "" {
+		ps = append(ps, pairs.WithStorageClass(o.StorageClass))
+	}
+	if len(o.UserMetadata) > 0 {
+		ps = append(ps, pairs.WithUserMetadata(o.UserMetadata))
+	}
+	return dst.Write(o.Path, r, ps...)
+}
+
+func dataPath(name, p string) string {
+	return path.Join(name, dataDir, p)
+}
+
+func sortObjects(objects []*Object) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Path < objects[j].Path
+	})
+}
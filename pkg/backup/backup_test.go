@@ -0,0 +1,211 @@
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// testStorager is a fs storager with name and location.
+type testStorager struct {
+	*fs.Storage
+
+	name, location string
+}
+
+func (s *testStorager) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     s.name,
+		Metadata: make(metadata.Metadata),
+	}
+	m.SetLocation(s.location)
+	return m, nil
+}
+
+// testServicer keeps storagers in sub dirs of dir.
+type testServicer struct {
+	dir string
+
+	created map[string]string
+}
+
+func (s *testServicer) String() string {
+	return "Servicer test"
+}
+
+func (s *testServicer) List(ps ...*types.Pair) (err error) {
+	fi, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, v := range fi {
+		store, err := s.Get(v.Name())
+		if err != nil {
+			return err
+		}
+		for _, p := range ps {
+			if p.Key == pairs.StoragerFunc {
+				p.Value.(storage.StoragerFunc)(store)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *testServicer) Get(name string, ps ...*types.Pair) (storage.Storager, error) {
+	store := fs.New()
+	err := store.Init(pairs.WithWorkDir(filepath.Join(s.dir, name)))
+	if err != nil {
+		return nil, err
+	}
+	return &testStorager{Storage: store, name: name, location: "test"}, nil
+}
+
+func (s *testServicer) Create(name string, ps ...*types.Pair) (storage.Storager, error) {
+	for _, p := range ps {
+		if p.Key == pairs.Location {
+			s.created[name] = p.Value.(string)
+		}
+	}
+	err := os.MkdirAll(filepath.Join(s.dir, name), 0755)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(name)
+}
+
+func (s *testServicer) Delete(name string, ps ...*types.Pair) (err error) {
+	return os.RemoveAll(filepath.Join(s.dir, name))
+}
+
+func newTestServicer(t *testing.T) *testServicer {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testServicer{dir: dir, created: make(map[string]string)}
+}
+
+func writeFile(t *testing.T, store storage.Storager, path, content string) {
+	err := store.Write(path, strings.NewReader(content), pairs.WithSize(int64(len(content))))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	files := make([]string, 0)
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			rel, _ := filepath.Rel(dir, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestBackup(t *testing.T) {
+	src := newTestServicer(t)
+	defer os.RemoveAll(src.dir)
+
+	a, err := src.Create("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, a, "x", "x")
+	writeFile(t, a, "y/z", "z")
+	b, err := src.Create("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, b, "x", "bx")
+
+	backupDir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(backupDir)
+	dst := fs.New()
+	err = dst.Init(pairs.WithWorkDir(backupDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Backup(dst, src)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"a/bucket.json", "a/data/x", "a/data/y/z", "a/objects.jsonl",
+		"b/bucket.json", "b/data/x", "b/objects.jsonl",
+	}, listFiles(t, backupDir))
+
+	// Only changed objects will be copied in the next backup.
+	marked := filepath.Join(backupDir, "a", "data", "y", "z")
+	err = ioutil.WriteFile(marked, []byte("marked"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.Delete("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, a, "w", "w")
+
+	err = BackupStorager(dst, a)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"a/bucket.json", "a/data/w", "a/data/y/z", "a/objects.jsonl",
+		"b/bucket.json", "b/data/x", "b/objects.jsonl",
+	}, listFiles(t, backupDir))
+	content, err := ioutil.ReadFile(marked)
+	assert.NoError(t, err)
+	assert.Equal(t, "marked", string(content))
+
+	objects, err := loadState(dst, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+
+	restored := newTestServicer(t)
+	defer os.RemoveAll(restored.dir)
+
+	err = Restore(restored, dst, true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "test", "b": "test"}, restored.created)
+	assert.Equal(t, []string{"a/w", "a/y/z", "b/x"}, listFiles(t, restored.dir))
+}
+
+func TestRestoreStorager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing backed up.
+	err = RestoreStorager(store, store, "a")
+	assert.NoError(t, err)
+
+	err = Restore(&testServicer{dir: dir}, store, false)
+	assert.NoError(t, err)
+}
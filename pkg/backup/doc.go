@@ -0,0 +1,16 @@
+/*
+Package backup provided backup and restore of all storagers in a Servicer into another Storager.
+
+Every storager will be backed up under "<name>/" of the destination:
+
+	<name>/bucket.json    name and location of the storager
+	<name>/objects.jsonl  state of the last backup, one object for each line
+	<name>/data/<path>    content of objects
+
+Backup is incremental: the state of the last backup will be compared with a snapshot of the storager, and only
+added or modified objects will be copied, and removed objects will be deleted. Content type, storage class and
+user metadata will be recorded in state, so they will be kept even if the destination doesn't support them.
+
+Restore goes the other direction, and could recreate storagers via Servicer.Create with their original location.
+*/
+package backup
@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/snapshot"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+const (
+	bucketFile = "bucket.json"
+	stateFile  = "objects.jsonl"
+	dataDir    = "data"
+)
+
+// Bucket is the storager recorded in backup.
+type Bucket struct {
+	Name     string `json:"name"`
+	Location string `json:"location,omitempty"`
+}
+
+// Object is an object recorded in backup state.
+type Object struct {
+	snapshot.Entry
+
+	ContentType  string            `json:"content_type,omitempty"`
+	StorageClass string            `json:"storage_class,omitempty"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+}
+
+func newObject(e *snapshot.Entry, o *types.Object) *Object {
+	obj := &Object{Entry: *e}
+	if v, ok := o.GetType(); ok {
+		obj.ContentType = v
+	}
+	if v, ok := o.GetClass(); ok {
+		obj.StorageClass = v
+	}
+	if v, ok := o.GetUserMetadata(); ok {
+		obj.UserMetadata = v
+	}
+	return obj
+}
+
+// loadState will read state of the last backup of name, empty state will be returned if not exist.
+func loadState(store storage.Storager, name string) (objects []*Object, err error) {
+	objects = make([]*Object, 0)
+
+	r, err := store.Read(path.Join(name, stateFile))
+	if errors.Is(err, types.ErrObjectNotExist) {
+		return objects, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	d := json.NewDecoder(r)
+	for {
+		o := &Object{}
+		err = d.Decode(o)
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, o)
+	}
+}
+
+func saveState(store storage.Storager, name string, objects []*Object) (err error) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	for _, v := range objects {
+		err = e.Encode(v)
+		if err != nil {
+			return err
+		}
+	}
+	return store.Write(path.Join(name, stateFile), &buf, pairs.WithSize(int64(buf.Len())))
+}
+
+func loadBucket(store storage.Storager, name string) (b *Bucket, err error) {
+	r, err := store.Read(path.Join(name, bucketFile))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	b = &Bucket{}
+	err = json.NewDecoder(r).Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func saveBucket(store storage.Storager, b *Bucket) (err error) {
+	content, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return store.Write(path.Join(b.Name, bucketFile), bytes.NewReader(content), pairs.WithSize(int64(len(content))))
+}
+
+// manifest will return the snapshot manifest of objects.
+func manifest(objects []*Object) snapshot.Manifest {
+	m := make(snapshot.Manifest, 0, len(objects))
+	for _, v := range objects {
+		m = append(m, &v.Entry)
+	}
+	return m
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/Xuanwo/storage/pkg/credential"
@@ -18,7 +19,8 @@ var (
 
 // Parse will parse config string and return service type and namespace.
 //
-// TODO: Options didn't support for now.
+// Only option "style" is supported for now, which will set the addressing style of endpoint like
+// "s3://hmac:ak:sk@http:127.0.0.1:9000/bucket?style=path".
 func Parse(cfg string) (t, namespace string, opt []*types.Pair, err error) {
 	errorMessage := "parse config [%s]: <%w>"
 
@@ -32,6 +34,18 @@ func Parse(cfg string) (t, namespace string, opt []*types.Pair, err error) {
 
 	// Split <credential>@<endpoint>/<name>?<options> into tow parts.
 	s = strings.SplitN(s[1], "/", 2)
+
+	// Split <name>?<options> into tow parts.
+	var options url.Values
+	no := strings.SplitN(s[1], "?", 2)
+	if len(no) == 2 {
+		options, err = url.ParseQuery(no[1])
+		if err != nil {
+			return "", "", nil, fmt.Errorf(errorMessage, cfg, ErrInvalidConfig)
+		}
+	}
+	style := endpoint.Style(options.Get("style"))
+
 	// Handle credential and endpoint
 	// credential and endpoint could be missing for some service.
 	if s[0] != "" {
@@ -53,16 +67,23 @@ func Parse(cfg string) (t, namespace string, opt []*types.Pair, err error) {
 			if err != nil {
 				return "", "", nil, fmt.Errorf(errorMessage, cfg, err)
 			}
+			if style != endpoint.StyleDefault {
+				v := end.Value()
+				v.Style = style
+				end, err = endpoint.NewStatic(v)
+				if err != nil {
+					return "", "", nil, fmt.Errorf(errorMessage, cfg, err)
+				}
+				style = endpoint.StyleDefault
+			}
 			opt = append(opt, pairs.WithEndpoint(end))
 		}
 	}
-	// Handle name and options.
-	s = strings.SplitN(s[1], "?", 2)
-	namespace = s[0]
-	if len(s) == 1 {
-		// We don't have options, return directly.
-		return
+	// Style could only be used with endpoint.
+	if style != endpoint.StyleDefault {
+		return "", "", nil, fmt.Errorf(errorMessage, cfg, ErrInvalidConfig)
 	}
-	// TODO: handle options.
+	// TODO: handle other options.
+	namespace = no[0]
 	return
 }
@@ -65,6 +65,33 @@ func TestParse(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			"endpoint with style",
+			"s3://hmac:ak:sk@http:127.0.0.1:9000/bucket?style=path",
+			"s3",
+			"bucket",
+			[]*types.Pair{
+				pairs.WithEndpoint(endpoint.NewHTTP("127.0.0.1", 9000).WithStyle(endpoint.StylePath)),
+				pairs.WithCredential(credential.MustNewHmac("ak", "sk")),
+			},
+			nil,
+		},
+		{
+			"endpoint with not supported style",
+			"s3://hmac:ak:sk@http:127.0.0.1:9000/bucket?style=unknown",
+			"",
+			"",
+			nil,
+			endpoint.ErrUnsupportedStyle,
+		},
+		{
+			"style without endpoint",
+			"s3://hmac:ak:sk/bucket?style=path",
+			"",
+			"",
+			nil,
+			ErrInvalidConfig,
+		},
 	}
 
 	for _, tt := range cases {
@@ -3,6 +3,8 @@ package endpoint
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
 )
@@ -12,6 +14,22 @@ var (
 	ErrInvalidConfig = errors.New("invalid config")
 	// ErrUnsupportedProtocol will return if protocol is unsupported.
 	ErrUnsupportedProtocol = errors.New("unsupported protocol")
+	// ErrUnsupportedStyle will return if style is unsupported.
+	ErrUnsupportedStyle = errors.New("unsupported style")
+)
+
+// Style decides how the bucket name will be put into the request URL.
+type Style string
+
+// All available styles for endpoint.
+//
+// StyleDefault leaves the decision to the service, StyleVirtualHost puts the bucket name
+// into the host like "bucket.example.com", and StylePath puts the bucket name into the
+// path like "example.com/bucket", which is required by most S3-compatible servers like MinIO.
+const (
+	StyleDefault     Style = ""
+	StyleVirtualHost Style = "virtual_host"
+	StylePath        Style = "path"
 )
 
 // Provider will return all info needed to connect a service.
@@ -24,36 +42,155 @@ type Value struct {
 	Protocol string
 	Host     string
 	Port     int
+	// Path is the prefix that all requests should be sent under, it will be empty or start with "/".
+	Path string
+	// Style is the addressing style of the service.
+	Style Style
 }
 
 // String will compose all info into a valid URL.
 func (v Value) String() string {
-	return fmt.Sprintf("%s://%s:%d", v.Protocol, v.Host, v.Port)
+	return fmt.Sprintf("%s://%s%s", v.Protocol, v.Addr(), v.Path)
+}
+
+// Addr will compose host and port into "host:port", IPv6 host will be enclosed in square brackets.
+func (v Value) Addr() string {
+	return net.JoinHostPort(v.Host, strconv.Itoa(v.Port))
 }
 
 // Parse will parse config string to create a endpoint Provider.
+//
+// Config string is formatted as "<protocol>:<host>[:<port>][/<path>][?style=<style>]", IPv6 host should be
+// enclosed in square brackets like "https:[::1]:9000". Port will fallback to protocol's default port if missing.
 func Parse(cfg string) (Provider, error) {
-	errorMessage := "parse credential config [%s]: <%w>"
+	errorMessage := "parse endpoint config [%s]: <%w>"
 
-	s := strings.Split(cfg, ":")
+	s := strings.SplitN(cfg, ":", 2)
 	if len(s) < 2 {
 		return nil, fmt.Errorf(errorMessage, cfg, ErrInvalidConfig)
 	}
 
-	switch s[0] {
-	case ProtocolHTTPS:
-		port, err := strconv.ParseInt(s[2], 10, 64)
+	protocol, data := s[0], s[1]
+	if _, ok := defaultPorts[protocol]; !ok {
+		return nil, fmt.Errorf(errorMessage, cfg, ErrUnsupportedProtocol)
+	}
+
+	style := StyleDefault
+	if idx := strings.Index(data, "?"); idx >= 0 {
+		q, err := url.ParseQuery(data[idx+1:])
 		if err != nil {
-			return nil, fmt.Errorf(errorMessage, cfg, err)
+			return nil, fmt.Errorf(errorMessage, cfg, ErrInvalidConfig)
 		}
-		return NewHTTPS(s[1], int(port)), nil
-	case ProtocolHTTP:
-		port, err := strconv.ParseInt(s[2], 10, 64)
+		data, style = data[:idx], Style(q.Get("style"))
+	}
+
+	path := ""
+	if idx := strings.Index(data, "/"); idx >= 0 {
+		data, path = data[:idx], data[idx:]
+	}
+
+	host, port, err := splitHostPort(data)
+	if err != nil {
+		return nil, fmt.Errorf(errorMessage, cfg, err)
+	}
+
+	v := Value{
+		Protocol: protocol,
+		Host:     host,
+		Port:     defaultPorts[protocol],
+		Path:     path,
+		Style:    style,
+	}
+	if port != "" {
+		p, err := strconv.ParseInt(port, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf(errorMessage, cfg, err)
+			return nil, fmt.Errorf(errorMessage, cfg, ErrInvalidConfig)
 		}
-		return NewHTTP(s[1], int(port)), nil
+		v.Port = int(p)
+	}
+
+	st, err := NewStatic(v)
+	if err != nil {
+		return nil, fmt.Errorf(errorMessage, cfg, err)
+	}
+	return st, nil
+}
+
+// ParseURL will parse an ordinary URL like "https://example.com:9000/path" to create a endpoint Provider.
+//
+// Addressing style could be set via query "style", for example: "http://127.0.0.1:9000/s3?style=path".
+func ParseURL(s string) (Provider, error) {
+	errorMessage := "parse endpoint url [%s]: <%w>"
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf(errorMessage, s, ErrInvalidConfig)
+	}
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return nil, fmt.Errorf(errorMessage, s, ErrUnsupportedProtocol)
+	}
+
+	v := Value{
+		Protocol: u.Scheme,
+		Host:     u.Hostname(),
+		Port:     defaultPorts[u.Scheme],
+		Path:     u.Path,
+		Style:    Style(u.Query().Get("style")),
+	}
+	if port := u.Port(); port != "" {
+		p, err := strconv.ParseInt(port, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf(errorMessage, s, ErrInvalidConfig)
+		}
+		v.Port = int(p)
+	}
+
+	st, err := NewStatic(v)
+	if err != nil {
+		return nil, fmt.Errorf(errorMessage, s, err)
+	}
+	return st, nil
+}
+
+// Validate will check whether this value could be used to connect a service.
+func (v Value) Validate() error {
+	if _, ok := defaultPorts[v.Protocol]; !ok {
+		return ErrUnsupportedProtocol
+	}
+	if v.Host == "" {
+		return ErrInvalidConfig
+	}
+	if v.Port <= 0 || v.Port > 65535 {
+		return ErrInvalidConfig
+	}
+	if v.Path != "" && !strings.HasPrefix(v.Path, "/") {
+		return ErrInvalidConfig
+	}
+	switch v.Style {
+	case StyleDefault, StyleVirtualHost, StylePath:
 	default:
-		return nil, fmt.Errorf(errorMessage, cfg, ErrUnsupportedProtocol)
+		return ErrUnsupportedStyle
+	}
+	return nil
+}
+
+// splitHostPort will split "host", "host:port", "[ipv6]" and "[ipv6]:port".
+func splitHostPort(s string) (host, port string, err error) {
+	if s == "" {
+		return "", "", ErrInvalidConfig
+	}
+
+	// Host without port, IPv6 host must be enclosed in square brackets.
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return s[1 : len(s)-1], "", nil
+	}
+	if !strings.Contains(s, ":") {
+		return s, "", nil
+	}
+
+	host, port, err = net.SplitHostPort(s)
+	if err != nil {
+		return "", "", ErrInvalidConfig
 	}
+	return host, port, nil
 }
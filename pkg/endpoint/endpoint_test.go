@@ -16,6 +16,14 @@ func TestValue_String(t *testing.T) {
 	}
 
 	assert.Equal(t, "http://example.com:80", v.String())
+
+	v = &Value{
+		Protocol: "https",
+		Host:     "::1",
+		Port:     9000,
+		Path:     "/s3",
+	}
+	assert.Equal(t, "https://[::1]:9000/s3", v.String())
 }
 
 func TestParse(t *testing.T) {
@@ -41,7 +49,7 @@ func TestParse(t *testing.T) {
 			"wrong port number in http",
 			"http:example.com:xxx",
 			nil,
-			strconv.ErrSyntax,
+			ErrInvalidConfig,
 		},
 		{
 			"normal https",
@@ -53,7 +61,31 @@ func TestParse(t *testing.T) {
 			"wrong port number in https",
 			"https:example.com:xxx",
 			nil,
-			strconv.ErrSyntax,
+			ErrInvalidConfig,
+		},
+		{
+			"path style with path",
+			"http:127.0.0.1:9000/s3?style=path",
+			NewHTTP("127.0.0.1", 9000).WithPath("/s3").WithStyle(StylePath),
+			nil,
+		},
+		{
+			"virtual host style without port",
+			"https:example.com?style=virtual_host",
+			NewHTTPS("example.com", 443).WithStyle(StyleVirtualHost),
+			nil,
+		},
+		{
+			"not supported style in config",
+			"https:example.com?style=unknown",
+			nil,
+			ErrUnsupportedStyle,
+		},
+		{
+			"invalid query in config",
+			"https:example.com?style=%zz",
+			nil,
+			ErrInvalidConfig,
 		},
 		{
 			"not supported protocol",
@@ -61,6 +93,60 @@ func TestParse(t *testing.T) {
 			nil,
 			ErrUnsupportedProtocol,
 		},
+		{
+			"https without port",
+			"https:example.com",
+			NewHTTPS("example.com", 443),
+			nil,
+		},
+		{
+			"http without port",
+			"http:example.com",
+			NewHTTP("example.com", 80),
+			nil,
+		},
+		{
+			"missing host",
+			"https:",
+			nil,
+			ErrInvalidConfig,
+		},
+		{
+			"missing host with port",
+			"https::443",
+			nil,
+			ErrInvalidConfig,
+		},
+		{
+			"port out of range",
+			"https:example.com:65536",
+			nil,
+			ErrInvalidConfig,
+		},
+		{
+			"ipv6 host",
+			"http:[::1]:9000",
+			NewHTTP("::1", 9000),
+			nil,
+		},
+		{
+			"ipv6 host without port",
+			"https:[fe80::1]",
+			NewHTTPS("fe80::1", 443),
+			nil,
+		},
+		{
+			"ipv6 host without brackets",
+			"https:fe80::1:443",
+			nil,
+			ErrInvalidConfig,
+		},
+		{
+			"with path",
+			"http:127.0.0.1:9000/minio/s3",
+			NewHTTP("127.0.0.1", 9000).WithPath("/minio/s3"),
+			nil,
+		},
 	}
 
 	for _, tt := range cases {
@@ -81,3 +167,88 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseURL(t *testing.T) {
+	cases := []struct {
+		name  string
+		url   string
+		value Provider
+		err   error
+	}{
+		{
+			"normal https",
+			"https://example.com:8443",
+			NewHTTPS("example.com", 8443),
+			nil,
+		},
+		{
+			"default port",
+			"http://example.com",
+			NewHTTP("example.com", 80),
+			nil,
+		},
+		{
+			"ipv6 host with path and style",
+			"http://[::1]:9000/s3?style=path",
+			NewHTTP("::1", 9000).WithPath("/s3").WithStyle(StylePath),
+			nil,
+		},
+		{
+			"not supported protocol",
+			"smb://example.com",
+			nil,
+			ErrUnsupportedProtocol,
+		},
+		{
+			"not supported style",
+			"https://example.com?style=unknown",
+			nil,
+			ErrUnsupportedStyle,
+		},
+		{
+			"missing host",
+			"https:///path",
+			nil,
+			ErrInvalidConfig,
+		},
+		{
+			"invalid url",
+			"https://example.com:port",
+			nil,
+			ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseURL(tt.url)
+			if tt.err == nil {
+				assert.Nil(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.err))
+			}
+			assert.EqualValues(t, tt.value, p)
+		})
+	}
+}
+
+func TestValue_Validate(t *testing.T) {
+	cases := []struct {
+		name  string
+		value Value
+		err   error
+	}{
+		{"valid", Value{Protocol: "https", Host: "example.com", Port: 443}, nil},
+		{"unsupported protocol", Value{Protocol: "smb", Host: "example.com", Port: 445}, ErrUnsupportedProtocol},
+		{"empty host", Value{Protocol: "https", Port: 443}, ErrInvalidConfig},
+		{"zero port", Value{Protocol: "https", Host: "example.com"}, ErrInvalidConfig},
+		{"relative path", Value{Protocol: "https", Host: "example.com", Port: 443, Path: "s3"}, ErrInvalidConfig},
+		{"invalid style", Value{Protocol: "https", Host: "example.com", Port: 443, Style: "xxx"}, ErrUnsupportedStyle},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, tt.value.Validate())
+		})
+	}
+}
@@ -5,13 +5,35 @@ const (
 	ProtocolHTTPS = "https"
 	// ProtocolHTTP is the http credential protocol.
 	ProtocolHTTP = "http"
+	// ProtocolSFTP is the sftp endpoint protocol.
+	ProtocolSFTP = "sftp"
+	// ProtocolFTP is the ftp endpoint protocol.
+	ProtocolFTP = "ftp"
+	// ProtocolFTPS is the ftp endpoint protocol with explicit TLS (AUTH TLS).
+	ProtocolFTPS = "ftps"
 )
 
+// defaultPorts will be used while port is missing in config.
+var defaultPorts = map[string]int{
+	ProtocolHTTPS: 443,
+	ProtocolHTTP:  80,
+	ProtocolSFTP:  22,
+	ProtocolFTP:   21,
+	ProtocolFTPS:  21,
+}
+
+// DefaultPort will return the default port of a protocol, 0 will be returned if protocol is unsupported.
+func DefaultPort(protocol string) int {
+	return defaultPorts[protocol]
+}
+
 // Static is the static endpoint.
 type Static struct {
 	protocol string
 	host     string
 	port     int
+	path     string
+	style    Style
 }
 
 // Value implements Provider interface.
@@ -20,7 +42,35 @@ func (s Static) Value() Value {
 		Protocol: s.protocol,
 		Host:     s.host,
 		Port:     s.port,
+		Path:     s.path,
+		Style:    s.style,
+	}
+}
+
+// WithPath will return a copy of this endpoint which sends requests under path.
+func (s Static) WithPath(path string) Static {
+	s.path = path
+	return s
+}
+
+// WithStyle will return a copy of this endpoint which uses given addressing style.
+func (s Static) WithStyle(style Style) Static {
+	s.style = style
+	return s
+}
+
+// NewStatic will create a static endpoint from value after validation.
+func NewStatic(v Value) (Static, error) {
+	if err := v.Validate(); err != nil {
+		return Static{}, err
 	}
+	return Static{
+		protocol: v.Protocol,
+		host:     v.Host,
+		port:     v.Port,
+		path:     v.Path,
+		style:    v.Style,
+	}, nil
 }
 
 // NewHTTPS will create a static endpoint from parsed URL.
@@ -37,3 +37,44 @@ func TestStatic_Value(t *testing.T) {
 	assert.Equal(t, host, v.Host)
 	assert.Equal(t, port, v.Port)
 }
+
+func TestNewStatic(t *testing.T) {
+	host := uuid.New().String()
+
+	s, err := NewStatic(Value{
+		Protocol: ProtocolHTTPS,
+		Host:     host,
+		Port:     9000,
+		Path:     "/s3",
+		Style:    StylePath,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, NewHTTPS(host, 9000).WithPath("/s3").WithStyle(StylePath), s)
+
+	_, err = NewStatic(Value{Protocol: ProtocolHTTPS, Port: 9000})
+	assert.Equal(t, ErrInvalidConfig, err)
+}
+
+func TestStatic_WithPath(t *testing.T) {
+	s := NewHTTP("example.com", 80)
+	x := s.WithPath("/s3")
+	assert.Equal(t, "", s.path)
+	assert.Equal(t, "/s3", x.path)
+	assert.Equal(t, "/s3", x.Value().Path)
+}
+
+func TestStatic_WithStyle(t *testing.T) {
+	s := NewHTTP("example.com", 80)
+	x := s.WithStyle(StyleVirtualHost)
+	assert.Equal(t, StyleDefault, s.style)
+	assert.Equal(t, StyleVirtualHost, x.Value().Style)
+}
+
+func TestDefaultPort(t *testing.T) {
+	assert.Equal(t, 443, DefaultPort(ProtocolHTTPS))
+	assert.Equal(t, 80, DefaultPort(ProtocolHTTP))
+	assert.Equal(t, 22, DefaultPort(ProtocolSFTP))
+	assert.Equal(t, 21, DefaultPort(ProtocolFTP))
+	assert.Equal(t, 21, DefaultPort(ProtocolFTPS))
+	assert.Equal(t, 0, DefaultPort("unknown"))
+}
@@ -0,0 +1,112 @@
+package httputil
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+)
+
+// Client will send requests for objects under work dir of endpoint.
+type Client struct {
+	*http.Client
+
+	// Endpoint is the url that all requests will be sent to, its path will be prepended to objects' path.
+	Endpoint *url.URL
+	// WorkDir is the dir under endpoint's path that objects' path are relative to.
+	WorkDir string
+	// Authorization is the value of Authorization header, it will be empty if credential is not given.
+	Authorization string
+}
+
+// NewClient will create a client with default http client, Endpoint must be set before sending requests.
+func NewClient() *Client {
+	return &Client{
+		Client: &http.Client{},
+	}
+}
+
+// Authorization will convert credential into the value of Authorization header.
+//
+// Hmac will be sent as basic auth with username and password, and api key will be sent as bearer token.
+func Authorization(cred *credential.Provider) (string, error) {
+	credProtocol, value := cred.Protocol(), cred.Value()
+	switch credProtocol {
+	case credential.ProtocolHmac:
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(value[0]+":"+value[1])), nil
+	case credential.ProtocolAPIKey:
+		return "Bearer " + value[0], nil
+	default:
+		return "", credential.ErrUnsupportedProtocol
+	}
+}
+
+// AbsPath will return the absolute path of p in url.
+func (c *Client) AbsPath(p string) string {
+	return path.Join("/", c.Endpoint.Path, c.WorkDir, p)
+}
+
+// FileURL will return the url for path.
+func (c *Client) FileURL(p string) string {
+	u := *c.Endpoint
+	u.Path = c.AbsPath(p)
+	return u.String()
+}
+
+// DirURL will return the url for dir which always ends with "/".
+func (c *Client) DirURL(p string) string {
+	u := *c.Endpoint
+	u.Path = strings.TrimSuffix(c.AbsPath(p), "/") + "/"
+	return u.String()
+}
+
+// NewRequest will create a request with Authorization header set.
+func (c *Client) NewRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Authorization != "" {
+		req.Header.Set("Authorization", c.Authorization)
+	}
+	return req, nil
+}
+
+// Read will GET url from offset with size, size 0 means till the end.
+func (c *Client) Read(url string, offset, size int64) (r io.ReadCloser, err error) {
+	req, err := c.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 || size > 0 {
+		req.Header.Set("Range", FormatRange(offset, size))
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckResponse(resp, http.StatusOK, http.StatusPartialContent); err != nil {
+		return nil, err
+	}
+
+	r = resp.Body
+	// Server is allowed to ignore Range header, so we need to skip the offset by ourselves.
+	if resp.StatusCode == http.StatusOK && offset > 0 {
+		_, err = io.CopyN(ioutil.Discard, r, offset)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+	}
+	if size > 0 {
+		r = iowrap.LimitReadCloser(r, size)
+	}
+	return r, nil
+}
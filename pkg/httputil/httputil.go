@@ -0,0 +1,46 @@
+// Package httputil provided helpers shared by services which talk HTTP directly.
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Xuanwo/storage/types"
+)
+
+// FormatRange will format offset and size into a http range header, size 0 means till the end.
+func FormatRange(offset, size int64) string {
+	if size == 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+size-1)
+}
+
+// CheckResponse will check whether response's status code is expected, and convert it into error if not.
+//
+// Response's body will be closed if error returned.
+func CheckResponse(resp *http.Response, expected ...int) error {
+	for _, v := range expected {
+		if resp.StatusCode == v {
+			return nil
+		}
+	}
+	_ = resp.Body.Close()
+
+	err := fmt.Errorf("%s %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusLocked:
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case http.StatusInsufficientStorage:
+		return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+	return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+}
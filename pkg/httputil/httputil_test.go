@@ -0,0 +1,131 @@
+package httputil
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/types"
+)
+
+func TestFormatRange(t *testing.T) {
+	assert.Equal(t, "bytes=0-", FormatRange(0, 0))
+	assert.Equal(t, "bytes=10-", FormatRange(10, 0))
+	assert.Equal(t, "bytes=10-19", FormatRange(10, 10))
+}
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected error
+	}{
+		{http.StatusOK, nil},
+		{http.StatusNotFound, types.ErrObjectNotExist},
+		{http.StatusGone, types.ErrObjectNotExist},
+		{http.StatusForbidden, types.ErrPermissionDenied},
+		{http.StatusLocked, types.ErrPermissionDenied},
+		{http.StatusPreconditionFailed, types.ErrPreconditionFailed},
+		{http.StatusInsufficientStorage, types.ErrQuotaExceeded},
+		{http.StatusTooManyRequests, types.ErrRateLimited},
+		{http.StatusServiceUnavailable, types.ErrServiceUnavailable},
+		{http.StatusTeapot, types.ErrUnhandledError},
+	}
+
+	for _, v := range tests {
+		resp := &http.Response{
+			StatusCode: v.code,
+			Status:     http.StatusText(v.code),
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/test"}},
+		}
+		err := CheckResponse(resp, http.StatusOK)
+		if v.expected == nil {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.True(t, errors.Is(err, v.expected), "%d", v.code)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	v, err := Authorization(credential.MustNewHmac("user", "password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Basic dXNlcjpwYXNzd29yZA==", v)
+
+	v, err = Authorization(credential.MustNewAPIKey("token"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token", v)
+}
+
+func TestClient_URL(t *testing.T) {
+	c := NewClient()
+	c.Endpoint = &url.URL{Scheme: "http", Host: "127.0.0.1:8080", Path: "/dav"}
+	c.WorkDir = "/work"
+
+	assert.Equal(t, "/dav/work/a/b", c.AbsPath("a/b"))
+	assert.Equal(t, "http://127.0.0.1:8080/dav/work/a%20b", c.FileURL("a b"))
+	assert.Equal(t, "http://127.0.0.1:8080/dav/work/a/", c.DirURL("a"))
+	assert.Equal(t, "http://127.0.0.1:8080/dav/work/", c.DirURL(""))
+}
+
+func TestClient_Read(t *testing.T) {
+	content := "0123456789"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Range header will be ignored for path "/ignore".
+		if r.URL.Path == "/ignore" {
+			_, _ = io.WriteString(w, content)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	c.Endpoint, _ = url.Parse(srv.URL)
+	c.Authorization = "Bearer token"
+
+	tests := []struct {
+		name   string
+		path   string
+		offset int64
+		size   int64
+		expect string
+	}{
+		{"full", "range", 0, 0, content},
+		{"range", "range", 2, 3, "234"},
+		{"offset only", "range", 7, 0, "789"},
+		{"range ignored", "ignore", 2, 3, "234"},
+		{"offset only with range ignored", "ignore", 7, 0, "789"},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			r, err := c.Read(c.FileURL(v.path), v.offset, v.size)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer r.Close()
+
+			b, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, v.expect, string(b))
+		})
+	}
+
+	c.Authorization = ""
+	_, err := c.Read(c.FileURL("range"), 0, 0)
+	assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+}
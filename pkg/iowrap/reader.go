@@ -1,9 +1,13 @@
 package iowrap
 
 import (
+	"fmt"
 	"io"
 )
 
+// ErrShortInput will be returned by SizedReader while the underlying reader ends before the expected size.
+var ErrShortInput = fmt.Errorf("short input: %w", io.ErrUnexpectedEOF)
+
 //go:generate mockgen -package iowrap -destination mock_test.go io Reader,Closer,ReaderAt,Seeker
 
 // LimitReadCloser will return a limited hasCall closer.
@@ -27,6 +31,34 @@ func (l *LimitedReadCloser) Close() error {
 	return l.r.Close()
 }
 
+// SizeReader will return a reader which reads exactly n bytes from r.
+func SizeReader(r io.Reader, n int64) *SizedReader {
+	return &SizedReader{r, n}
+}
+
+// SizedReader reads from underlying r like io.LimitedReader, but ErrShortInput will be returned if r ends
+// before n bytes.
+type SizedReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read will read up to remaining bytes from underlying reader.
+func (s *SizedReader) Read(p []byte) (n int, err error) {
+	if s.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.n {
+		p = p[0:s.n]
+	}
+	n, err = s.r.Read(p)
+	s.n -= int64(n)
+	if err == io.EOF && s.n > 0 {
+		err = ErrShortInput
+	}
+	return
+}
+
 // SectionReadCloser will return a sectioned hasCall closer.
 func SectionReadCloser(r interface {
 	io.Closer
@@ -1,7 +1,10 @@
 package iowrap
 
 import (
+	"bytes"
+	"errors"
 	"io"
+	"io/ioutil"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -97,6 +100,34 @@ func TestLimitedReadCloser_Close(t *testing.T) {
 	}
 }
 
+func TestSizedReader_Read(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		size    int64
+		expect  string
+		err     error
+	}{
+		{"exact", "0123456789", 10, "0123456789", nil},
+		{"longer input", "0123456789", 4, "0123", nil},
+		{"short input", "0123", 10, "0123", ErrShortInput},
+		{"empty", "", 0, "", nil},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			b, err := ioutil.ReadAll(SizeReader(bytes.NewReader([]byte(v.content)), v.size))
+			assert.Equal(t, v.expect, string(b))
+			if v.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, v.err))
+				assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+			}
+		})
+	}
+}
+
 func TestSectionedReadCloser_Read(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,40 @@
+package iowrap
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// sniffLen is the max bytes that http.DetectContentType will consider.
+const sniffLen = 512
+
+// SniffContentType will detect content type from the first 512 bytes of r.
+//
+// The returned reader must be used instead of r: if r is an io.Seeker, it will be seeked back to
+// where it was, otherwise the sniffed bytes will be put in front of the rest of r.
+func SniffContentType(r io.Reader) (contentType string, rr io.Reader, err error) {
+	buf := make([]byte, sniffLen)
+
+	if s, ok := r.(io.ReadSeeker); ok {
+		cur, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", nil, err
+		}
+		n, err := io.ReadFull(s, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return "", nil, err
+		}
+		if _, err = s.Seek(cur, io.SeekStart); err != nil {
+			return "", nil, err
+		}
+		return http.DetectContentType(buf[:n]), r, nil
+	}
+
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	buf = buf[:n]
+	return http.DetectContentType(buf), io.MultiReader(bytes.NewReader(buf), r), nil
+}
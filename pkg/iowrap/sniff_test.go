@@ -0,0 +1,55 @@
+package iowrap
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniffContentType(t *testing.T) {
+	html := "<html><body>hello</body></html>"
+
+	tests := []struct {
+		name    string
+		input   io.Reader
+		want    string
+		content string
+	}{
+		{
+			"seeker",
+			strings.NewReader(html),
+			"text/html; charset=utf-8", html,
+		},
+		{
+			"non-seeker",
+			bytes.NewBufferString(html),
+			"text/html; charset=utf-8", html,
+		},
+		{
+			"empty",
+			bytes.NewBuffer(nil),
+			"text/plain; charset=utf-8", "",
+		},
+		{
+			"longer than sniff len",
+			bytes.NewBufferString(strings.Repeat("a", 1024)),
+			"text/plain; charset=utf-8", strings.Repeat("a", 1024),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct, r, err := SniffContentType(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, ct)
+
+			content, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.content, string(content))
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package s3gateway
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// maxKeys is the max keys that ListObjectsV2 could return in one page.
+const maxKeys = 1000
+
+func (g *Gateway) listBuckets(w http.ResponseWriter, r *http.Request) {
+	resp := &listAllMyBucketsResult{
+		Xmlns:   xmlns,
+		Buckets: make([]bucketEntry, 0),
+	}
+
+	if g.service == nil {
+		resp.Buckets = append(resp.Buckets, bucketEntry{Name: g.bucket})
+		writeXML(w, http.StatusOK, resp)
+		return
+	}
+
+	var merr error
+	fn := func(store storage.Storager) {
+		m, err := store.Metadata()
+		if err != nil {
+			merr = err
+			return
+		}
+		resp.Buckets = append(resp.Buckets, bucketEntry{Name: m.Name})
+	}
+	err := g.service.List(pairs.WithStoragerFunc(fn))
+	if err == nil {
+		err = merr
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+func (g *Gateway) createBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	if g.service == nil {
+		writeError(w, r, errNotImplemented)
+		return
+	}
+
+	ps := make([]*types.Pair, 0)
+	cfg := &createBucketConfiguration{}
+	err := xml.NewDecoder(r.Body).Decode(cfg)
+	switch {
+	case err == io.EOF:
+		// Configuration is optional.
+	case err != nil:
+		writeError(w, r, errMalformedXML)
+		return
+	case cfg.LocationConstraint != "":
+		ps = append(ps, pairs.WithLocation(cfg.LocationConstraint))
+	}
+
+	_, err = g.service.Create(bucket, ps...)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *Gateway) deleteBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	if g.service == nil {
+		writeError(w, r, errNotImplemented)
+		return
+	}
+
+	err := g.service.Delete(bucket)
+	if errors.Is(err, types.ErrObjectNotExist) {
+		err = errNoSuchBucket
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *Gateway) getBucketLocation(w http.ResponseWriter, r *http.Request, store storage.Storager) {
+	m, err := store.Metadata()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := &locationConstraint{Xmlns: xmlns}
+	if v, ok := m.GetLocation(); ok {
+		resp.Location = v
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+func (g *Gateway) listObjectsV2(w http.ResponseWriter, r *http.Request, store storage.Storager, bucket string) {
+	query := r.URL.Query()
+
+	resp := &listBucketV2Result{
+		Xmlns:             xmlns,
+		Name:              bucket,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		StartAfter:        query.Get("start-after"),
+		ContinuationToken: query.Get("continuation-token"),
+		EncodingType:      query.Get("encoding-type"),
+		MaxKeys:           maxKeys,
+		Contents:          make([]objectEntry, 0),
+		CommonPrefixes:    make([]commonPrefix, 0),
+	}
+	if !validKey(resp.Prefix) {
+		writeError(w, r, errInvalidObjectName)
+		return
+	}
+	if v := query.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, errInvalidArgument)
+			return
+		}
+		if n < maxKeys {
+			resp.MaxKeys = n
+		}
+	}
+
+	// Continuation token is the base64 encoded last key of previous page.
+	marker := resp.StartAfter
+	if resp.ContinuationToken != "" {
+		b, err := base64.StdEncoding.DecodeString(resp.ContinuationToken)
+		if err != nil {
+			writeError(w, r, errInvalidArgument)
+			return
+		}
+		marker = string(b)
+	}
+
+	entries, err := listEntries(store, resp.Prefix, resp.Delimiter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	last := ""
+	for _, e := range entries {
+		if e.key <= marker {
+			continue
+		}
+		if resp.KeyCount >= resp.MaxKeys {
+			resp.IsTruncated = true
+			resp.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(last))
+			break
+		}
+		last = e.key
+		resp.KeyCount++
+
+		key := e.key
+		if resp.EncodingType == "url" {
+			key = url.QueryEscape(key)
+		}
+		if e.object == nil {
+			resp.CommonPrefixes = append(resp.CommonPrefixes, commonPrefix{Prefix: key})
+			continue
+		}
+		resp.Contents = append(resp.Contents, formatObjectEntry(key, e.object))
+	}
+
+	if resp.EncodingType == "url" {
+		resp.Prefix = url.QueryEscape(resp.Prefix)
+		resp.Delimiter = url.QueryEscape(resp.Delimiter)
+		resp.StartAfter = url.QueryEscape(resp.StartAfter)
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+// entry is a key in list result, object will be nil if it's a common prefix.
+type entry struct {
+	key    string
+	object *types.Object
+}
+
+// listEntries will list all objects under prefix, and group them by delimiter into common prefixes.
+//
+// Directory based storagers only list one level in List, so dirs will be walked recursively,
+// unless delimiter is "/" which means dirs could be returned as common prefixes directly.
+func listEntries(store storage.Storager, prefix, delimiter string) ([]*entry, error) {
+	seen := make(map[string]bool)
+	entries := make([]*entry, 0)
+
+	add := func(key string, o *types.Object) {
+		if !strings.HasPrefix(key, prefix) {
+			return
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				key, o = key[:len(prefix)+i+len(delimiter)], nil
+			}
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		entries = append(entries, &entry{key: key, object: o})
+	}
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		dirs := make([]string, 0)
+		err := store.List(dir,
+			pairs.WithFileFunc(func(o *types.Object) {
+				add(o.Name, o)
+			}),
+			pairs.WithDirFunc(func(o *types.Object) {
+				dirs = append(dirs, strings.TrimSuffix(o.Name, "/")+"/")
+			}),
+		)
+		if errors.Is(err, types.ErrObjectNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, d := range dirs {
+			switch {
+			case delimiter == "/" && strings.HasPrefix(d, prefix) && len(d) > len(prefix):
+				add(d, nil)
+			case strings.HasPrefix(d, prefix) || strings.HasPrefix(prefix, d):
+				if err = walk(strings.TrimSuffix(d, "/")); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// Start from the dir which contains prefix.
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i]
+	}
+	if err := walk(dir); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries, nil
+}
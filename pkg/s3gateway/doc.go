@@ -0,0 +1,37 @@
+/*
+Package s3gateway provided a gateway which serves any Storager or Servicer via a subset of S3 REST API,
+so that existing S3 tools and SDKs could talk to services like fs, qingstor or azblob.
+
+Only path style requests like "http://127.0.0.1:9000/<bucket>/<key>" are supported, and requests will be
+mapped to Storager's API as following:
+
+  - GET    /                                -> ListBuckets (Servicer.List)
+  - PUT    /<bucket>                        -> CreateBucket (Servicer.Create)
+  - DELETE /<bucket>                        -> DeleteBucket (Servicer.Delete)
+  - HEAD   /<bucket>                        -> HeadBucket
+  - GET    /<bucket>?location               -> GetBucketLocation
+  - GET    /<bucket>?list-type=2            -> ListObjectsV2 (List)
+  - GET    /<bucket>/<key>                  -> GetObject (Read with offset and size from "Range")
+  - HEAD   /<bucket>/<key>                  -> HeadObject (Stat)
+  - PUT    /<bucket>/<key>                  -> PutObject (Write)
+  - DELETE /<bucket>/<key>                  -> DeleteObject (Delete)
+  - POST   /<bucket>/<key>?uploads          -> CreateMultipartUpload (Segmenter)
+  - PUT    /<bucket>/<key>?uploadId=<id>    -> UploadPart (Segmenter.WriteSegment)
+  - POST   /<bucket>/<key>?uploadId=<id>    -> CompleteMultipartUpload (Segmenter.CompleteSegment)
+  - DELETE /<bucket>/<key>?uploadId=<id>    -> AbortMultipartUpload (Segmenter.AbortSegment)
+
+Other APIs will get a "NotImplemented" error.
+
+Multipart upload is only available while the Storager implements Segmenter. Parts could be uploaded in any
+order, and they will be staged in local temp files until completed. Segmenter needs a fixed part size while
+S3 doesn't, so the size of part 1 will be used as part size: all parts except the last one MUST have the same
+size, and all uploaded parts MUST be completed in order. Upload states are kept in memory, so in-progress
+uploads will be lost after gateway restarted. Uploads not finished within Gateway.UploadTTL (24 hours by
+default) will be aborted and their staged parts removed.
+
+If a Verifier is given, every request must be signed by AWS Signature Version 4 with the same credential,
+both Authorization header and presigned url (expires up to 7 days) are supported. Signed payload will be
+spooled into a local temp file and checked against X-Amz-Content-Sha256 before written, "aws-chunked"
+encoded payload is not supported.
+*/
+package s3gateway
@@ -0,0 +1,278 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+)
+
+// Gateway will serve a Storager or Servicer via S3 compatible API.
+type Gateway struct {
+	// service will be nil if gateway only serves a single storager.
+	service storage.Servicer
+	store   storage.Storager
+	bucket  string
+
+	verifier *Verifier
+
+	// UploadTTL is the time after which an unfinished multipart upload and its staged parts will be removed,
+	// 24 hours will be used if not set.
+	UploadTTL time.Duration
+
+	uploads map[string]*upload
+	l       sync.Mutex
+}
+
+// New will create a gateway which serves store as bucket, verifier could be nil if signature is not required.
+func New(store storage.Storager, bucket string, verifier *Verifier) *Gateway {
+	return &Gateway{
+		store:    store,
+		bucket:   bucket,
+		verifier: verifier,
+		uploads:  make(map[string]*upload),
+	}
+}
+
+// NewService will create a gateway which serves every storager in srv as a bucket, verifier could be nil
+// if signature is not required.
+func NewService(srv storage.Servicer, verifier *Verifier) *Gateway {
+	return &Gateway{
+		service:  srv,
+		verifier: verifier,
+		uploads:  make(map[string]*upload),
+	}
+}
+
+// String implements Stringer.
+func (g *Gateway) String() string {
+	if g.service != nil {
+		return fmt.Sprintf("Gateway {Servicer: %s}", g.service)
+	}
+	return fmt.Sprintf("Gateway {Storager: %s, Bucket: %s}", g.store, g.bucket)
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.verifier != nil {
+		err := g.verifier.Verify(r)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		cleanup, err := g.verifier.VerifyPayload(r)
+		defer cleanup()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	bucket, key := splitPath(r.URL.Path)
+	if !validKey(bucket) || !validKey(key) {
+		writeError(w, r, errInvalidObjectName)
+		return
+	}
+	query := r.URL.Query()
+	_, hasUploads := query["uploads"]
+	_, hasUploadID := query["uploadId"]
+	_, hasLocation := query["location"]
+
+	if bucket == "" {
+		if r.Method == http.MethodGet {
+			g.listBuckets(w, r)
+			return
+		}
+		writeError(w, r, errNotImplemented)
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodPut:
+		g.createBucket(w, r, bucket)
+		return
+	case key == "" && r.Method == http.MethodDelete:
+		g.deleteBucket(w, r, bucket)
+		return
+	}
+
+	store, err := g.getStorager(bucket)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet && hasLocation:
+		g.getBucketLocation(w, r, store)
+	case key == "" && r.Method == http.MethodGet && query.Get("list-type") == "2":
+		g.listObjectsV2(w, r, store, bucket)
+	case key == "":
+		writeError(w, r, errNotImplemented)
+	case r.Method == http.MethodGet:
+		g.getObject(w, r, store, key)
+	case r.Method == http.MethodHead:
+		g.headObject(w, r, store, key)
+	case r.Method == http.MethodPut && hasUploadID:
+		g.uploadPart(w, r, bucket, key)
+	case r.Method == http.MethodPut:
+		g.putObject(w, r, store, key)
+	case r.Method == http.MethodPost && hasUploads:
+		g.createMultipartUpload(w, r, store, bucket, key)
+	case r.Method == http.MethodPost && hasUploadID:
+		g.completeMultipartUpload(w, r, bucket, key)
+	case r.Method == http.MethodDelete && hasUploadID:
+		g.abortMultipartUpload(w, r, bucket, key)
+	case r.Method == http.MethodDelete:
+		g.deleteObject(w, r, store, key)
+	default:
+		writeError(w, r, errNotImplemented)
+	}
+}
+
+// getStorager will get the storager for bucket.
+func (g *Gateway) getStorager(bucket string) (storage.Storager, error) {
+	if g.service == nil {
+		if bucket != g.bucket {
+			return nil, errNoSuchBucket
+		}
+		return g.store, nil
+	}
+
+	store, err := g.service.Get(bucket)
+	if errors.Is(err, types.ErrObjectNotExist) {
+		return nil, errNoSuchBucket
+	}
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// splitPath will split path style request path into bucket and key.
+func splitPath(path string) (bucket, key string) {
+	x := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(x) == 1 {
+		return x[0], ""
+	}
+	return x[0], x[1]
+}
+
+// validKey will check whether key contains "." or ".." segments, which could escape the bucket in services
+// like fs.
+func validKey(key string) bool {
+	for _, v := range strings.Split(key, "/") {
+		if v == "." || v == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// apiError is the error which carries S3 error code and HTTP status code.
+type apiError struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// All S3 errors that gateway could return directly.
+var (
+	errNoSuchBucket         = &apiError{"NoSuchBucket", http.StatusNotFound, "The specified bucket does not exist."}
+	errNoSuchKey            = &apiError{"NoSuchKey", http.StatusNotFound, "The specified key does not exist."}
+	errNoSuchUpload         = &apiError{"NoSuchUpload", http.StatusNotFound, "The specified multipart upload does not exist."}
+	errNotImplemented       = &apiError{"NotImplemented", http.StatusNotImplemented, "A header or query you provided implies functionality that is not implemented."}
+	errInvalidArgument      = &apiError{"InvalidArgument", http.StatusBadRequest, "Invalid argument."}
+	errInvalidObjectName    = &apiError{"InvalidObjectName", http.StatusBadRequest, "The specified key is not valid."}
+	errInvalidRange         = &apiError{"InvalidRange", http.StatusRequestedRangeNotSatisfiable, "The requested range is not satisfiable."}
+	errMalformedXML         = &apiError{"MalformedXML", http.StatusBadRequest, "The XML you provided was not well-formed."}
+	errMissingContentLength = &apiError{"MissingContentLength", http.StatusLengthRequired, "You must provide the Content-Length HTTP header."}
+	errPreconditionFailed   = &apiError{"PreconditionFailed", http.StatusPreconditionFailed, "At least one of the preconditions you specified did not hold."}
+	errInvalidPart          = &apiError{"InvalidPart", http.StatusBadRequest, "One or more of the specified parts could not be found or completed."}
+	errInvalidPartOrder     = &apiError{"InvalidPartOrder", http.StatusBadRequest, "The list of parts was not in ascending order."}
+	errEntityTooSmall       = &apiError{"EntityTooSmall", http.StatusBadRequest, "All parts except the last one must have the same size as the first part."}
+	errEntityTooLarge       = &apiError{"EntityTooLarge", http.StatusBadRequest, "Part must not be larger than the first part."}
+)
+
+// toAPIError will convert err into S3 error.
+func toAPIError(err error) *apiError {
+	var e *apiError
+	if errors.As(err, &e) {
+		return e
+	}
+
+	code, status := "InternalError", http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrSignatureMissing):
+		code, status = "AccessDenied", http.StatusForbidden
+	case errors.Is(err, ErrSignatureInvalid):
+		code, status = "SignatureDoesNotMatch", http.StatusForbidden
+	case errors.Is(err, ErrSignatureExpired):
+		code, status = "RequestTimeTooSkewed", http.StatusForbidden
+	case errors.Is(err, ErrAccessKeyInvalid):
+		code, status = "InvalidAccessKeyId", http.StatusForbidden
+	case errors.Is(err, ErrContentSHA256Mismatch):
+		code, status = "XAmzContentSHA256Mismatch", http.StatusBadRequest
+	case errors.Is(err, types.ErrObjectNotExist):
+		code, status = "NoSuchKey", http.StatusNotFound
+	case errors.Is(err, types.ErrPermissionDenied), errors.Is(err, types.ErrReadOnly):
+		code, status = "AccessDenied", http.StatusForbidden
+	case errors.Is(err, types.ErrPreconditionFailed):
+		code, status = "PreconditionFailed", http.StatusPreconditionFailed
+	case errors.Is(err, types.ErrObjectAlreadyExist), errors.Is(err, types.ErrDirAlreadyExist):
+		code, status = "BucketAlreadyExists", http.StatusConflict
+	case errors.Is(err, types.ErrDirNotEmpty):
+		code, status = "BucketNotEmpty", http.StatusConflict
+	case errors.Is(err, types.ErrInvalidPath):
+		code, status = "InvalidObjectName", http.StatusBadRequest
+	case errors.Is(err, types.ErrPairRequired):
+		code, status = "InvalidRequest", http.StatusBadRequest
+	case errors.Is(err, types.ErrNotSupported):
+		code, status = "NotImplemented", http.StatusNotImplemented
+	case errors.Is(err, types.ErrQuotaExceeded):
+		code, status = "EntityTooLarge", http.StatusBadRequest
+	case errors.Is(err, types.ErrRateLimited):
+		code, status = "SlowDown", http.StatusServiceUnavailable
+	case errors.Is(err, types.ErrServiceUnavailable):
+		code, status = "ServiceUnavailable", http.StatusServiceUnavailable
+	}
+	return &apiError{Code: code, Status: status, Message: err.Error()}
+}
+
+// writeError will write err as S3 error response.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e := toAPIError(err)
+
+	// HEAD response doesn't have body.
+	if r.Method == http.MethodHead {
+		w.WriteHeader(e.Status)
+		return
+	}
+	writeXML(w, e.Status, &errorResponse{
+		Code:     e.Code,
+		Message:  e.Message,
+		Resource: r.URL.Path,
+	})
+}
+
+// writeXML will write v as XML response.
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,668 @@
+package s3gateway
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/segment"
+	"github.com/Xuanwo/storage/services/fs"
+	s3service "github.com/Xuanwo/storage/services/s3"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+const (
+	testBucket    = "test"
+	testAccessKey = "test_access_key"
+	testSecretKey = "test_secret_key"
+)
+
+// segmentStorage is a fs storager with in memory Segmenter for test.
+type segmentStorage struct {
+	*fs.Storage
+
+	segments map[string]*segment.Segment
+	data     map[string]map[int64][]byte
+}
+
+func (s *segmentStorage) ListSegments(path string, ps ...*types.Pair) error {
+	return nil
+}
+
+func (s *segmentStorage) InitSegment(path string, ps ...*types.Pair) (string, error) {
+	var partSize int64
+	for _, v := range ps {
+		if v.Key == pairs.PartSize {
+			partSize = v.Value.(int64)
+		}
+	}
+	id := uuid.New().String()
+	s.segments[id] = segment.NewSegment(path, id, partSize)
+	s.data[id] = make(map[int64][]byte)
+	return id, nil
+}
+
+func (s *segmentStorage) WriteSegment(id string, offset, size int64, r io.Reader, ps ...*types.Pair) error {
+	seg, ok := s.segments[id]
+	if !ok {
+		return segment.ErrSegmentNotInitiated
+	}
+	if _, err := seg.InsertPart(offset, size); err != nil {
+		return err
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.data[id][offset] = b
+	return nil
+}
+
+func (s *segmentStorage) CompleteSegment(id string, ps ...*types.Pair) error {
+	seg, ok := s.segments[id]
+	if !ok {
+		return segment.ErrSegmentNotInitiated
+	}
+	if err := seg.ValidateParts(); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	for _, p := range seg.SortedParts() {
+		buf.Write(s.data[id][p.Offset])
+	}
+	delete(s.segments, id)
+	return s.Write(seg.Path, buf, pairs.WithSize(int64(buf.Len())))
+}
+
+func (s *segmentStorage) AbortSegment(id string, ps ...*types.Pair) error {
+	if _, ok := s.segments[id]; !ok {
+		return segment.ErrSegmentNotInitiated
+	}
+	delete(s.segments, id)
+	return nil
+}
+
+func newTestGateway(t *testing.T) (dir string, srv *httptest.Server) {
+	dir, err := ioutil.TempDir("", "s3gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := New(&segmentStorage{
+		Storage:  store,
+		segments: make(map[string]*segment.Segment),
+		data:     make(map[string]map[int64][]byte),
+	}, testBucket, NewVerifier(testAccessKey, testSecretKey))
+	return dir, httptest.NewServer(g)
+}
+
+func newTestStorager(t *testing.T, url, secretKey string) storage.Storager {
+	cred, err := credential.NewHmac(testAccessKey, secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep, err := endpoint.ParseURL(url + "?style=path")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := s3service.New(pairs.WithCredential(cred), pairs.WithEndpoint(ep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := srv.Get(testBucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func newTestClient(url string) *s3.S3 {
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials(testAccessKey, testSecretKey, "")).
+		WithEndpoint(url).
+		WithRegion("us-east-1").
+		WithS3ForcePathStyle(true)))
+	return s3.New(sess)
+}
+
+func TestGatewayWithS3Service(t *testing.T) {
+	_ = os.Setenv("AWS_REGION", "us-east-1")
+	defer os.Unsetenv("AWS_REGION")
+
+	dir, srv := newTestGateway(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	store := newTestStorager(t, srv.URL, testSecretKey)
+	content := []byte("0123456789")
+
+	t.Run("write", func(t *testing.T) {
+		err := store.Write("dir/test file", bytes.NewReader(content),
+			pairs.WithSize(int64(len(content))),
+			pairs.WithContentType("text/plain"),
+		)
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "dir", "test file"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		o, err := store.Stat("dir/test file")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), o.Size)
+	})
+
+	t.Run("read with range", func(t *testing.T) {
+		r, err := store.Read("dir/test file", pairs.WithOffset(2), pairs.WithSize(3))
+		assert.NoError(t, err)
+		defer r.Close()
+
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "234", string(b))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		names := make([]string, 0)
+		err := store.List("", pairs.WithFileFunc(func(o *types.Object) {
+			names = append(names, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"dir/test file"}, names)
+	})
+
+	t.Run("reach", func(t *testing.T) {
+		url, err := store.(storage.Reacher).Reach("dir/test file", pairs.WithExpire(100))
+		assert.NoError(t, err)
+
+		resp, err := http.Get(url)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := store.Delete("dir/test file")
+		assert.NoError(t, err)
+
+		_, err = store.Stat("dir/test file")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		store := newTestStorager(t, srv.URL, "invalid_secret_key")
+
+		_, err := store.Stat("dir/test file")
+		assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+	})
+}
+
+func TestGatewayListObjectsV2(t *testing.T) {
+	dir, srv := newTestGateway(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	client := newTestClient(srv.URL)
+	for _, v := range []string{"a", "b/c", "b/d/e", "bc"} {
+		_, err := client.PutObject(&s3.PutObjectInput{
+			Bucket: aws.String(testBucket),
+			Key:    aws.String(v),
+			Body:   bytes.NewReader([]byte(v)),
+		})
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		delimiter string
+		keys      []string
+		prefixes  []string
+	}{
+		{"all", "", "", []string{"a", "b/c", "b/d/e", "bc"}, nil},
+		{"with delimiter", "", "/", []string{"a", "bc"}, []string{"b/"}},
+		{"with prefix", "b", "", []string{"b/c", "b/d/e", "bc"}, nil},
+		{"with prefix and delimiter", "b/", "/", []string{"b/c"}, []string{"b/d/"}},
+		{"not exist", "x/", "/", nil, nil},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			input := &s3.ListObjectsV2Input{
+				Bucket:  aws.String(testBucket),
+				Prefix:  aws.String(v.prefix),
+				MaxKeys: aws.Int64(1),
+			}
+			if v.delimiter != "" {
+				input.Delimiter = aws.String(v.delimiter)
+			}
+
+			var keys, prefixes []string
+			err := client.ListObjectsV2Pages(input, func(output *s3.ListObjectsV2Output, last bool) bool {
+				for _, o := range output.Contents {
+					keys = append(keys, aws.StringValue(o.Key))
+				}
+				for _, p := range output.CommonPrefixes {
+					prefixes = append(prefixes, aws.StringValue(p.Prefix))
+				}
+				return true
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, v.keys, keys)
+			assert.Equal(t, v.prefixes, prefixes)
+		})
+	}
+}
+
+func TestGatewayMultipartUpload(t *testing.T) {
+	dir, srv := newTestGateway(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	client := newTestClient(srv.URL)
+	parts := [][]byte{[]byte("01234"), []byte("56789"), []byte("ab")}
+
+	output, err := client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String("multipart"),
+	})
+	assert.NoError(t, err)
+
+	completed := make([]*s3.CompletedPart, 0)
+	for i, v := range parts {
+		resp, err := client.UploadPart(&s3.UploadPartInput{
+			Bucket:     aws.String(testBucket),
+			Key:        aws.String("multipart"),
+			UploadId:   output.UploadId,
+			PartNumber: aws.Int64(int64(i + 1)),
+			Body:       bytes.NewReader(v),
+		})
+		assert.NoError(t, err)
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       resp.ETag,
+			PartNumber: aws.Int64(int64(i + 1)),
+		})
+	}
+
+	t.Run("invalid part", func(t *testing.T) {
+		_, err := client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(testBucket),
+			Key:             aws.String("multipart"),
+			UploadId:        output.UploadId,
+			MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed[1:]},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		_, err := client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(testBucket),
+			Key:             aws.String("multipart"),
+			UploadId:        output.UploadId,
+			MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+		})
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "multipart"))
+		assert.NoError(t, err)
+		assert.Equal(t, "0123456789ab", string(b))
+	})
+
+	t.Run("no such upload", func(t *testing.T) {
+		_, err := client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(testBucket),
+			Key:      aws.String("multipart"),
+			UploadId: output.UploadId,
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	o := &types.Object{Name: "test"}
+	o.Metadata = make(metadata.Metadata)
+	o.SetChecksum(`"abc"`)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{"none", nil, 0},
+		{"if-match", map[string]string{"If-Match": `"abc"`}, 0},
+		{"if-match failed", map[string]string{"If-Match": `"def"`}, http.StatusPreconditionFailed},
+		{"if-match any", map[string]string{"If-Match": "*"}, 0},
+		{"if-none-match", map[string]string{"If-None-Match": `"def", "abc"`}, http.StatusNotModified},
+		{"if-none-match passed", map[string]string{"If-None-Match": `"def"`}, 0},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/test/test", nil)
+			for k, h := range v.header {
+				r.Header.Set(k, h)
+			}
+			assert.Equal(t, v.status, checkPreconditions(r, o))
+		})
+	}
+}
+
+func TestGatewayWithDotDot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secret := filepath.Join(dir, "secret.txt")
+	err = ioutil.WriteFile(secret, []byte("secret"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(filepath.Join(dir, "root")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(store, testBucket, nil)
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		for _, path := range []string{
+			"/" + testBucket + "/../secret.txt",
+			"/" + testBucket + "/a/../../secret.txt",
+		} {
+			r := httptest.NewRequest(method, path, bytes.NewReader([]byte("overwritten")))
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, r)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%s %s", method, path)
+			assert.Contains(t, w.Body.String(), "InvalidObjectName")
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/"+testBucket+"?list-type=2&prefix=../", nil)
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	content, err := ioutil.ReadFile(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(content))
+}
+
+func TestGatewayWithPayloadMismatch(t *testing.T) {
+	dir, srv := newTestGateway(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	signer := v4.NewSigner(credentials.NewStaticCredentials(testAccessKey, testSecretKey, ""))
+
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/"+testBucket+"/replayed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = signer.Sign(req, bytes.NewReader([]byte("signed")), "s3", "us-east-1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay the signed request with another payload.
+	req.Body = ioutil.NopCloser(bytes.NewReader([]byte("forged")))
+	req.ContentLength = 6
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, string(content), "XAmzContentSHA256Mismatch")
+
+	_, err = os.Stat(filepath.Join(dir, "replayed"))
+	assert.True(t, os.IsNotExist(err))
+
+	req.Header.Set("X-Amz-Content-Sha256", "STREAMING-AWS4-HMAC-SHA256-PAYLOAD")
+	_, err = signer.Sign(req, nil, "s3", "us-east-1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader([]byte("forged")))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+func TestGatewayMultipartUploadOutOfOrder(t *testing.T) {
+	dir, srv := newTestGateway(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	client := newTestClient(srv.URL)
+	parts := [][]byte{[]byte("01234"), []byte("56789"), []byte("ab")}
+
+	output, err := client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String("multipart"),
+	})
+	assert.NoError(t, err)
+
+	// The short last part arrives first, as SDKs upload parts concurrently.
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i := len(parts) - 1; i >= 0; i-- {
+		resp, err := client.UploadPart(&s3.UploadPartInput{
+			Bucket:     aws.String(testBucket),
+			Key:        aws.String("multipart"),
+			UploadId:   output.UploadId,
+			PartNumber: aws.Int64(int64(i + 1)),
+			Body:       bytes.NewReader(parts[i]),
+		})
+		assert.NoError(t, err)
+		completed[i] = &s3.CompletedPart{
+			ETag:       resp.ETag,
+			PartNumber: aws.Int64(int64(i + 1)),
+		}
+	}
+
+	_, err = client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(testBucket),
+		Key:             aws.String("multipart"),
+		UploadId:        output.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "multipart"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789ab", string(b))
+}
+
+func TestGatewayPutWithPreconditions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// fs doesn't support if_match in Write, and doesn't have etag.
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(store, testBucket, nil)
+
+	put := func(header, value, content string) int {
+		r := httptest.NewRequest(http.MethodPut, "/"+testBucket+"/test", bytes.NewReader([]byte(content)))
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusPreconditionFailed, put("If-Match", "*", "a"))
+	assert.Equal(t, http.StatusOK, put("If-None-Match", "*", "b"))
+	assert.Equal(t, http.StatusPreconditionFailed, put("If-None-Match", "*", "c"))
+	assert.Equal(t, http.StatusPreconditionFailed, put("If-Match", `"0cc175b9c0f1b6a831c399e269772661"`, "d"))
+	assert.Equal(t, http.StatusOK, put("If-Match", "*", "e"))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "test"))
+	assert.NoError(t, err)
+	assert.Equal(t, "e", string(content))
+}
+
+func TestGatewayMultipartUploadExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(&segmentStorage{
+		Storage:  store,
+		segments: make(map[string]*segment.Segment),
+		data:     make(map[string]map[int64][]byte),
+	}, testBucket, nil)
+	g.UploadTTL = 100 * time.Millisecond
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	client := newTestClient(srv.URL)
+	output, err := client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String("multipart"),
+	})
+	assert.NoError(t, err)
+	_, err = client.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String(testBucket),
+		Key:        aws.String("multipart"),
+		UploadId:   output.UploadId,
+		PartNumber: aws.Int64(1),
+		Body:       bytes.NewReader([]byte("01234")),
+	})
+	assert.NoError(t, err)
+
+	g.l.Lock()
+	u := g.uploads[*output.UploadId]
+	g.l.Unlock()
+	u.l.Lock()
+	staged := u.parts[1].path
+	u.l.Unlock()
+
+	assert.Eventually(t, func() bool {
+		g.l.Lock()
+		defer g.l.Unlock()
+		return len(g.uploads) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	u.l.Lock()
+	assert.True(t, u.done)
+	u.l.Unlock()
+	_, err = os.Stat(staged)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = client.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String(testBucket),
+		Key:        aws.String("multipart"),
+		UploadId:   output.UploadId,
+		PartNumber: aws.Int64(2),
+		Body:       bytes.NewReader([]byte("56789")),
+	})
+	assert.Error(t, err)
+}
+
+func TestUploadAddPartAfterDone(t *testing.T) {
+	u := &upload{parts: make(map[int]*part)}
+
+	p, err := stagePart(bytes.NewReader([]byte("01234")), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload is aborted while the part is being staged.
+	u.l.Lock()
+	u.clean()
+	u.l.Unlock()
+
+	err = u.addPart(1, p)
+	assert.True(t, errors.Is(err, errNoSuchUpload))
+	assert.Empty(t, u.parts)
+	_, err = os.Stat(p.path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestVerifierPresignExpires(t *testing.T) {
+	signer := v4.NewSigner(credentials.NewStaticCredentials(testAccessKey, testSecretKey, ""))
+	v := NewVerifier(testAccessKey, testSecretKey)
+
+	cases := []struct {
+		name    string
+		expires time.Duration
+		err     error
+	}{
+		{"seven days", 7 * 24 * time.Hour, nil},
+		{"more than seven days", 7*24*time.Hour + time.Second, ErrSignatureInvalid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1/"+testBucket+"/object", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = signer.Presign(req, nil, "s3", "us-east-1", tt.expires, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = v.Verify(req)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.err))
+			}
+		})
+	}
+}
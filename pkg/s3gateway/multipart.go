@@ -0,0 +1,337 @@
+package s3gateway
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+const (
+	// maxPartNumber is the max part number that S3 allows.
+	maxPartNumber = 10000
+	// defaultUploadTTL is the time after which an unfinished upload will be aborted if Gateway.UploadTTL is not set.
+	defaultUploadTTL = 24 * time.Hour
+)
+
+// upload is an in-progress multipart upload.
+//
+// Parts could be uploaded in any order and concurrently, so they will be staged in local temp files, and
+// written into Segmenter while completing, when the part size is known.
+type upload struct {
+	bucket string
+	key    string
+	store  storage.Segmenter
+
+	parts map[int]*part
+	// done will be true after the upload is completed, aborted or expired.
+	done  bool
+	timer *time.Timer
+
+	l sync.Mutex
+}
+
+// part is an uploaded part staged in a local temp file.
+type part struct {
+	size int64
+	md5  []byte
+	path string
+}
+
+// clean will remove all staged parts and mark the upload as done, caller must hold u.l.
+func (u *upload) clean() {
+	if u.done {
+		return
+	}
+	u.done = true
+	if u.timer != nil {
+		u.timer.Stop()
+	}
+	for _, v := range u.parts {
+		_ = os.Remove(v.path)
+	}
+}
+
+// addPart will add a staged part as part number n, the part will be removed if the upload is already done.
+func (u *upload) addPart(n int, p *part) error {
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	// The upload could be finished while the part is being staged.
+	if u.done {
+		_ = os.Remove(p.path)
+		return errNoSuchUpload
+	}
+	// Part could be uploaded again, and only the last one will be kept.
+	if old, ok := u.parts[n]; ok {
+		_ = os.Remove(old.path)
+	}
+	u.parts[n] = p
+	return nil
+}
+
+func (g *Gateway) createMultipartUpload(w http.ResponseWriter, r *http.Request, store storage.Storager, bucket, key string) {
+	seg, ok := store.(storage.Segmenter)
+	if !ok {
+		writeError(w, r, errNotImplemented)
+		return
+	}
+
+	id := uuid.New().String()
+	u := &upload{
+		bucket: bucket,
+		key:    key,
+		store:  seg,
+		parts:  make(map[int]*part),
+	}
+
+	ttl := g.UploadTTL
+	if ttl <= 0 {
+		ttl = defaultUploadTTL
+	}
+
+	g.l.Lock()
+	g.uploads[id] = u
+	u.timer = time.AfterFunc(ttl, func() { g.expireUpload(id, u) })
+	g.l.Unlock()
+
+	writeXML(w, http.StatusOK, &initiateMultipartUploadResult{
+		Xmlns:    xmlns,
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: id,
+	})
+}
+
+func (g *Gateway) uploadPart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	u, err := g.getUpload(r, bucket, key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	n, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || n < 1 || n > maxPartNumber {
+		writeError(w, r, errInvalidArgument)
+		return
+	}
+	if !checkPayload(w, r) {
+		return
+	}
+	size := r.ContentLength
+	if size == 0 {
+		writeError(w, r, errInvalidArgument)
+		return
+	}
+
+	p, err := stagePart(r.Body, size)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = u.addPart(n, p)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+hex.EncodeToString(p.md5)+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// stagePart will copy size bytes from r into a local temp file.
+func stagePart(r io.Reader, size int64) (p *part, err error) {
+	f, err := ioutil.TempFile("", "s3gateway-part")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	h := md5.New()
+	_, err = io.CopyN(io.MultiWriter(f, h), r, size)
+	if err != nil {
+		return nil, err
+	}
+	return &part{size: size, md5: h.Sum(nil), path: f.Name()}, nil
+}
+
+func (g *Gateway) completeMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	u, err := g.getUpload(r, bucket, key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req := &completeMultipartUpload{}
+	if err = xml.NewDecoder(r.Body).Decode(req); err != nil || len(req.Parts) == 0 {
+		writeError(w, r, errMalformedXML)
+		return
+	}
+
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	if u.done {
+		writeError(w, r, errNoSuchUpload)
+		return
+	}
+
+	// Parts must be exactly the same as uploaded, so that no staged part will be left.
+	if len(req.Parts) != len(u.parts) {
+		writeError(w, r, errInvalidPart)
+		return
+	}
+
+	// Segmenter needs a fixed part size, so the size of the first part will be used.
+	partSize := int64(0)
+	parts := make([]*part, 0, len(req.Parts))
+	// Multipart upload's ETag is the md5 of all parts' md5 with the count of parts.
+	h := md5.New()
+	for i, v := range req.Parts {
+		if i > 0 && v.PartNumber <= req.Parts[i-1].PartNumber {
+			writeError(w, r, errInvalidPartOrder)
+			return
+		}
+		p, ok := u.parts[v.PartNumber]
+		if !ok || v.PartNumber != i+1 || strings.Trim(v.ETag, `"`) != hex.EncodeToString(p.md5) {
+			writeError(w, r, errInvalidPart)
+			return
+		}
+		if i == 0 {
+			partSize = p.size
+		}
+		if i != len(req.Parts)-1 && p.size != partSize {
+			writeError(w, r, errEntityTooSmall)
+			return
+		}
+		if p.size > partSize {
+			writeError(w, r, errEntityTooLarge)
+			return
+		}
+		parts = append(parts, p)
+		_, _ = h.Write(p.md5)
+	}
+
+	err = writeParts(u.store, u.key, partSize, parts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	u.clean()
+	g.deleteUpload(r)
+
+	writeXML(w, http.StatusOK, &completeMultipartUploadResult{
+		Xmlns:    xmlns,
+		Location: "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(h.Sum(nil)), len(req.Parts)),
+	})
+}
+
+// writeParts will write staged parts into path of s in order, the segment will be aborted if failed.
+func writeParts(s storage.Segmenter, path string, partSize int64, parts []*part) (err error) {
+	id, err := s.InitSegment(path, pairs.WithPartSize(partSize))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = s.AbortSegment(id)
+		}
+	}()
+
+	for i, p := range parts {
+		err = writePart(s, id, int64(i)*partSize, p)
+		if err != nil {
+			return err
+		}
+	}
+	return s.CompleteSegment(id)
+}
+
+func writePart(s storage.Segmenter, id string, offset int64, p *part) (err error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return s.WriteSegment(id, offset, p.size, f)
+}
+
+func (g *Gateway) abortMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	u, err := g.getUpload(r, bucket, key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	if u.done {
+		writeError(w, r, errNoSuchUpload)
+		return
+	}
+	u.clean()
+	g.deleteUpload(r)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getUpload will get the upload for request's uploadId.
+func (g *Gateway) getUpload(r *http.Request, bucket, key string) (*upload, error) {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	u, ok := g.uploads[r.URL.Query().Get("uploadId")]
+	if !ok || u.bucket != bucket || u.key != key {
+		return nil, errNoSuchUpload
+	}
+	return u, nil
+}
+
+// deleteUpload will delete the upload for request's uploadId.
+func (g *Gateway) deleteUpload(r *http.Request) {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	delete(g.uploads, r.URL.Query().Get("uploadId"))
+}
+
+// expireUpload will abort the upload which is not finished in time.
+func (g *Gateway) expireUpload(id string, u *upload) {
+	g.l.Lock()
+	if g.uploads[id] == u {
+		delete(g.uploads, id)
+	}
+	g.l.Unlock()
+
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	u.clean()
+}
@@ -0,0 +1,326 @@
+package s3gateway
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/server"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// userMetadataPrefix is the header prefix for user metadata.
+const userMetadataPrefix = "X-Amz-Meta-"
+
+func (g *Gateway) getObject(w http.ResponseWriter, r *http.Request, store storage.Storager, key string) {
+	o, ok := g.statObject(w, r, store, key)
+	if !ok {
+		return
+	}
+
+	offset, size, ok := server.ParseRange(r.Header.Get("Range"), o.Size)
+	if !ok {
+		writeError(w, r, errInvalidRange)
+		return
+	}
+
+	ps := make([]*types.Pair, 0)
+	status := http.StatusOK
+	if offset != 0 || size != o.Size {
+		ps = append(ps, pairs.WithOffset(offset), pairs.WithSize(size))
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+size-1, o.Size))
+	}
+
+	rc, err := store.Read(key, ps...)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer rc.Close()
+
+	setObjectHeader(w, o)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(status)
+	_, _ = io.CopyN(w, rc, size)
+}
+
+func (g *Gateway) headObject(w http.ResponseWriter, r *http.Request, store storage.Storager, key string) {
+	o, ok := g.statObject(w, r, store, key)
+	if !ok {
+		return
+	}
+
+	setObjectHeader(w, o)
+	w.Header().Set("Content-Length", strconv.FormatInt(o.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// statObject will stat key and check preconditions, response will be written if ok is false.
+func (g *Gateway) statObject(w http.ResponseWriter, r *http.Request, store storage.Storager, key string) (o *types.Object, ok bool) {
+	o, err := store.Stat(key)
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	if o.Type == types.ObjectTypeDir {
+		writeError(w, r, errNoSuchKey)
+		return nil, false
+	}
+
+	switch checkPreconditions(r, o) {
+	case http.StatusNotModified:
+		setObjectHeader(w, o)
+		w.WriteHeader(http.StatusNotModified)
+		return nil, false
+	case http.StatusPreconditionFailed:
+		writeError(w, r, errPreconditionFailed)
+		return nil, false
+	}
+	return o, true
+}
+
+func (g *Gateway) putObject(w http.ResponseWriter, r *http.Request, store storage.Storager, key string) {
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		writeError(w, r, errNotImplemented)
+		return
+	}
+	if !checkPayload(w, r) {
+		return
+	}
+
+	err := checkWritePreconditions(r, store, key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ps := append(parseWriteHeader(r.Header), pairs.WithSize(r.ContentLength))
+	cps := make([]*types.Pair, 0, 2)
+	if v := r.Header.Get("If-Match"); v != "" {
+		cps = append(cps, pairs.WithIfMatch(v))
+	}
+	if v := r.Header.Get("If-None-Match"); v != "" {
+		cps = append(cps, pairs.WithIfNoneMatch(v))
+	}
+
+	h := md5.New()
+	body := &countReader{r: io.TeeReader(r.Body, h)}
+	err = store.Write(key, body, append(ps, cps...)...)
+	// Preconditions have been checked via Stat, write again without them if the service doesn't support them
+	// and the body is untouched.
+	if len(cps) > 0 && errors.Is(err, types.ErrNotSupported) && body.n == 0 {
+		err = store.Write(key, body, ps...)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", `"`+hex.EncodeToString(h.Sum(nil))+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkWritePreconditions will check If-Match and If-None-Match against the existing object.
+//
+// Not all services support if_match and if_none_match in Write, unsupported pairs will be ignored or rejected
+// with ErrNotSupported, so they will be checked here via Stat. The pairs will still be passed to Write, so that
+// services which support them could check atomically.
+func checkWritePreconditions(r *http.Request, store storage.Storager, key string) error {
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if ifMatch == "" && ifNoneMatch == "" {
+		return nil
+	}
+
+	o, err := store.Stat(key)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+	exist := err == nil && o.Type != types.ObjectTypeDir
+
+	// Object without etag could only match "*".
+	match := func(header string) bool {
+		if !exist {
+			return false
+		}
+		if etag, ok := o.GetChecksum(); ok {
+			return matchETag(header, etag)
+		}
+		return matchETag(header, "*")
+	}
+	if ifMatch != "" && !match(ifMatch) {
+		return errPreconditionFailed
+	}
+	if ifNoneMatch != "" && match(ifNoneMatch) {
+		return errPreconditionFailed
+	}
+	return nil
+}
+
+// countReader will count bytes read from r.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (g *Gateway) deleteObject(w http.ResponseWriter, r *http.Request, store storage.Storager, key string) {
+	err := store.Delete(key)
+	// S3 will not return error while deleting a not exist key.
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkPayload will check whether request's payload could be handled, response will be written if not.
+func checkPayload(w http.ResponseWriter, r *http.Request) bool {
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		writeError(w, r, errNotImplemented)
+		return false
+	}
+	if r.ContentLength < 0 {
+		writeError(w, r, errMissingContentLength)
+		return false
+	}
+	return true
+}
+
+// parseWriteHeader will parse request headers into pairs for Write.
+func parseWriteHeader(h http.Header) []*types.Pair {
+	ps := make([]*types.Pair, 0)
+	if v := h.Get("Content-Type"); v != "" {
+		ps = append(ps, pairs.WithContentType(v))
+	}
+	if v := h.Get("Content-Encoding"); v != "" {
+		ps = append(ps, pairs.WithContentEncoding(v))
+	}
+	if v := h.Get("Content-Disposition"); v != "" {
+		ps = append(ps, pairs.WithContentDisposition(v))
+	}
+	if v := h.Get("Cache-Control"); v != "" {
+		ps = append(ps, pairs.WithCacheControl(v))
+	}
+	if v := h.Get("X-Amz-Storage-Class"); v != "" {
+		ps = append(ps, pairs.WithStorageClass(v))
+	}
+
+	m := make(map[string]string)
+	for k := range h {
+		if strings.HasPrefix(k, userMetadataPrefix) {
+			m[strings.ToLower(strings.TrimPrefix(k, userMetadataPrefix))] = h.Get(k)
+		}
+	}
+	if len(m) > 0 {
+		ps = append(ps, pairs.WithUserMetadata(m))
+	}
+	return ps
+}
+
+// setObjectHeader will set object's metadata into response headers.
+func setObjectHeader(w http.ResponseWriter, o *types.Object) {
+	h := w.Header()
+	h.Set("Accept-Ranges", "bytes")
+	if !o.UpdatedAt.IsZero() {
+		h.Set("Last-Modified", o.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if v, ok := o.GetChecksum(); ok {
+		h.Set("ETag", formatETag(v))
+	}
+	if v, ok := o.GetType(); ok {
+		h.Set("Content-Type", v)
+	}
+	if v, ok := o.GetContentEncoding(); ok {
+		h.Set("Content-Encoding", v)
+	}
+	if v, ok := o.GetContentDisposition(); ok {
+		h.Set("Content-Disposition", v)
+	}
+	if v, ok := o.GetCacheControl(); ok {
+		h.Set("Cache-Control", v)
+	}
+	if v, ok := o.GetClass(); ok {
+		h.Set("X-Amz-Storage-Class", v)
+	}
+	if m, ok := o.GetUserMetadata(); ok {
+		for k, v := range m {
+			h.Set(userMetadataPrefix+k, v)
+		}
+	}
+}
+
+// formatObjectEntry will format object into an entry in list response.
+func formatObjectEntry(key string, o *types.Object) objectEntry {
+	e := objectEntry{
+		Key:          key,
+		LastModified: o.UpdatedAt.UTC().Format(timeFormat),
+		Size:         o.Size,
+		StorageClass: "STANDARD",
+	}
+	if v, ok := o.GetChecksum(); ok {
+		e.ETag = formatETag(v)
+	}
+	if v, ok := o.GetClass(); ok {
+		e.StorageClass = v
+	}
+	return e
+}
+
+// formatETag will make sure etag is quoted.
+func formatETag(etag string) string {
+	return `"` + strings.Trim(etag, `"`) + `"`
+}
+
+// checkPreconditions will check conditional headers against o as RFC 7232 described,
+// 0 will be returned if all preconditions passed.
+func checkPreconditions(r *http.Request, o *types.Object) int {
+	etag, hasETag := o.GetChecksum()
+	// HTTP date only has second precision.
+	updatedAt := o.UpdatedAt.Truncate(time.Second)
+
+	if v := r.Header.Get("If-Match"); v != "" {
+		if !hasETag || !matchETag(v, etag) {
+			return http.StatusPreconditionFailed
+		}
+	} else if t, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil {
+		if updatedAt.After(t) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if v := r.Header.Get("If-None-Match"); v != "" {
+		if hasETag && matchETag(v, etag) {
+			return http.StatusNotModified
+		}
+	} else if t, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		if !updatedAt.After(t) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// matchETag will check whether etag is listed in header, "*" matches any etag.
+func matchETag(header, etag string) bool {
+	etag = strings.Trim(etag, `"`)
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == "*" || strings.Trim(v, `"`) == etag {
+			return true
+		}
+	}
+	return false
+}
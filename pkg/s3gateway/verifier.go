@@ -0,0 +1,286 @@
+package s3gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// All errors that verifier could return.
+var (
+	ErrSignatureMissing = errors.New("signature missing")
+	ErrSignatureInvalid = errors.New("signature invalid")
+	ErrSignatureExpired = errors.New("signature expired")
+	ErrAccessKeyInvalid = errors.New("access key invalid")
+	// ErrContentSHA256Mismatch will be returned while the body doesn't match x-amz-content-sha256.
+	ErrContentSHA256Mismatch = errors.New("x-amz-content-sha256 mismatch")
+)
+
+const (
+	signAlgorithm  = "AWS4-HMAC-SHA256"
+	signTimeFormat = "20060102T150405Z"
+	// unsignedPayload is used by presigned url which can't sign the payload.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// maxClockSkew is the max difference between request's date and server's clock, the same as S3.
+	maxClockSkew = 15 * time.Minute
+	// maxPresignExpires is the max expires of presigned url, the same as S3.
+	maxPresignExpires = 7 * 24 * time.Hour
+)
+
+// Verifier will verify requests signed by AWS Signature Version 4.
+type Verifier struct {
+	accessKey string
+	secretKey string
+
+	// now is used to mock time in test.
+	now func() time.Time
+}
+
+// NewVerifier will create a new verifier with access key and secret key.
+func NewVerifier(accessKey, secretKey string) *Verifier {
+	return &Verifier{
+		accessKey: accessKey,
+		secretKey: secretKey,
+		now:       time.Now,
+	}
+}
+
+// Verify will check whether r is signed by this verifier's credential.
+func (v *Verifier) Verify(r *http.Request) error {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != "" {
+		return v.verifyQuery(r, query)
+	}
+
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return ErrSignatureMissing
+	}
+	return v.verifyHeader(r, query, auth)
+}
+
+// VerifyPayload will check the body of r against the signed x-amz-content-sha256 header, it should be called
+// after Verify succeeded.
+//
+// Body will be spooled into a local temp file while hashing, and r.Body will be replaced by it, so that nothing
+// will be written into storager before the body has been verified. cleanup must be called after r has been
+// handled. Presigned urls and UNSIGNED-PAYLOAD don't sign the body, other claims like streaming payload are not
+// supported.
+func (v *Verifier) VerifyPayload(r *http.Request) (cleanup func(), err error) {
+	cleanup = func() {}
+
+	if r.URL.Query().Get("X-Amz-Algorithm") != "" {
+		return cleanup, nil
+	}
+	claim := r.Header.Get("X-Amz-Content-Sha256")
+	if claim == unsignedPayload {
+		return cleanup, nil
+	}
+	expected, err := hex.DecodeString(claim)
+	if err != nil || len(expected) != sha256.Size {
+		return cleanup, fmt.Errorf("x-amz-content-sha256 %s: %w", claim, errNotImplemented)
+	}
+
+	h := sha256.New()
+	if r.Body == nil || r.ContentLength == 0 {
+		if !hmac.Equal(h.Sum(nil), expected) {
+			return cleanup, ErrContentSHA256Mismatch
+		}
+		return cleanup, nil
+	}
+
+	f, err := ioutil.TempFile("", "s3gateway")
+	if err != nil {
+		return cleanup, err
+	}
+	cleanup = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	_, err = io.Copy(f, io.TeeReader(r.Body, h))
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return cleanup, err
+	}
+	if !hmac.Equal(h.Sum(nil), expected) {
+		return cleanup, ErrContentSHA256Mismatch
+	}
+
+	r.Body = f
+	return cleanup, nil
+}
+
+// verifyHeader will verify request signed in Authorization header, which is formatted as:
+//
+//	AWS4-HMAC-SHA256 Credential=<credential>, SignedHeaders=<headers>, Signature=<signature>
+func (v *Verifier) verifyHeader(r *http.Request, query url.Values, auth string) error {
+	if !strings.HasPrefix(auth, signAlgorithm+" ") {
+		return fmt.Errorf("algorithm: %w", ErrSignatureInvalid)
+	}
+	fields := make(map[string]string)
+	for _, kv := range strings.Split(strings.TrimPrefix(auth, signAlgorithm+" "), ",") {
+		x := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(x) != 2 {
+			return fmt.Errorf("authorization: %w", ErrSignatureInvalid)
+		}
+		fields[x[0]] = x[1]
+	}
+
+	date := r.Header.Get("X-Amz-Date")
+	t, err := time.Parse(signTimeFormat, date)
+	if err != nil {
+		return fmt.Errorf("parse date: %w", ErrSignatureInvalid)
+	}
+	if d := v.now().Sub(t); d > maxClockSkew || d < -maxClockSkew {
+		return ErrSignatureExpired
+	}
+
+	payload := r.Header.Get("X-Amz-Content-Sha256")
+	if payload == "" {
+		return fmt.Errorf("x-amz-content-sha256 missing: %w", ErrSignatureInvalid)
+	}
+	return v.verify(r, query, date, fields["Credential"], fields["SignedHeaders"], payload, fields["Signature"])
+}
+
+// verifyQuery will verify presigned url.
+func (v *Verifier) verifyQuery(r *http.Request, query url.Values) error {
+	if query.Get("X-Amz-Algorithm") != signAlgorithm {
+		return fmt.Errorf("algorithm: %w", ErrSignatureInvalid)
+	}
+
+	date := query.Get("X-Amz-Date")
+	t, err := time.Parse(signTimeFormat, date)
+	if err != nil {
+		return fmt.Errorf("parse date: %w", ErrSignatureInvalid)
+	}
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse expires: %w", ErrSignatureInvalid)
+	}
+	if expires <= 0 || expires > int64(maxPresignExpires/time.Second) {
+		return fmt.Errorf("expires %d out of range: %w", expires, ErrSignatureInvalid)
+	}
+	if v.now().After(t.Add(time.Duration(expires) * time.Second)) {
+		return ErrSignatureExpired
+	}
+
+	signature := query.Get("X-Amz-Signature")
+	query.Del("X-Amz-Signature")
+	return v.verify(r, query, date, query.Get("X-Amz-Credential"), query.Get("X-Amz-SignedHeaders"), unsignedPayload, signature)
+}
+
+// verify will calculate the signature and compare it with the given one.
+func (v *Verifier) verify(r *http.Request, query url.Values, date, credential, signedHeaders, payload, signature string) error {
+	// Credential is formatted as "<access_key>/<date>/<region>/<service>/aws4_request".
+	cred := strings.SplitN(credential, "/", 2)
+	if len(cred) != 2 {
+		return fmt.Errorf("credential: %w", ErrSignatureInvalid)
+	}
+	if cred[0] != v.accessKey {
+		return ErrAccessKeyInvalid
+	}
+	scope := cred[1]
+	scopes := strings.Split(scope, "/")
+	if len(scopes) != 4 || scopes[3] != "aws4_request" {
+		return fmt.Errorf("credential scope: %w", ErrSignatureInvalid)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		escape(r.URL.Path, false),
+		canonicalQuery(query),
+		canonicalHeaders(r, signedHeaders),
+		signedHeaders,
+		payload,
+	}, "\n")
+	h := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{signAlgorithm, date, scope, hex.EncodeToString(h[:])}, "\n")
+
+	key := []byte("AWS4" + v.secretKey)
+	for _, s := range scopes {
+		key = hmacSHA256(key, s)
+	}
+	expected := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, content string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(content))
+	return h.Sum(nil)
+}
+
+// canonicalQuery will sort query by key and value, and escape them.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	x := make([]string, 0, len(query))
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			x = append(x, escape(k, true)+"="+escape(v, true))
+		}
+	}
+	return strings.Join(x, "&")
+}
+
+// canonicalHeaders will format signed headers into "<name>:<value>\n" lines.
+func canonicalHeaders(r *http.Request, signedHeaders string) string {
+	var b strings.Builder
+	for _, k := range strings.Split(signedHeaders, ";") {
+		var values []string
+		switch k {
+		case "host":
+			// Host header has been removed from header map by net/http.
+			values = []string{r.Host}
+		case "content-length":
+			values = []string{strconv.FormatInt(r.ContentLength, 10)}
+		default:
+			values = append([]string{}, r.Header[http.CanonicalHeaderKey(k)]...)
+		}
+		for i, v := range values {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		b.WriteString(k + ":" + strings.Join(values, ",") + "\n")
+	}
+	return b.String()
+}
+
+// escape will escape all characters except unreserved characters as defined in RFC 3986,
+// "/" will be kept if encodeSlash is false.
+func escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		_, _ = fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,15 @@
+/*
+Package server provided a HTTP server which serves any Storager over REST.
+
+Requests will be mapped to Storager's API as following:
+
+  - GET    /<path>        -> Read, "Range" header will be mapped to pair offset and size.
+  - GET    /<path>?list   -> List, objects will be returned in JSON.
+  - HEAD   /<path>        -> Stat
+  - PUT    /<path>        -> Write, "Content-Type" header will be mapped to pair content_type.
+  - DELETE /<path>        -> Delete
+
+If a Signer is given, every request must carry a valid signature in query which is generated by the same
+Signer, so that Storager without native signed url support like fs could implement Reacher via this server.
+*/
+package server
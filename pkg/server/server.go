@@ -0,0 +1,316 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// QueryList is the query key which turns a GET request into List.
+const QueryList = "list"
+
+// Server will serve a Storager over HTTP.
+type Server struct {
+	// ErrorLog specifies an optional logger for errors returned by storager, the log package's standard logger
+	// will be used if nil.
+	ErrorLog *log.Logger
+
+	store  storage.Storager
+	signer *Signer
+}
+
+// New will create a new server for store, signer could be nil if signature is not required.
+func New(store storage.Storager, signer *Signer) *Server {
+	return &Server{
+		store:  store,
+		signer: signer,
+	}
+}
+
+// String implements Stringer.
+func (s *Server) String() string {
+	return fmt.Sprintf("Server {Storager: %s}", s.store)
+}
+
+// Object is the JSON representation of types.Object in list response.
+type Object struct {
+	Name      string            `json:"name"`
+	Type      types.ObjectType  `json:"type"`
+	Size      int64             `json:"size"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Metadata  metadata.Metadata `json:"metadata,omitempty"`
+}
+
+// ListResponse is the response of list request.
+type ListResponse struct {
+	Objects []*Object `json:"objects"`
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := CleanPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "path must not contain .. segment", http.StatusBadRequest)
+		return
+	}
+
+	if s.signer != nil {
+		err := s.signer.Validate(r.Method, path, r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if _, ok := r.URL.Query()[QueryList]; ok {
+			s.list(w, path)
+			return
+		}
+		s.read(w, r, path)
+	case http.MethodHead:
+		s.stat(w, path)
+	case http.MethodPut:
+		s.write(w, r, path)
+	case http.MethodDelete:
+		s.delete(w, path)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// CleanPath will clean p and trim the leading slash, ok will be false if p contains a ".." segment, so that
+// requests could never escape the storager's work dir.
+func CleanPath(p string) (cleaned string, ok bool) {
+	for _, v := range strings.Split(p, "/") {
+		if v == ".." {
+			return "", false
+		}
+	}
+
+	cleaned = strings.TrimPrefix(path.Clean("/"+p), "/")
+	// Trailing slash means dir in some services, keep it.
+	if cleaned != "" && strings.HasSuffix(p, "/") {
+		cleaned += "/"
+	}
+	return cleaned, true
+}
+
+func (s *Server) list(w http.ResponseWriter, path string) {
+	resp := &ListResponse{
+		Objects: make([]*Object, 0),
+	}
+	fn := func(o *types.Object) {
+		resp.Objects = append(resp.Objects, &Object{
+			Name:      o.Name,
+			Type:      o.Type,
+			Size:      o.Size,
+			UpdatedAt: o.UpdatedAt,
+			Metadata:  o.Metadata,
+		})
+	}
+
+	err := s.store.List(path, pairs.WithDirFunc(fn), pairs.WithFileFunc(fn))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) read(w http.ResponseWriter, r *http.Request, path string) {
+	o, err := s.store.Stat(path)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if o.Type == types.ObjectTypeDir {
+		http.Error(w, "path is a dir, use list instead", http.StatusBadRequest)
+		return
+	}
+	setHeader(w, o)
+
+	offset, size, ok := ParseRange(r.Header.Get("Range"), o.Size)
+	if !ok {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", o.Size))
+		http.Error(w, http.StatusText(http.StatusRequestedRangeNotSatisfiable), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	ps := make([]*types.Pair, 0)
+	status := http.StatusOK
+	if offset != 0 || size != o.Size {
+		ps = append(ps, pairs.WithOffset(offset), pairs.WithSize(size))
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+size-1, o.Size))
+	}
+
+	rc, err := s.store.Read(path, ps...)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(status)
+	_, _ = io.CopyN(w, rc, size)
+}
+
+func (s *Server) stat(w http.ResponseWriter, path string) {
+	o, err := s.store.Stat(path)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	setHeader(w, o)
+	w.Header().Set("Content-Length", strconv.FormatInt(o.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) write(w http.ResponseWriter, r *http.Request, path string) {
+	ps := make([]*types.Pair, 0)
+	if r.ContentLength >= 0 {
+		ps = append(ps, pairs.WithSize(r.ContentLength))
+	}
+	if v := r.Header.Get("Content-Type"); v != "" {
+		ps = append(ps, pairs.WithContentType(v))
+	}
+
+	err := s.store.Write(path, r.Body, ps...)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) delete(w http.ResponseWriter, path string) {
+	err := s.store.Delete(path)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setHeader will set object's metadata into response headers.
+func setHeader(w http.ResponseWriter, o *types.Object) {
+	h := w.Header()
+	h.Set("Accept-Ranges", "bytes")
+	if !o.UpdatedAt.IsZero() {
+		h.Set("Last-Modified", o.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if v, ok := o.GetType(); ok {
+		h.Set("Content-Type", v)
+	}
+	if v, ok := o.GetChecksum(); ok {
+		h.Set("ETag", v)
+	}
+	if v, ok := o.GetContentEncoding(); ok {
+		h.Set("Content-Encoding", v)
+	}
+	if v, ok := o.GetContentDisposition(); ok {
+		h.Set("Content-Disposition", v)
+	}
+	if v, ok := o.GetCacheControl(); ok {
+		h.Set("Cache-Control", v)
+	}
+}
+
+// ParseRange will parse a single "bytes" range into offset and size.
+//
+// Whole content will be returned if header is empty or not supported like multiple ranges,
+// ok will be false only if the range is not satisfiable.
+func ParseRange(header string, total int64) (offset, size int64, ok bool) {
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return 0, total, true
+	}
+	s := strings.SplitN(strings.TrimPrefix(header, "bytes="), "-", 2)
+	if len(s) != 2 {
+		return 0, total, true
+	}
+	start, end := strings.TrimSpace(s[0]), strings.TrimSpace(s[1])
+
+	// Suffix range like "bytes=-500" means the last 500 bytes.
+	if start == "" {
+		n, err := strconv.ParseInt(end, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > total {
+			n = total
+		}
+		return total - n, n, true
+	}
+
+	offset, err := strconv.ParseInt(start, 10, 64)
+	if err != nil || offset < 0 || offset >= total {
+		return 0, 0, false
+	}
+	last := total - 1
+	if end != "" {
+		last, err = strconv.ParseInt(end, 10, 64)
+		if err != nil || last < offset {
+			return 0, 0, false
+		}
+		if last >= total {
+			last = total - 1
+		}
+	}
+	return offset, last - offset + 1, true
+}
+
+// errorCodes maps sentinel errors into status codes, the first matched one will be used.
+var errorCodes = []struct {
+	err  error
+	code int
+}{
+	{types.ErrObjectNotExist, http.StatusNotFound},
+	{types.ErrPermissionDenied, http.StatusForbidden},
+	{types.ErrPreconditionFailed, http.StatusPreconditionFailed},
+	{types.ErrObjectAlreadyExist, http.StatusConflict},
+	{types.ErrDirAlreadyExist, http.StatusConflict},
+	{types.ErrDirNotEmpty, http.StatusConflict},
+	{types.ErrInvalidPath, http.StatusBadRequest},
+	{types.ErrPairRequired, http.StatusBadRequest},
+	{types.ErrNotSupported, http.StatusNotImplemented},
+	{types.ErrReadOnly, http.StatusMethodNotAllowed},
+	{types.ErrQuotaExceeded, http.StatusInsufficientStorage},
+	{types.ErrRateLimited, http.StatusTooManyRequests},
+	{types.ErrServiceUnavailable, http.StatusServiceUnavailable},
+}
+
+// writeError will only send the matched sentinel error or status text to client, for err could contain the
+// storager's work dir and other details of the server. err itself will be logged.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Print(err)
+	} else {
+		log.Print(err)
+	}
+
+	for _, v := range errorCodes {
+		if errors.Is(err, v.err) {
+			http.Error(w, v.err.Error(), v.code)
+			return
+		}
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
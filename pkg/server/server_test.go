@@ -0,0 +1,294 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// memStorage is a in memory storager for test.
+type memStorage struct {
+	data map[string][]byte
+	ct   map[string]string
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{
+		data: make(map[string][]byte),
+		ct:   make(map[string]string),
+	}
+}
+
+func (m *memStorage) String() string                      { return "memStorage" }
+func (m *memStorage) Init(pairs ...*types.Pair) error     { return nil }
+func (m *memStorage) Metadata() (metadata.Storage, error) { return metadata.Storage{}, nil }
+
+func (m *memStorage) List(path string, ps ...*types.Pair) error {
+	var fn types.ObjectFunc
+	for _, v := range ps {
+		if v.Key == "file_func" {
+			fn = v.Value.(types.ObjectFunc)
+		}
+	}
+	for k, v := range m.data {
+		if strings.HasPrefix(k, path) {
+			fn(&types.Object{Name: k, Type: types.ObjectTypeFile, Size: int64(len(v))})
+		}
+	}
+	return nil
+}
+
+func (m *memStorage) Read(path string, ps ...*types.Pair) (io.ReadCloser, error) {
+	b, ok := m.data[path]
+	if !ok {
+		return nil, types.NewError(m, "Read", types.ErrObjectNotExist, path)
+	}
+	var offset, size int64 = 0, int64(len(b))
+	for _, v := range ps {
+		switch v.Key {
+		case "offset":
+			offset = v.Value.(int64)
+		case "size":
+			size = v.Value.(int64)
+		}
+	}
+	return ioutil.NopCloser(bytes.NewReader(b[offset : offset+size])), nil
+}
+
+func (m *memStorage) Write(path string, r io.Reader, ps ...*types.Pair) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.data[path] = b
+	for _, v := range ps {
+		if v.Key == "content_type" {
+			m.ct[path] = v.Value.(string)
+		}
+	}
+	return nil
+}
+
+func (m *memStorage) Stat(path string, ps ...*types.Pair) (*types.Object, error) {
+	b, ok := m.data[path]
+	if !ok {
+		return nil, types.NewError(m, "Stat", types.ErrObjectNotExist, path)
+	}
+	o := &types.Object{
+		Name:      path,
+		Type:      types.ObjectTypeFile,
+		Size:      int64(len(b)),
+		UpdatedAt: time.Unix(1500000000, 0),
+		Metadata:  make(metadata.Metadata),
+	}
+	if v, ok := m.ct[path]; ok {
+		o.SetType(v)
+	}
+	return o, nil
+}
+
+func (m *memStorage) Delete(path string, ps ...*types.Pair) error {
+	if _, ok := m.data[path]; !ok {
+		return types.NewError(m, "Delete", types.ErrObjectNotExist, path)
+	}
+	delete(m.data, path)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	store := newMemStorage()
+	srv := httptest.NewServer(New(store, nil))
+	defer srv.Close()
+
+	do := func(method, path string, body io.Reader, header map[string]string) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+path, body)
+		assert.NoError(t, err)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("put", func(t *testing.T) {
+		resp := do(http.MethodPut, "/dir/test", strings.NewReader("0123456789"), map[string]string{"Content-Type": "text/plain"})
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "0123456789", string(store.data["dir/test"]))
+	})
+
+	t.Run("head", func(t *testing.T) {
+		resp := do(http.MethodHead, "/dir/test", nil, nil)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "10", resp.Header.Get("Content-Length"))
+		assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		resp := do(http.MethodGet, "/dir/test", nil, nil)
+		defer resp.Body.Close()
+		content, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "0123456789", string(content))
+	})
+
+	t.Run("get with range", func(t *testing.T) {
+		resp := do(http.MethodGet, "/dir/test", nil, map[string]string{"Range": "bytes=2-4"})
+		defer resp.Body.Close()
+		content, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		assert.Equal(t, "bytes 2-4/10", resp.Header.Get("Content-Range"))
+		assert.Equal(t, "234", string(content))
+	})
+
+	t.Run("get with unsatisfiable range", func(t *testing.T) {
+		resp := do(http.MethodGet, "/dir/test", nil, map[string]string{"Range": "bytes=20-"})
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		resp := do(http.MethodGet, "/dir?list", nil, nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		lr := &ListResponse{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(lr))
+		assert.Equal(t, 1, len(lr.Objects))
+		assert.Equal(t, "dir/test", lr.Objects[0].Name)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := do(http.MethodDelete, "/dir/test", nil, nil)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_, ok := store.data["dir/test"]
+		assert.False(t, ok)
+	})
+
+	t.Run("not exist", func(t *testing.T) {
+		resp := do(http.MethodGet, "/dir/test", nil, nil)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp := do(http.MethodPost, "/dir/test", nil, nil)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
+
+func TestServerWithDotDot(t *testing.T) {
+	store := newMemStorage()
+	store.data["../secret.txt"] = []byte("secret")
+	srv := New(store, nil)
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		for _, path := range []string{"/../secret.txt", "/dir/../../secret.txt", "/%2e%2e/secret.txt"} {
+			req := httptest.NewRequest(method, "http://example.com"+path, strings.NewReader("overwritten"))
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%s %s", method, path)
+		}
+	}
+	assert.Equal(t, "secret", string(store.data["../secret.txt"]))
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"/", "", true},
+		{"/a//b/./c", "a/b/c", true},
+		{"/dir/", "dir/", true},
+		{"/a/../b", "", false},
+		{"/..", "", false},
+	}
+
+	for _, v := range tests {
+		cleaned, ok := CleanPath(v.input)
+		assert.Equal(t, v.ok, ok, v.input)
+		assert.Equal(t, v.expected, cleaned, v.input)
+	}
+}
+
+func TestServerWithSigner(t *testing.T) {
+	store := newMemStorage()
+	store.data["test"] = []byte("content")
+
+	signer := NewSigner([]byte("test_secret"))
+	srv := httptest.NewServer(New(store, signer))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/test")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "/test?" + signer.Sign(http.MethodGet, "test", time.Minute).Encode())
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		offset int64
+		size   int64
+		ok     bool
+	}{
+		{"empty", "", 0, 100, true},
+		{"multiple ranges", "bytes=0-1,5-6", 0, 100, true},
+		{"start and end", "bytes=10-19", 10, 10, true},
+		{"start only", "bytes=90-", 90, 10, true},
+		{"end exceeded", "bytes=90-200", 90, 10, true},
+		{"suffix", "bytes=-20", 80, 20, true},
+		{"suffix exceeded", "bytes=-200", 0, 100, true},
+		{"start exceeded", "bytes=100-", 0, 0, false},
+		{"end before start", "bytes=20-10", 0, 0, false},
+		{"invalid", "bytes=a-b", 0, 0, false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			offset, size, ok := ParseRange(v.header, 100)
+			assert.Equal(t, v.ok, ok)
+			assert.Equal(t, v.offset, offset)
+			assert.Equal(t, v.size, size)
+		})
+	}
+}
+
+func TestServerErrorDetails(t *testing.T) {
+	var buf bytes.Buffer
+	s := New(newMemStorage(), nil)
+	s.ErrorLog = log.New(&buf, "", 0)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/not_exist", nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, types.ErrObjectNotExist.Error()+"\n", w.Body.String())
+	// Details should only be logged.
+	assert.Contains(t, buf.String(), "memStorage")
+}
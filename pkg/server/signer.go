@@ -0,0 +1,85 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// All errors that signer could return.
+var (
+	ErrSignatureInvalid = errors.New("signature invalid")
+	ErrSignatureExpired = errors.New("signature expired")
+)
+
+// Query keys used by signed url.
+const (
+	QueryExpires   = "expires"
+	QuerySignature = "signature"
+)
+
+// Signer will sign and validate url with HMAC-SHA256.
+type Signer struct {
+	secret []byte
+
+	// now is used to mock time in test.
+	now func() time.Time
+}
+
+// NewSigner will create a new signer with secret.
+func NewSigner(secret []byte) *Signer {
+	return &Signer{
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+// Sign will sign method and path, and returns query values which should be added into url.
+func (s *Signer) Sign(method, path string, expire time.Duration) url.Values {
+	expires := s.now().Add(expire).Unix()
+
+	v := url.Values{}
+	v.Set(QueryExpires, strconv.FormatInt(expires, 10))
+	v.Set(QuerySignature, s.signature(method, path, expires))
+	return v
+}
+
+// Validate will check whether query carries a valid signature for method and path.
+//
+// HEAD request could be validated by a signature for GET.
+func (s *Signer) Validate(method, path string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get(QueryExpires), 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse expires: %w", ErrSignatureInvalid)
+	}
+	if s.now().Unix() > expires {
+		return ErrSignatureExpired
+	}
+
+	actual, err := hex.DecodeString(query.Get(QuerySignature))
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", ErrSignatureInvalid)
+	}
+
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	expected, _ := hex.DecodeString(s.signature(method, path, expires))
+	if !hmac.Equal(actual, expected) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// signature will calculate hex encoded signature for method, path and expires.
+func (s *Signer) signature(method, path string, expires int64) string {
+	h := hmac.New(sha256.New, s.secret)
+	_, _ = fmt.Fprintf(h, "%s\n%s\n%d", method, path, expires)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Now()
+
+	s := NewSigner([]byte("test_secret"))
+	s.now = func() time.Time { return now }
+
+	q := s.Sign(http.MethodGet, "test/path", time.Minute)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		query   url.Values
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", http.MethodGet, "test/path", q, now, nil},
+		{"head with get signature", http.MethodHead, "test/path", q, now, nil},
+		{"different method", http.MethodPut, "test/path", q, now, ErrSignatureInvalid},
+		{"different path", http.MethodGet, "test/other", q, now, ErrSignatureInvalid},
+		{"expired", http.MethodGet, "test/path", q, now.Add(time.Hour), ErrSignatureExpired},
+		{"missing", http.MethodGet, "test/path", url.Values{}, now, ErrSignatureInvalid},
+		{
+			"tampered expires", http.MethodGet, "test/path",
+			url.Values{
+				QueryExpires:   []string{"9999999999"},
+				QuerySignature: q[QuerySignature],
+			}, now, ErrSignatureInvalid,
+		},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			s.now = func() time.Time { return v.now }
+
+			err := s.Validate(v.method, v.path, v.query)
+			if v.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, v.wantErr))
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package snapshot
+
+// ChangeType is the type of Change.
+type ChangeType string
+
+// All change types.
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is the difference of an entry between two manifests.
+type Change struct {
+	Type ChangeType
+	// Old will be nil if Type is ChangeAdded.
+	Old *Entry
+	// New will be nil if Type is ChangeRemoved.
+	New *Entry
+}
+
+// Path will return the path of the changed entry.
+func (c *Change) Path() string {
+	if c.New != nil {
+		return c.New.Path
+	}
+	return c.Old.Path
+}
+
+// Diff will return changes from old to new, ordered by path.
+func Diff(old, new Manifest) []*Change {
+	changes := make([]*Change, 0)
+
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case j == len(new) || (i < len(old) && old[i].Path < new[j].Path):
+			changes = append(changes, &Change{Type: ChangeRemoved, Old: old[i]})
+			i++
+		case i == len(old) || old[i].Path > new[j].Path:
+			changes = append(changes, &Change{Type: ChangeAdded, New: new[j]})
+			j++
+		default:
+			if !equal(old[i], new[j]) {
+				changes = append(changes, &Change{Type: ChangeModified, Old: old[i], New: new[j]})
+			}
+			i++
+			j++
+		}
+	}
+	return changes
+}
+
+// equal will compare entries by version id or checksum if both have it, or by size and updated time if not.
+func equal(a, b *Entry) bool {
+	if a.Size != b.Size {
+		return false
+	}
+	if a.VersionID != "" && b.VersionID != "" {
+		return a.VersionID == b.VersionID
+	}
+	if a.Checksum != "" && b.Checksum != "" {
+		return a.Checksum == b.Checksum
+	}
+	return a.UpdatedAt.Equal(b.UpdatedAt)
+}
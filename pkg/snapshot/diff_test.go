@@ -0,0 +1,36 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+
+	old := Manifest{
+		{Path: "a", Size: 1, UpdatedAt: now},
+		{Path: "b", Size: 1, Checksum: "x", UpdatedAt: now},
+		{Path: "c", Size: 1, VersionID: "1"},
+		{Path: "d", Size: 1, UpdatedAt: now},
+	}
+	new := Manifest{
+		{Path: "b", Size: 1, Checksum: "x", UpdatedAt: now.Add(time.Hour)},
+		{Path: "c", Size: 1, VersionID: "2"},
+		{Path: "d", Size: 1, UpdatedAt: now.Add(time.Hour)},
+		{Path: "e", Size: 1},
+	}
+
+	changes := Diff(old, new)
+
+	paths := make([]string, 0)
+	types := make([]ChangeType, 0)
+	for _, c := range changes {
+		paths = append(paths, c.Path())
+		types = append(types, c.Type)
+	}
+	assert.Equal(t, []string{"a", "c", "d", "e"}, paths)
+	assert.Equal(t, []ChangeType{ChangeRemoved, ChangeModified, ChangeModified, ChangeAdded}, types)
+}
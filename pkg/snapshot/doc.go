@@ -0,0 +1,11 @@
+/*
+Package snapshot provided point-in-time manifests of a Storager's subtree.
+
+A Manifest records path, size, checksum, updated time and version id (if supported by the service) of every file
+under a path. It will be stored as a single JSON lines object, one entry for each line ordered by path, so that
+manifests could be diffed by any text tool.
+
+Diff compares two manifests, and Restore makes a subtree match a manifest by copying changed files from a copy
+source, which could be the same versioned storager or a backup of it.
+*/
+package snapshot
@@ -0,0 +1,157 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// Entry is a file in Manifest.
+type Entry struct {
+	// Path is relative to the path of snapshot.
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	Checksum  string    `json:"checksum,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	VersionID string    `json:"version_id,omitempty"`
+}
+
+// Manifest is the state of a subtree, entries are ordered by path.
+type Manifest []*Entry
+
+// Take will walk path in store recursively and record all files into a Manifest.
+//
+// Version ids are not returned by List in most services, so files will be stated one by one to get their
+// version ids if store is a Versioner.
+func Take(store storage.Storager, path string) (m Manifest, err error) {
+	prefix := strings.Trim(path, "/")
+
+	files := make([]*types.Object, 0)
+	err = storageutil.Walk(store, path, func(o *types.Object) {
+		files = append(files, o)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, versioned := store.(storage.Versioner)
+
+	m = make(Manifest, 0, len(files))
+	for _, o := range files {
+		if _, ok := o.GetVersionId(); versioned && !ok {
+			so, err := store.Stat(o.Name)
+			if err != nil {
+				return nil, err
+			}
+			if v, ok := so.GetVersionId(); ok {
+				o.SetVersionId(v)
+			}
+		}
+		m = append(m, newEntry(prefix, o))
+	}
+
+	m.sort()
+	return m, nil
+}
+
+// Create will take a Manifest of path in src, and write it into manifestPath in dst.
+func Create(dst storage.Storager, manifestPath string, src storage.Storager, path string) (m Manifest, err error) {
+	m, err = Take(src, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = m.Encode(&buf)
+	if err != nil {
+		return nil, err
+	}
+	err = dst.Write(manifestPath, &buf, pairs.WithSize(int64(buf.Len())))
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load will read a Manifest from manifestPath in store.
+func Load(store storage.Storager, manifestPath string) (m Manifest, err error) {
+	r, err := store.Read(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return Decode(r)
+}
+
+// Decode will read a Manifest in JSON lines from r.
+func Decode(r io.Reader) (m Manifest, err error) {
+	m = make(Manifest, 0)
+
+	d := json.NewDecoder(bufio.NewReader(r))
+	for {
+		e := &Entry{}
+		err = d.Decode(e)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, e)
+	}
+
+	m.sort()
+	return m, nil
+}
+
+// Encode will write m into w in JSON lines.
+func (m Manifest) Encode(w io.Writer) (err error) {
+	e := json.NewEncoder(w)
+	for _, v := range m {
+		err = e.Encode(v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m Manifest) sort() {
+	sort.Slice(m, func(i, j int) bool {
+		return m[i].Path < m[j].Path
+	})
+}
+
+func newEntry(prefix string, o *types.Object) *Entry {
+	e := &Entry{
+		Path:      entryPath(prefix, o.Name),
+		Size:      o.Size,
+		UpdatedAt: o.UpdatedAt,
+	}
+	if v, ok := o.GetChecksum(); ok {
+		e.Checksum = v
+	}
+	if v, ok := o.GetVersionId(); ok {
+		e.VersionID = v
+	}
+	return e
+}
+
+// entryPath will trim prefix from name, name will be returned as is if it's not under prefix.
+func entryPath(prefix, name string) string {
+	name = strings.Trim(name, "/")
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, prefix+"/")
+}
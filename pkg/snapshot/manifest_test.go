@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func newTestStorager(t *testing.T, files map[string]string) (string, storage.Storager) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range files {
+		err = store.Write(k, strings.NewReader(v), pairs.WithSize(int64(len(v))))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, store
+}
+
+func TestTake(t *testing.T) {
+	dir, store := newTestStorager(t, map[string]string{
+		"data/b/c":     "c",
+		"data/a":       "aa",
+		"not_included": "",
+	})
+	defer os.RemoveAll(dir)
+
+	m, err := Take(store, "data")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(m))
+	assert.Equal(t, "a", m[0].Path)
+	assert.Equal(t, int64(2), m[0].Size)
+	assert.Equal(t, "b/c", m[1].Path)
+}
+
+func TestManifest_Encode(t *testing.T) {
+	now := time.Now().UTC()
+	m := Manifest{
+		{Path: "a", Size: 1, UpdatedAt: now},
+		{Path: "b", Size: 2, Checksum: "x", UpdatedAt: now, VersionID: "1"},
+	}
+
+	var buf bytes.Buffer
+	err := m.Encode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+	decoded, err := Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, m, decoded)
+}
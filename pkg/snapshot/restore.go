@@ -0,0 +1,116 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// ErrEntryMismatch will be returned while the file in copy source doesn't match the entry in manifest.
+var ErrEntryMismatch = errors.New("entry mismatch")
+
+// ErrUnsafeRestore will be returned while restoring a path from itself without version ids, which would delete
+// files without any way to get them back.
+var ErrUnsafeRestore = errors.New("unsafe restore")
+
+// Restore will make path in dst match m, changed files will be copied from srcPath in src, and files not in m
+// will be deleted.
+//
+// Files will be read from src with version_id if entries have it. If src and srcPath are the same as dst and
+// path, dst must be a Versioner and every changed entry must have a version id, files will be restored by
+// Versioner.Restore instead of copying, or ErrUnsafeRestore will be returned. All changed files will be checked
+// in src before dst is modified, files which don't match the size or checksum recorded in m will be treated as
+// ErrEntryMismatch.
+func Restore(dst storage.Storager, p string, m Manifest, src storage.Storager, srcPath string) (err error) {
+	current, err := Take(dst, p)
+	if errors.Is(err, types.ErrObjectNotExist) {
+		current, err = Manifest{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	changes := Diff(current, m)
+
+	v, versioned := dst.(storage.Versioner)
+	inPlace := src == dst && path.Clean("/"+srcPath) == path.Clean("/"+p)
+	if inPlace {
+		if !versioned {
+			return fmt.Errorf("%w: %s is restored from itself without versioning", ErrUnsafeRestore, p)
+		}
+		for _, c := range changes {
+			if c.Type != ChangeRemoved && c.New.VersionID == "" {
+				return fmt.Errorf("%w: %s has no version id", ErrUnsafeRestore, c.Path())
+			}
+		}
+	}
+
+	objects := make(map[string]*types.Object, len(changes))
+	for _, c := range changes {
+		if c.Type == ChangeRemoved {
+			continue
+		}
+		o, err := statEntry(src, path.Join(srcPath, c.Path()), c.New)
+		if err != nil {
+			return err
+		}
+		objects[c.Path()] = o
+	}
+
+	for _, c := range changes {
+		dstPath := path.Join(p, c.Path())
+
+		switch {
+		case c.Type == ChangeRemoved:
+			err = dst.Delete(dstPath)
+		case inPlace:
+			err = v.Restore(dstPath, c.New.VersionID)
+		default:
+			err = copyEntry(src, path.Join(srcPath, c.Path()), dst, dstPath, c.New, objects[c.Path()])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statEntry will stat e in src and check it against e.
+func statEntry(src storage.Storager, srcPath string, e *Entry) (o *types.Object, err error) {
+	o, err = src.Stat(srcPath, entryPairs(e)...)
+	if err != nil {
+		return nil, err
+	}
+	if o.Size != e.Size {
+		return nil, fmt.Errorf("%w: %s size %d, expected %d", ErrEntryMismatch, srcPath, o.Size, e.Size)
+	}
+	if v, ok := o.GetChecksum(); ok && e.Checksum != "" && v != e.Checksum {
+		return nil, fmt.Errorf("%w: %s checksum %s, expected %s", ErrEntryMismatch, srcPath, v, e.Checksum)
+	}
+	return o, nil
+}
+
+// copyEntry will copy e from src into dst, o is the stated object of e in src.
+func copyEntry(src storage.Storager, srcPath string, dst storage.Storager, dstPath string, e *Entry, o *types.Object) (err error) {
+	r, err := src.Read(srcPath, entryPairs(e)...)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	wps := []*types.Pair{pairs.WithSize(o.Size)}
+	if v, ok := o.GetType(); ok {
+		wps = append(wps, pairs.WithContentType(v))
+	}
+	return dst.Write(dstPath, r, wps...)
+}
+
+func entryPairs(e *Entry) []*types.Pair {
+	if e.VersionID == "" {
+		return nil
+	}
+	return []*types.Pair{pairs.WithVersionId(e.VersionID)}
+}
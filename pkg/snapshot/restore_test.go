@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func TestRestore(t *testing.T) {
+	files := map[string]string{
+		"a":   "a",
+		"b/c": "c",
+	}
+	srcDir, src := newTestStorager(t, files)
+	defer os.RemoveAll(srcDir)
+	dstDir, dst := newTestStorager(t, map[string]string{
+		"data/a": "old",
+		"data/d": "d",
+	})
+	defer os.RemoveAll(dstDir)
+
+	m, err := Create(src, "manifest", src, "")
+	assert.NoError(t, err)
+	// Manifest itself is not in the snapshot.
+	assert.Equal(t, 2, len(m))
+
+	loaded, err := Load(src, "manifest")
+	assert.NoError(t, err)
+	assert.Equal(t, len(m), len(loaded))
+
+	err = Restore(dst, "data", loaded, src, "")
+	assert.NoError(t, err)
+
+	restored, err := Take(dst, "data")
+	assert.NoError(t, err)
+	paths := make([]string, 0)
+	for _, e := range restored {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{"a", "b/c"}, paths)
+
+	_, err = dst.Stat("data/d")
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+	err = src.Write("a", strings.NewReader("changed"), pairs.WithSize(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dst.Delete("data/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dst.Write("data/e", strings.NewReader("e"), pairs.WithSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Restore(dst, "data", m, src, "")
+	assert.True(t, errors.Is(err, ErrEntryMismatch))
+	// Mismatch should be found before any file is deleted.
+	_, err = dst.Stat("data/e")
+	assert.NoError(t, err)
+}
+
+func TestRestoreInPlace(t *testing.T) {
+	dir, store := newTestStorager(t, map[string]string{
+		"data/a": "a",
+	})
+	defer os.RemoveAll(dir)
+
+	m, err := Take(store, "data")
+	assert.NoError(t, err)
+
+	err = store.Write("data/b", strings.NewReader("b"), pairs.WithSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Write("data/a", strings.NewReader("changed"), pairs.WithSize(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Versions are not kept, restore from itself should be refused.
+	err = Restore(store, "data", m, store, "data")
+	assert.True(t, errors.Is(err, ErrUnsafeRestore))
+	_, err = store.Stat("data/b")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,46 @@
+// Package storagetest provides helpers for testing storagers composed of other storagers.
+package storagetest
+
+import (
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// Read will read the whole content of path in store, error message will be returned as content if read failed.
+func Read(t *testing.T, store storage.Storager, path string) string {
+	r, err := store.Read(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// List will list path in store, and return sorted names of files and dirs.
+func List(t *testing.T, store storage.Storager, path string) (files, dirs []string) {
+	files, dirs = make([]string, 0), make([]string, 0)
+	err := store.List(path,
+		pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}),
+		pairs.WithDirFunc(func(o *types.Object) {
+			dirs = append(dirs, o.Name)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	sort.Strings(dirs)
+	return
+}
@@ -0,0 +1,83 @@
+// Package storageutil provides helpers shared by storagers, especially the ones composed of other storagers.
+//
+// It only depends on storage and types, so that it could be imported by any service without linking others.
+package storageutil
+
+import (
+	"path"
+	"strings"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// CleanPath will make equivalent paths have the same form without leading and trailing slash.
+func CleanPath(p string) string {
+	return strings.Trim(path.Clean("/"+p), "/")
+}
+
+// JoinPath will join dir and name into an object name without leading slash.
+func JoinPath(dir, name string) string {
+	return strings.TrimPrefix(path.Join(dir, name), "/")
+}
+
+// Walk will list path in store recursively, fileFunc will be called on all files and dirFunc will be called on
+// all dirs under path. Dirs will be visited level by level, so parent dirs are always visited before their
+// children. Both funcs could be nil.
+func Walk(store storage.Storager, path string, fileFunc, dirFunc types.ObjectFunc) (err error) {
+	ps := make([]*types.Pair, 0, 2)
+	if fileFunc != nil {
+		ps = append(ps, pairs.WithFileFunc(fileFunc))
+	}
+
+	dirs := []string{path}
+	ps = append(ps, pairs.WithDirFunc(func(o *types.Object) {
+		dirs = append(dirs, o.Name)
+		if dirFunc != nil {
+			dirFunc(o)
+		}
+	}))
+
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		err = store.List(dir, ps...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyObject will copy an object from src to dst by reading and writing it with size, content type, storage
+// class and user metadata returned by src's Stat, the object stated from src will be returned.
+func CopyObject(src storage.Storager, srcPath string, dst storage.Storager, dstPath string) (o *types.Object, err error) {
+	o, err = src.Stat(srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := src.Read(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	ps := []*types.Pair{pairs.WithSize(o.Size)}
+	if v, ok := o.GetType(); ok {
+		ps = append(ps, pairs.WithContentType(v))
+	}
+	if v, ok := o.GetClass(); ok {
+		ps = append(ps, pairs.WithStorageClass(v))
+	}
+	if v, ok := o.GetUserMetadata(); ok {
+		ps = append(ps, pairs.WithUserMetadata(v))
+	}
+	err = dst.Write(dstPath, r, ps...)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
@@ -0,0 +1,137 @@
+package storageutil
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func TestCleanPath(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"./a/b/", "a/b"},
+		{"/a//b", "a/b"},
+		{"../a", "a"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CleanPath(tt.input))
+		})
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	assert.Equal(t, "a", JoinPath("", "a"))
+	assert.Equal(t, "a", JoinPath("/", "a"))
+	assert.Equal(t, "a/b", JoinPath("a", "b"))
+	assert.Equal(t, "a/b", JoinPath("/a/", "b"))
+}
+
+func TestWalkAndCopyObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storageutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"src/a", "src/b/c"} {
+		err = store.Write(v, strings.NewReader(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, dirs := make([]string, 0), make([]string, 0)
+	err = Walk(store, "src", func(o *types.Object) {
+		files = append(files, o.Name)
+	}, func(o *types.Object) {
+		dirs = append(dirs, o.Name)
+	})
+	assert.NoError(t, err)
+	sort.Strings(files)
+	assert.Equal(t, []string{"src/a", "src/b/c"}, files)
+	assert.Equal(t, []string{"src/b"}, dirs)
+
+	o, err := CopyObject(store, "src/b/c", store, "dst/c")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("src/b/c")), o.Size)
+
+	r, err := store.Read("dst/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	content, _ := ioutil.ReadAll(r)
+	assert.Equal(t, "src/b/c", string(content))
+}
+
+// metadataStorager will return storage class and user metadata in Stat and record pairs passed to Write.
+type metadataStorager struct {
+	storage.Storager
+
+	written []*types.Pair
+}
+
+func (s *metadataStorager) Stat(path string, ps ...*types.Pair) (o *types.Object, err error) {
+	o, err = s.Storager.Stat(path, ps...)
+	if err != nil {
+		return nil, err
+	}
+	o.SetClass("cold")
+	o.SetUserMetadata(map[string]string{"k": "v"})
+	return o, nil
+}
+
+func (s *metadataStorager) Write(path string, r io.Reader, ps ...*types.Pair) (err error) {
+	s.written = ps
+	return s.Storager.Write(path, r, ps...)
+}
+
+func TestCopyObjectWithMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storageutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fsStore := fs.New()
+	err = fsStore.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &metadataStorager{Storager: fsStore}
+	err = store.Write("a", strings.NewReader("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = CopyObject(store, "a", store, "b")
+	assert.NoError(t, err)
+
+	written := make(map[string]interface{})
+	for _, v := range store.written {
+		written[v.Key] = v.Value
+	}
+	assert.Equal(t, "cold", written[pairs.StorageClass])
+	assert.Equal(t, map[string]string{"k": "v"}, written[pairs.UserMetadata])
+}
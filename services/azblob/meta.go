@@ -2,6 +2,8 @@
 package azblob
 
 import (
+	"time"
+
 	"github.com/Xuanwo/storage"
 	"github.com/Xuanwo/storage/pkg/credential"
 	"github.com/Xuanwo/storage/pkg/endpoint"
@@ -14,11 +16,15 @@ var _ credential.Provider
 var _ endpoint.Provider
 var _ segment.Segment
 var _ storage.Storager
+var _ time.Duration
 
 // Type is the type for azblob
 const Type = "azblob"
 
 var allowedStoragePairs = map[string]map[string]struct{}{
+	"delete": {
+		"version_id": struct{}{},
+	},
 	"init": {
 		"work_dir": struct{}{},
 	},
@@ -31,13 +37,45 @@ var allowedStoragePairs = map[string]map[string]struct{}{
 	"list_segments": {
 		"segment_func": struct{}{},
 	},
+	"list_versions": {
+		"file_func": struct{}{},
+	},
 	"reach": {
-		"expire": struct{}{},
+		"content_type": struct{}{},
+		"expire":       struct{}{},
+		"method":       struct{}{},
+		"size":         struct{}{},
+	},
+	"read": {
+		"if_match":            struct{}{},
+		"if_modified_since":   struct{}{},
+		"if_none_match":       struct{}{},
+		"if_unmodified_since": struct{}{},
+		"offset":              struct{}{},
+		"size":                struct{}{},
+		"version_id":          struct{}{},
+	},
+	"stat": {
+		"if_match":            struct{}{},
+		"if_modified_since":   struct{}{},
+		"if_none_match":       struct{}{},
+		"if_unmodified_since": struct{}{},
+		"version_id":          struct{}{},
 	},
 	"write": {
-		"checksum":      struct{}{},
-		"size":          struct{}{},
-		"storage_class": struct{}{},
+		"cache_control":       struct{}{},
+		"checksum":            struct{}{},
+		"content_disposition": struct{}{},
+		"content_encoding":    struct{}{},
+		"content_type":        struct{}{},
+		"detect_content_type": struct{}{},
+		"if_match":            struct{}{},
+		"if_modified_since":   struct{}{},
+		"if_none_match":       struct{}{},
+		"if_unmodified_since": struct{}{},
+		"size":                struct{}{},
+		"storage_class":       struct{}{},
+		"user_metadata":       struct{}{},
 	},
 }
 
@@ -51,6 +89,34 @@ var allowedServicePairs = map[string]map[string]struct{}{
 	},
 }
 
+type pairStorageDelete struct {
+	HasVersionId bool
+	VersionId    string
+}
+
+func parseStoragePairDelete(opts ...*types.Pair) (*pairStorageDelete, error) {
+	result := &pairStorageDelete{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["delete"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["delete"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.VersionId]
+	if ok {
+		result.HasVersionId = true
+		result.VersionId = v.(string)
+	}
+	return result, nil
+}
+
 type pairStorageInit struct {
 	HasWorkDir bool
 	WorkDir    string
@@ -169,9 +235,43 @@ func parseStoragePairListSegments(opts ...*types.Pair) (*pairStorageListSegments
 	return result, nil
 }
 
+type pairStorageListVersions struct {
+	HasFileFunc bool
+	FileFunc    types.ObjectFunc
+}
+
+func parseStoragePairListVersions(opts ...*types.Pair) (*pairStorageListVersions, error) {
+	result := &pairStorageListVersions{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["list_versions"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["list_versions"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.FileFunc]
+	if ok {
+		result.HasFileFunc = true
+		result.FileFunc = v.(types.ObjectFunc)
+	}
+	return result, nil
+}
+
 type pairStorageReach struct {
-	HasExpire bool
-	Expire    int
+	HasContentType bool
+	ContentType    string
+	HasExpire      bool
+	Expire         int
+	HasMethod      bool
+	Method         string
+	HasSize        bool
+	Size           int64
 }
 
 func parseStoragePairReach(opts ...*types.Pair) (*pairStorageReach, error) {
@@ -189,6 +289,11 @@ func parseStoragePairReach(opts ...*types.Pair) (*pairStorageReach, error) {
 	}
 	var v interface{}
 	var ok bool
+	v, ok = values[pairs.ContentType]
+	if ok {
+		result.HasContentType = true
+		result.ContentType = v.(string)
+	}
 	v, ok = values[pairs.Expire]
 	if !ok {
 		return nil, types.NewErrPairRequired(pairs.Expire)
@@ -197,16 +302,172 @@ func parseStoragePairReach(opts ...*types.Pair) (*pairStorageReach, error) {
 		result.HasExpire = true
 		result.Expire = v.(int)
 	}
+	v, ok = values[pairs.Method]
+	if ok {
+		result.HasMethod = true
+		result.Method = v.(string)
+	}
+	v, ok = values[pairs.Size]
+	if ok {
+		result.HasSize = true
+		result.Size = v.(int64)
+	}
+	return result, nil
+}
+
+type pairStorageRead struct {
+	HasIfMatch           bool
+	IfMatch              string
+	HasIfModifiedSince   bool
+	IfModifiedSince      time.Time
+	HasIfNoneMatch       bool
+	IfNoneMatch          string
+	HasIfUnmodifiedSince bool
+	IfUnmodifiedSince    time.Time
+	HasOffset            bool
+	Offset               int64
+	HasSize              bool
+	Size                 int64
+	HasVersionId         bool
+	VersionId            string
+}
+
+func parseStoragePairRead(opts ...*types.Pair) (*pairStorageRead, error) {
+	result := &pairStorageRead{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["read"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["read"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.IfMatch]
+	if ok {
+		result.HasIfMatch = true
+		result.IfMatch = v.(string)
+	}
+	v, ok = values[pairs.IfModifiedSince]
+	if ok {
+		result.HasIfModifiedSince = true
+		result.IfModifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.IfNoneMatch]
+	if ok {
+		result.HasIfNoneMatch = true
+		result.IfNoneMatch = v.(string)
+	}
+	v, ok = values[pairs.IfUnmodifiedSince]
+	if ok {
+		result.HasIfUnmodifiedSince = true
+		result.IfUnmodifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.Offset]
+	if ok {
+		result.HasOffset = true
+		result.Offset = v.(int64)
+	}
+	v, ok = values[pairs.Size]
+	if ok {
+		result.HasSize = true
+		result.Size = v.(int64)
+	}
+	v, ok = values[pairs.VersionId]
+	if ok {
+		result.HasVersionId = true
+		result.VersionId = v.(string)
+	}
+	return result, nil
+}
+
+type pairStorageStat struct {
+	HasIfMatch           bool
+	IfMatch              string
+	HasIfModifiedSince   bool
+	IfModifiedSince      time.Time
+	HasIfNoneMatch       bool
+	IfNoneMatch          string
+	HasIfUnmodifiedSince bool
+	IfUnmodifiedSince    time.Time
+	HasVersionId         bool
+	VersionId            string
+}
+
+func parseStoragePairStat(opts ...*types.Pair) (*pairStorageStat, error) {
+	result := &pairStorageStat{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["stat"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["stat"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.IfMatch]
+	if ok {
+		result.HasIfMatch = true
+		result.IfMatch = v.(string)
+	}
+	v, ok = values[pairs.IfModifiedSince]
+	if ok {
+		result.HasIfModifiedSince = true
+		result.IfModifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.IfNoneMatch]
+	if ok {
+		result.HasIfNoneMatch = true
+		result.IfNoneMatch = v.(string)
+	}
+	v, ok = values[pairs.IfUnmodifiedSince]
+	if ok {
+		result.HasIfUnmodifiedSince = true
+		result.IfUnmodifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.VersionId]
+	if ok {
+		result.HasVersionId = true
+		result.VersionId = v.(string)
+	}
 	return result, nil
 }
 
 type pairStorageWrite struct {
-	HasChecksum     bool
-	Checksum        string
-	HasSize         bool
-	Size            int64
-	HasStorageClass bool
-	StorageClass    string
+	HasCacheControl       bool
+	CacheControl          string
+	HasChecksum           bool
+	Checksum              string
+	HasContentDisposition bool
+	ContentDisposition    string
+	HasContentEncoding    bool
+	ContentEncoding       string
+	HasContentType        bool
+	ContentType           string
+	HasDetectContentType  bool
+	DetectContentType     bool
+	HasIfMatch            bool
+	IfMatch               string
+	HasIfModifiedSince    bool
+	IfModifiedSince       time.Time
+	HasIfNoneMatch        bool
+	IfNoneMatch           string
+	HasIfUnmodifiedSince  bool
+	IfUnmodifiedSince     time.Time
+	HasSize               bool
+	Size                  int64
+	HasStorageClass       bool
+	StorageClass          string
+	HasUserMetadata       bool
+	UserMetadata          map[string]string
 }
 
 func parseStoragePairWrite(opts ...*types.Pair) (*pairStorageWrite, error) {
@@ -224,11 +485,56 @@ func parseStoragePairWrite(opts ...*types.Pair) (*pairStorageWrite, error) {
 	}
 	var v interface{}
 	var ok bool
+	v, ok = values[pairs.CacheControl]
+	if ok {
+		result.HasCacheControl = true
+		result.CacheControl = v.(string)
+	}
 	v, ok = values[pairs.Checksum]
 	if ok {
 		result.HasChecksum = true
 		result.Checksum = v.(string)
 	}
+	v, ok = values[pairs.ContentDisposition]
+	if ok {
+		result.HasContentDisposition = true
+		result.ContentDisposition = v.(string)
+	}
+	v, ok = values[pairs.ContentEncoding]
+	if ok {
+		result.HasContentEncoding = true
+		result.ContentEncoding = v.(string)
+	}
+	v, ok = values[pairs.ContentType]
+	if ok {
+		result.HasContentType = true
+		result.ContentType = v.(string)
+	}
+	v, ok = values[pairs.DetectContentType]
+	if ok {
+		result.HasDetectContentType = true
+		result.DetectContentType = v.(bool)
+	}
+	v, ok = values[pairs.IfMatch]
+	if ok {
+		result.HasIfMatch = true
+		result.IfMatch = v.(string)
+	}
+	v, ok = values[pairs.IfModifiedSince]
+	if ok {
+		result.HasIfModifiedSince = true
+		result.IfModifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.IfNoneMatch]
+	if ok {
+		result.HasIfNoneMatch = true
+		result.IfNoneMatch = v.(string)
+	}
+	v, ok = values[pairs.IfUnmodifiedSince]
+	if ok {
+		result.HasIfUnmodifiedSince = true
+		result.IfUnmodifiedSince = v.(time.Time)
+	}
 	v, ok = values[pairs.Size]
 	if !ok {
 		return nil, types.NewErrPairRequired(pairs.Size)
@@ -242,6 +548,11 @@ func parseStoragePairWrite(opts ...*types.Pair) (*pairStorageWrite, error) {
 		result.HasStorageClass = true
 		result.StorageClass = v.(string)
 	}
+	v, ok = values[pairs.UserMetadata]
+	if ok {
+		result.HasUserMetadata = true
+		result.UserMetadata = v.(map[string]string)
+	}
 	return result, nil
 }
 
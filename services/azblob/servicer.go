@@ -15,6 +15,9 @@ import (
 // Service is the azblob config.
 type Service struct {
 	service azblob.ServiceURL
+
+	// credential is used to sign SAS tokens.
+	credential *azblob.SharedKeyCredential
 }
 
 // New will create a new azblob oss service.
@@ -29,29 +32,28 @@ type Service struct {
 //
 // Our Service will store a ServiceURL for operation.
 func New(pairs ...*types.Pair) (s *Service, err error) {
-	const errorMessage = "%s New: %w"
-
 	s = &Service{}
 
 	opt, err := parseServicePairNew(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	primaryURL, _ := url.Parse(opt.Endpoint.Value().String())
 
 	credProtocol, credValue := opt.Credential.Protocol(), opt.Credential.Value()
 	if credProtocol != credential.ProtocolHmac {
-		return nil, fmt.Errorf(errorMessage, s, credential.ErrUnsupportedProtocol)
+		return nil, types.NewError(s, "New", credential.ErrUnsupportedProtocol)
 	}
 
 	cred, err := azblob.NewSharedKeyCredential(credValue[0], credValue[1])
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	p := azblob.NewPipeline(cred, azblob.PipelineOptions{})
 	s.service = azblob.NewServiceURL(*primaryURL, p)
+	s.credential = cred
 	return
 }
 
@@ -62,11 +64,9 @@ func (s Service) String() string {
 
 // List implements Servicer.List
 func (s Service) List(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List: %w"
-
 	opt, err := parseServicePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "List", err)
 	}
 
 	marker := azblob.Marker{}
@@ -75,12 +75,13 @@ func (s Service) List(pairs ...*types.Pair) (err error) {
 		output, err = s.service.ListContainersSegment(context.TODO(),
 			marker, azblob.ListContainersSegmentOptions{})
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, err)
+			err = handleAzblobError(err)
+			return types.NewError(s, "List", err)
 		}
 
 		for _, v := range output.ContainerItems {
 			bucket := s.service.NewContainerURL(v.Name)
-			opt.StoragerFunc(newStorage(bucket, v.Name))
+			opt.StoragerFunc(newStorage(bucket, v.Name, s.credential))
 		}
 
 		marker = output.NextMarker
@@ -96,29 +97,27 @@ func (s Service) Get(name string, pairs ...*types.Pair) (storage.Storager, error
 	const _ = "%s Get [%s]: %w"
 
 	bucket := s.service.NewContainerURL(name)
-	return newStorage(bucket, name), nil
+	return newStorage(bucket, name, s.credential), nil
 }
 
 // Create implements Servicer.Create
 func (s Service) Create(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s Create [%s]: %w"
-
 	bucket := s.service.NewContainerURL(name)
 	_, err := bucket.Create(context.TODO(), azblob.Metadata{}, azblob.PublicAccessNone)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		err = handleAzblobError(err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
-	return newStorage(bucket, name), nil
+	return newStorage(bucket, name, s.credential), nil
 }
 
 // Delete implements Servicer.Delete
 func (s Service) Delete(name string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
-
 	bucket := s.service.NewContainerURL(name)
 	_, err = bucket.Delete(context.TODO(), azblob.ContainerAccessConditions{})
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, name, err)
+		err = handleAzblobError(err)
+		return types.NewError(s, "Delete", err, name)
 	}
 	return nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
 
@@ -17,23 +19,25 @@ import (
 //
 //go:generate ../../internal/bin/meta
 type Storage struct {
-	bucket azblob.ContainerURL
+	bucket     azblob.ContainerURL
+	credential *azblob.SharedKeyCredential
 
 	name    string
 	workDir string
 }
 
 // newStorage will create a new client.
-func newStorage(bucket azblob.ContainerURL, name string) *Storage {
+func newStorage(bucket azblob.ContainerURL, name string, credential *azblob.SharedKeyCredential) *Storage {
 	c := &Storage{
-		bucket: bucket,
-		name:   name,
+		bucket:     bucket,
+		credential: credential,
+		name:       name,
 	}
 	return c
 }
 
 // String implements Storager.String
-func (s Storage) String() string {
+func (s *Storage) String() string {
 	return fmt.Sprintf(
 		"Storager azblob {Name: %s, WorkDir: %s}",
 		s.name, "/"+s.workDir,
@@ -41,12 +45,10 @@ func (s Storage) String() string {
 }
 
 // Init implements Storager.Init
-func (s Storage) Init(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Init: %w"
-
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
 	opt, err := parseStoragePairInit(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "Init", err)
 	}
 
 	if opt.HasWorkDir {
@@ -58,7 +60,7 @@ func (s Storage) Init(pairs ...*types.Pair) (err error) {
 }
 
 // Metadata implements Storager.Metadata
-func (s Storage) Metadata() (m metadata.Storage, err error) {
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
 	m = metadata.Storage{
 		Name:     s.name,
 		WorkDir:  s.workDir,
@@ -68,12 +70,10 @@ func (s Storage) Metadata() (m metadata.Storage, err error) {
 }
 
 // List implements Storager.List
-func (s Storage) List(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List [%s]: %w"
-
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 	opt, err := parseStoragePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "List", err, path)
 	}
 
 	rp := s.getAbsPath(path)
@@ -83,15 +83,19 @@ func (s Storage) List(path string, pairs ...*types.Pair) (err error) {
 	for {
 		output, err = s.bucket.ListBlobsFlatSegment(context.TODO(), marker, azblob.ListBlobsSegmentOptions{
 			Prefix: rp,
+			Details: azblob.BlobListingDetails{
+				Metadata: true,
+			},
 		})
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, path, err)
+			err = handleAzblobError(err)
+			return types.NewError(s, "List", err, path)
 		}
 
 		for _, v := range output.Segment.BlobItems {
 			o := &types.Object{
 				Name:      s.getRelPath(v.Name),
-				Type:      types.ObjectTypeDir,
+				Type:      types.ObjectTypeFile,
 				Size:      *v.Properties.ContentLength,
 				UpdatedAt: v.Properties.LastModified,
 				Metadata:  make(metadata.Metadata),
@@ -99,6 +103,9 @@ func (s Storage) List(path string, pairs ...*types.Pair) (err error) {
 			o.SetType(*v.Properties.ContentType)
 			o.SetClass(string(v.Properties.AccessTier))
 			o.SetChecksum(string(v.Properties.ContentMD5))
+			if len(v.Metadata) > 0 {
+				o.SetUserMetadata(v.Metadata)
+			}
 
 			opt.FileFunc(o)
 		}
@@ -113,47 +120,193 @@ func (s Storage) List(path string, pairs ...*types.Pair) (err error) {
 }
 
 // Read implements Storager.Read
-func (s Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
-	const errorMessage = "%s Read [%s]: %w"
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	cond := azblob.BlobAccessConditions{}
+	if opt.HasIfMatch {
+		cond.IfMatch = azblob.ETag(opt.IfMatch)
+	}
+	if opt.HasIfNoneMatch {
+		cond.IfNoneMatch = azblob.ETag(opt.IfNoneMatch)
+	}
+	if opt.HasIfModifiedSince {
+		cond.IfModifiedSince = opt.IfModifiedSince
+	}
+	if opt.HasIfUnmodifiedSince {
+		cond.IfUnmodifiedSince = opt.IfUnmodifiedSince
+	}
 
 	rp := s.getAbsPath(path)
 
-	output, err := s.bucket.NewBlockBlobURL(rp).Download(context.TODO(), 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	count := int64(azblob.CountToEnd)
+	if opt.HasSize {
+		count = opt.Size
+	}
+
+	blob := s.bucket.NewBlockBlobURL(rp)
+	if opt.HasVersionId {
+		blob = blob.WithSnapshot(opt.VersionId)
+	}
+
+	output, err := blob.Download(context.TODO(), opt.Offset, count, cond, false)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		err = handleAzblobError(err)
+		return nil, types.NewError(s, "Read", err, path)
 	}
 	return output.Body(azblob.RetryReaderOptions{}), nil
 }
 
 // Write implements Storager.Write
-func (s Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Write [%s]: %w"
-
-	_, err = parseStoragePairWrite(pairs...)
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairWrite(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
+	}
+
+	if opt.HasDetectContentType && opt.DetectContentType && !opt.HasContentType {
+		opt.ContentType, r, err = iowrap.SniffContentType(r)
+		if err != nil {
+			return types.NewError(s, "Write", err, path)
+		}
+		opt.HasContentType = true
 	}
 
 	rp := s.getAbsPath(path)
 
+	m := azblob.Metadata{}
+	if opt.HasUserMetadata {
+		m = opt.UserMetadata
+	}
+
+	h := azblob.BlobHTTPHeaders{}
+	if opt.HasContentType {
+		h.ContentType = opt.ContentType
+	}
+	if opt.HasContentEncoding {
+		h.ContentEncoding = opt.ContentEncoding
+	}
+	if opt.HasContentDisposition {
+		h.ContentDisposition = opt.ContentDisposition
+	}
+	if opt.HasCacheControl {
+		h.CacheControl = opt.CacheControl
+	}
+
+	cond := azblob.BlobAccessConditions{}
+	if opt.HasIfMatch {
+		cond.IfMatch = azblob.ETag(opt.IfMatch)
+	}
+	if opt.HasIfNoneMatch {
+		cond.IfNoneMatch = azblob.ETag(opt.IfNoneMatch)
+	}
+	if opt.HasIfModifiedSince {
+		cond.IfModifiedSince = opt.IfModifiedSince
+	}
+	if opt.HasIfUnmodifiedSince {
+		cond.IfUnmodifiedSince = opt.IfUnmodifiedSince
+	}
+
 	// TODO: add checksum and storage class support.
 	_, err = s.bucket.NewBlockBlobURL(rp).Upload(context.TODO(), iowrap.NewReadSeekCloser(r),
-		azblob.BlobHTTPHeaders{}, azblob.Metadata{}, azblob.BlobAccessConditions{})
+		h, m, cond)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		err = handleAzblobError(err)
+		return types.NewError(s, "Write", err, path)
 	}
 	return nil
 }
 
+// Reach implements Storager.Reach
+//
+// azblob's SAS token can't constrain content type or length, and client must send header
+// "x-ms-blob-type: BlockBlob" while uploading via PUT.
+func (s *Storage) Reach(path string, pairs ...*types.Pair) (url string, err error) {
+	opt, err := parseStoragePairReach(pairs...)
+	if err != nil {
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	method := http.MethodGet
+	if opt.HasMethod {
+		method = opt.Method
+	}
+
+	var perm azblob.BlobSASPermissions
+	switch method {
+	case http.MethodGet:
+		perm.Read = true
+	case http.MethodPut:
+		// SAS couldn't constrain content type and length of the uploaded blob.
+		if opt.HasContentType {
+			err = fmt.Errorf("content_type %s: %w", opt.ContentType, types.ErrNotSupported)
+			return "", types.NewError(s, "Reach", err, path)
+		}
+		if opt.HasSize {
+			err = fmt.Errorf("size %d: %w", opt.Size, types.ErrNotSupported)
+			return "", types.NewError(s, "Reach", err, path)
+		}
+		perm.Create = true
+		perm.Write = true
+	default:
+		err = fmt.Errorf("method %s: %w", method, types.ErrNotSupported)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	sas, err := azblob.BlobSASSignatureValues{
+		Protocol:      azblob.SASProtocolHTTPS,
+		ExpiryTime:    time.Now().UTC().Add(time.Duration(opt.Expire) * time.Second),
+		ContainerName: s.name,
+		BlobName:      rp,
+		Permissions:   perm.String(),
+	}.NewSASQueryParameters(s.credential)
+	if err != nil {
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	parts := azblob.NewBlobURLParts(s.bucket.NewBlockBlobURL(rp).URL())
+	parts.SAS = sas
+	u := parts.URL()
+	return u.String(), nil
+}
+
 // Stat implements Storager.Stat
-func (s Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
-	const errorMessage = "%s Stat [%s]: %w"
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	opt, err := parseStoragePairStat(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+
+	cond := azblob.BlobAccessConditions{}
+	if opt.HasIfMatch {
+		cond.IfMatch = azblob.ETag(opt.IfMatch)
+	}
+	if opt.HasIfNoneMatch {
+		cond.IfNoneMatch = azblob.ETag(opt.IfNoneMatch)
+	}
+	if opt.HasIfModifiedSince {
+		cond.IfModifiedSince = opt.IfModifiedSince
+	}
+	if opt.HasIfUnmodifiedSince {
+		cond.IfUnmodifiedSince = opt.IfUnmodifiedSince
+	}
 
 	rp := s.getAbsPath(path)
 
-	output, err := s.bucket.NewBlockBlobURL(rp).GetProperties(context.TODO(), azblob.BlobAccessConditions{})
+	blob := s.bucket.NewBlockBlobURL(rp)
+	if opt.HasVersionId {
+		blob = blob.WithSnapshot(opt.VersionId)
+	}
+
+	output, err := blob.GetProperties(context.TODO(), cond)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		err = handleAzblobError(err)
+		return nil, types.NewError(s, "Stat", err, path)
 	}
 
 	o = &types.Object{
@@ -163,19 +316,157 @@ func (s Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err e
 		UpdatedAt: output.LastModified(),
 		Metadata:  make(metadata.Metadata),
 	}
+
+	if v := output.ContentType(); v != "" {
+		o.SetType(v)
+	}
+	if v := output.ETag(); v != "" {
+		o.SetChecksum(string(v))
+	}
+	if v := output.AccessTier(); v != "" {
+		o.SetClass(v)
+	}
+	if m := output.NewMetadata(); len(m) > 0 {
+		o.SetUserMetadata(m)
+	}
+	if v := output.ContentEncoding(); v != "" {
+		o.SetContentEncoding(v)
+	}
+	if v := output.ContentDisposition(); v != "" {
+		o.SetContentDisposition(v)
+	}
+	if v := output.CacheControl(); v != "" {
+		o.SetCacheControl(v)
+	}
+	if opt.HasVersionId {
+		o.SetVersionId(opt.VersionId)
+	}
 	return o, nil
 }
 
 // Delete implements Storager.Delete
-func (s Storage) Delete(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
+//
+// The snapshot will be deleted if version_id is given.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairDelete(pairs...)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
 
 	rp := s.getAbsPath(path)
 
-	_, err = s.bucket.NewBlockBlobURL(rp).Delete(context.TODO(),
+	blob := s.bucket.NewBlockBlobURL(rp)
+	if opt.HasVersionId {
+		blob = blob.WithSnapshot(opt.VersionId)
+	}
+
+	_, err = blob.Delete(context.TODO(),
 		azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		err = handleAzblobError(err)
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// ListVersions implements Storager.ListVersions
+//
+// Snapshots will be used as versions, and their timestamps will be used as version ids. The current blob will
+// be listed first without version id.
+func (s *Storage) ListVersions(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairListVersions(pairs...)
+	if err != nil {
+		return types.NewError(s, "ListVersions", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	objects := make([]*types.Object, 0)
+	marker := azblob.Marker{}
+	var output *azblob.ListBlobsFlatSegmentResponse
+	for {
+		output, err = s.bucket.ListBlobsFlatSegment(context.TODO(), marker, azblob.ListBlobsSegmentOptions{
+			Prefix: rp,
+			Details: azblob.BlobListingDetails{
+				Snapshots: true,
+			},
+		})
+		if err != nil {
+			err = handleAzblobError(err)
+			return types.NewError(s, "ListVersions", err, path)
+		}
+
+		for _, v := range output.Segment.BlobItems {
+			// Blobs which have path as prefix will also be listed.
+			if v.Name != rp {
+				continue
+			}
+
+			o := &types.Object{
+				Name:      path,
+				Type:      types.ObjectTypeFile,
+				Size:      *v.Properties.ContentLength,
+				UpdatedAt: v.Properties.LastModified,
+				Metadata:  make(metadata.Metadata),
+			}
+			if v.Snapshot != "" {
+				o.SetVersionId(v.Snapshot)
+			}
+			o.SetClass(string(v.Properties.AccessTier))
+			o.SetChecksum(string(v.Properties.ContentMD5))
+			objects = append(objects, o)
+		}
+
+		marker = output.NextMarker
+		if !marker.NotDone() {
+			break
+		}
+	}
+
+	// azblob lists snapshots from oldest to newest, and the current blob is the last one.
+	if opt.HasFileFunc {
+		for i := len(objects) - 1; i >= 0; i-- {
+			opt.FileFunc(objects[i])
+		}
+	}
+	return nil
+}
+
+// Restore implements Storager.Restore
+//
+// A snapshot of the current blob will be created before the snapshot is copied over it.
+func (s *Storage) Restore(path, version string, pairs ...*types.Pair) (err error) {
+	rp := s.getAbsPath(path)
+
+	blob := s.bucket.NewBlockBlobURL(rp)
+	_, err = blob.CreateSnapshot(context.TODO(), azblob.Metadata{}, azblob.BlobAccessConditions{})
+	if err != nil {
+		err = handleAzblobError(err)
+		return types.NewError(s, "Restore", err, path)
+	}
+
+	output, err := blob.StartCopyFromURL(context.TODO(), blob.WithSnapshot(version).URL(), azblob.Metadata{},
+		azblob.ModifiedAccessConditions{}, azblob.BlobAccessConditions{})
+	if err != nil {
+		err = handleAzblobError(err)
+		return types.NewError(s, "Restore", err, path)
+	}
+
+	// Copy inside the same account is usually done after started, but it could still be pending.
+	status := output.CopyStatus()
+	for status == azblob.CopyStatusPending {
+		time.Sleep(time.Second)
+
+		props, err := blob.GetProperties(context.TODO(), azblob.BlobAccessConditions{})
+		if err != nil {
+			err = handleAzblobError(err)
+			return types.NewError(s, "Restore", err, path)
+		}
+		status = props.CopyStatus()
+	}
+	if status != azblob.CopyStatusSuccess {
+		err = fmt.Errorf("%w: copy snapshot %s %s", types.ErrUnhandledError, version, status)
+		return types.NewError(s, "Restore", err, path)
 	}
 	return nil
 }
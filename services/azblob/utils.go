@@ -1,7 +1,13 @@
 package azblob
 
 import (
+	"fmt"
+	"net/http"
 	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Xuanwo/storage/types"
 )
 
 func (s *Storage) getAbsPath(path string) string {
@@ -11,3 +17,54 @@ func (s *Storage) getAbsPath(path string) string {
 func (s *Storage) getRelPath(path string) string {
 	return strings.TrimPrefix(path, s.workDir+"/")
 }
+
+func handleAzblobError(err error) error {
+	if err == nil {
+		panic("error must not be nil")
+	}
+
+	e, ok := err.(azblob.StorageError)
+	if !ok {
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+
+	switch e.ServiceCode() {
+	case azblob.ServiceCodeBlobNotFound, azblob.ServiceCodeContainerNotFound, azblob.ServiceCodeResourceNotFound:
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case azblob.ServiceCodeAuthenticationFailed:
+		return fmt.Errorf("%w: %v", types.ErrConfigIncorrect, err)
+	case azblob.ServiceCodeInsufficientAccountPermissions, azblob.ServiceCodeAccountIsDisabled:
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case azblob.ServiceCodeBlobAlreadyExists, azblob.ServiceCodeContainerAlreadyExists, azblob.ServiceCodeResourceAlreadyExists:
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	case azblob.ServiceCodeInvalidResourceName, azblob.ServiceCodeOutOfRangeInput:
+		return fmt.Errorf("%w: %v", types.ErrInvalidPath, err)
+	case azblob.ServiceCodeConditionNotMet, azblob.ServiceCodeTargetConditionNotMet:
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case azblob.ServiceCodeRequestBodyTooLarge:
+		return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+	case azblob.ServiceCodeServerBusy:
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case azblob.ServiceCodeInternalError, azblob.ServiceCodeOperationTimedOut:
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+
+	// HEAD requests don't have body, so we can only handle them via status code.
+	if e.Response() == nil {
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+	switch e.Response().StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	case http.StatusPreconditionFailed, http.StatusNotModified:
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	default:
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+}
@@ -0,0 +1,11 @@
+/*
+Package cas provided a content addressable storager which deduplicates objects by content.
+
+Content of objects will be stored as blobs under their SHA-256 digest in "blobs/<ab>/<cd>/<digest>" of the
+underlying storager, and every path will be a small manifest in "refs/<path>" which refers to a blob. Writing
+the same content into different paths will only store one blob.
+
+Delete will only remove the manifest, blobs which are no longer referred by any manifest will be removed by GC.
+GC should not be called while writing, or blobs reused by new manifests could be removed.
+*/
+package cas
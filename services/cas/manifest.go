@@ -0,0 +1,47 @@
+package cas
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// manifest is the content of a ref, which refers to a blob by digest.
+type manifest struct {
+	Digest      string    `json:"digest"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func readManifest(store storage.Storager, path string) (m *manifest, err error) {
+	r, err := store.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m = &manifest{}
+	err = json.Unmarshal(content, m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func writeManifest(store storage.Storager, path string, m *manifest) (err error) {
+	content, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return store.Write(path, bytes.NewReader(content), ps.WithSize(int64(len(content))))
+}
@@ -0,0 +1,170 @@
+// Code generated by go generate via internal/cmd/meta; DO NOT EDIT.
+package cas
+
+import (
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/segment"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+var _ credential.Provider
+var _ endpoint.Provider
+var _ segment.Segment
+var _ storage.Storager
+var _ time.Duration
+
+// Type is the type for cas
+const Type = "cas"
+
+var allowedStoragePairs = map[string]map[string]struct{}{
+	"gc": {
+		"file_func": struct{}{},
+	},
+	"init": {
+		"storager": struct{}{},
+	},
+	"list": {
+		"dir_func":  struct{}{},
+		"file_func": struct{}{},
+	},
+	"write": {
+		"content_type": struct{}{},
+		"size":         struct{}{},
+	},
+}
+
+var allowedServicePairs = map[string]map[string]struct{}{}
+
+type pairStorageGc struct {
+	HasFileFunc bool
+	FileFunc    types.ObjectFunc
+}
+
+func parseStoragePairGc(opts ...*types.Pair) (*pairStorageGc, error) {
+	result := &pairStorageGc{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["gc"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["gc"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.FileFunc]
+	if ok {
+		result.HasFileFunc = true
+		result.FileFunc = v.(types.ObjectFunc)
+	}
+	return result, nil
+}
+
+type pairStorageInit struct {
+	HasStorager bool
+	Storager    storage.Storager
+}
+
+func parseStoragePairInit(opts ...*types.Pair) (*pairStorageInit, error) {
+	result := &pairStorageInit{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["init"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["init"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.Storager]
+	if !ok {
+		return nil, types.NewErrPairRequired(pairs.Storager)
+	}
+	if ok {
+		result.HasStorager = true
+		result.Storager = v.(storage.Storager)
+	}
+	return result, nil
+}
+
+type pairStorageList struct {
+	HasDirFunc  bool
+	DirFunc     types.ObjectFunc
+	HasFileFunc bool
+	FileFunc    types.ObjectFunc
+}
+
+func parseStoragePairList(opts ...*types.Pair) (*pairStorageList, error) {
+	result := &pairStorageList{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["list"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["list"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.DirFunc]
+	if ok {
+		result.HasDirFunc = true
+		result.DirFunc = v.(types.ObjectFunc)
+	}
+	v, ok = values[pairs.FileFunc]
+	if ok {
+		result.HasFileFunc = true
+		result.FileFunc = v.(types.ObjectFunc)
+	}
+	return result, nil
+}
+
+type pairStorageWrite struct {
+	HasContentType bool
+	ContentType    string
+	HasSize        bool
+	Size           int64
+}
+
+func parseStoragePairWrite(opts ...*types.Pair) (*pairStorageWrite, error) {
+	result := &pairStorageWrite{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["write"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["write"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.ContentType]
+	if ok {
+		result.HasContentType = true
+		result.ContentType = v.(string)
+	}
+	v, ok = values[pairs.Size]
+	if ok {
+		result.HasSize = true
+		result.Size = v.(int64)
+	}
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package cas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// Storage is the content addressable storager.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	store storage.Storager
+}
+
+// New will create a content addressable storager.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager cas {Storager: %s}", s.store)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.store = opt.Storager
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m, err = s.store.Metadata()
+	if err != nil {
+		return m, types.NewError(s, "Metadata", err)
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+//
+// Manifests of files will be read to fill in their size and checksum.
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	files := make([]*types.Object, 0)
+	listPairs := []*types.Pair{
+		ps.WithFileFunc(func(o *types.Object) {
+			files = append(files, o)
+		}),
+	}
+	if opt.HasDirFunc {
+		listPairs = append(listPairs, ps.WithDirFunc(func(o *types.Object) {
+			opt.DirFunc(&types.Object{
+				Name:     getPathFromRef(o.Name),
+				Type:     types.ObjectTypeDir,
+				Metadata: make(metadata.Metadata),
+			})
+		}))
+	}
+
+	err = s.store.List(getRefPath(path), listPairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	if !opt.HasFileFunc {
+		return nil
+	}
+
+	for _, v := range files {
+		m, err := readManifest(s.store, v.Name)
+		if err != nil {
+			return types.NewError(s, "List", err, path)
+		}
+		opt.FileFunc(formatObject(getPathFromRef(v.Name), m))
+	}
+	return nil
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	m, err := readManifest(s.store, getRefPath(path))
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	r, err = s.store.Read(getBlobPath(m.Digest), pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// Content will be buffered in a local temp file to calculate the digest, and the blob will only be written if
+// it doesn't exist yet.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairWrite(pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	f, err := ioutil.TempFile("", "cas")
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	h := sha256.New()
+	w := io.MultiWriter(f, h)
+	var n int64
+	if opt.HasSize {
+		n, err = io.CopyN(w, r, opt.Size)
+	} else {
+		n, err = io.Copy(w, r)
+	}
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	m := &manifest{
+		Digest:    hex.EncodeToString(h.Sum(nil)),
+		Size:      n,
+		CreatedAt: time.Now(),
+	}
+	if opt.HasContentType {
+		m.ContentType = opt.ContentType
+	}
+
+	bp := getBlobPath(m.Digest)
+	_, err = s.store.Stat(bp)
+	if errors.Is(err, types.ErrObjectNotExist) {
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return types.NewError(s, "Write", err, path)
+		}
+		err = s.store.Write(bp, f, ps.WithSize(n))
+	}
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	// Manifest must be written after blob, so that it will never refer to a missing blob.
+	err = writeManifest(s.store, getRefPath(path), m)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	rp := getRefPath(path)
+
+	o, err = s.store.Stat(rp)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	if o.Type == types.ObjectTypeDir {
+		o.Name = path
+		return o, nil
+	}
+
+	m, err := readManifest(s.store, rp)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	return formatObject(path, m), nil
+}
+
+// Delete implements Storager.Delete
+//
+// Only the manifest will be deleted, the blob will be removed in GC if not referred anymore.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	err = s.store.Delete(getRefPath(path), pairs...)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// GC will remove all blobs which are not referred by any manifest, file_func will be called for every removed
+// blob.
+//
+// GC is mark and sweep, all manifests will be read before any blob removed. Manifests written during GC could
+// refer to blobs which are being removed, so GC should not be called while writing.
+func (s *Storage) GC(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairGc(pairs...)
+	if err != nil {
+		return types.NewError(s, "GC", err)
+	}
+
+	refs := make([]string, 0)
+	err = storageutil.Walk(s.store, refDir, func(o *types.Object) {
+		refs = append(refs, o.Name)
+	}, nil)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return types.NewError(s, "GC", err)
+	}
+
+	marked := make(map[string]struct{})
+	for _, v := range refs {
+		m, err := readManifest(s.store, v)
+		if err != nil {
+			return types.NewError(s, "GC", err, v)
+		}
+		marked[m.Digest] = struct{}{}
+	}
+
+	blobs := make([]*types.Object, 0)
+	err = storageutil.Walk(s.store, blobDir, func(o *types.Object) {
+		if _, ok := marked[path.Base(o.Name)]; !ok {
+			blobs = append(blobs, o)
+		}
+	}, nil)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return types.NewError(s, "GC", err)
+	}
+
+	for _, o := range blobs {
+		err = s.store.Delete(o.Name)
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return types.NewError(s, "GC", err, o.Name)
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return nil
+}
+
+func formatObject(path string, m *manifest) *types.Object {
+	o := &types.Object{
+		Name:      path,
+		Type:      types.ObjectTypeFile,
+		Size:      m.Size,
+		UpdatedAt: m.CreatedAt,
+		Metadata:  make(metadata.Metadata),
+	}
+	o.SetChecksum(m.Digest)
+	if m.ContentType != "" {
+		o.SetType(m.ContentType)
+	}
+	return o
+}
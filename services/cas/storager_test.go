@@ -0,0 +1,113 @@
+package cas
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func newTestStorage(t *testing.T) (string, *fs.Storage, *Storage) {
+	dir, err := ioutil.TempDir("", "cas")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New()
+	err = s.Init(pairs.WithStorager(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, store, s
+}
+
+func countBlobs(t *testing.T, s *Storage) int {
+	n := 0
+	err := storageutil.Walk(s.store, blobDir, func(o *types.Object) {
+		n++
+	}, nil)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestStorage(t *testing.T) {
+	dir, _, s := newTestStorage(t)
+	defer os.RemoveAll(dir)
+
+	content := bytes.Repeat([]byte("a"), 1024)
+	for _, v := range []string{"a", "b/c"} {
+		err := s.Write(v, bytes.NewReader(content), pairs.WithContentType("text/plain"))
+		assert.NoError(t, err)
+	}
+	err := s.Write("d", bytes.NewReader([]byte("d")), pairs.WithSize(1))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, countBlobs(t, s))
+
+	r, err := s.Read("b/c")
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, content, b)
+
+	o, err := s.Stat("a")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), o.Size)
+	v, _ := o.GetType()
+	assert.Equal(t, "text/plain", v)
+
+	files, dirs := make([]string, 0), make([]string, 0)
+	err = s.List("",
+		pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}),
+		pairs.WithDirFunc(func(o *types.Object) {
+			dirs = append(dirs, o.Name)
+		}),
+	)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "d"}, files)
+	assert.Equal(t, []string{"b"}, dirs)
+
+	err = s.Delete("a")
+	assert.NoError(t, err)
+	err = s.Delete("d")
+	assert.NoError(t, err)
+	_, err = s.Stat("d")
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+	removed := 0
+	err = s.GC(pairs.WithFileFunc(func(o *types.Object) {
+		removed++
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 1, countBlobs(t, s))
+
+	r, err = s.Read("b/c")
+	assert.NoError(t, err)
+	r.Close()
+}
+
+func TestStorage_GC(t *testing.T) {
+	dir, _, s := newTestStorage(t)
+	defer os.RemoveAll(dir)
+
+	err := s.GC()
+	assert.NoError(t, err)
+}
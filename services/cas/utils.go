@@ -0,0 +1,28 @@
+package cas
+
+import (
+	"path"
+	"strings"
+
+	"github.com/Xuanwo/storage/pkg/storageutil"
+)
+
+const (
+	blobDir = "blobs"
+	refDir  = "refs"
+)
+
+// getBlobPath will shard blobs by the first two bytes of digest, so that there will not be too many blobs in
+// one dir.
+func getBlobPath(digest string) string {
+	return path.Join(blobDir, digest[0:2], digest[2:4], digest)
+}
+
+func getRefPath(p string) string {
+	return path.Join(refDir, storageutil.CleanPath(p))
+}
+
+// getPathFromRef is the reverse of getRefPath.
+func getPathFromRef(p string) string {
+	return strings.TrimPrefix(storageutil.CleanPath(p), refDir+"/")
+}
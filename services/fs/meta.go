@@ -2,6 +2,8 @@
 package fs
 
 import (
+	"time"
+
 	"github.com/Xuanwo/storage"
 	"github.com/Xuanwo/storage/pkg/credential"
 	"github.com/Xuanwo/storage/pkg/endpoint"
@@ -14,32 +16,91 @@ var _ credential.Provider
 var _ endpoint.Provider
 var _ segment.Segment
 var _ storage.Storager
+var _ time.Duration
 
 // Type is the type for fs
 const Type = "fs"
 
 var allowedStoragePairs = map[string]map[string]struct{}{
+	"delete": {
+		"version_id": struct{}{},
+	},
 	"init": {
-		"work_dir": struct{}{},
+		"credential": struct{}{},
+		"endpoint":   struct{}{},
+		"versioning": struct{}{},
+		"work_dir":   struct{}{},
 	},
 	"list": {
 		"dir_func":  struct{}{},
 		"file_func": struct{}{},
 	},
+	"list_versions": {
+		"file_func": struct{}{},
+	},
+	"reach": {
+		"expire": struct{}{},
+		"method": struct{}{},
+	},
 	"read": {
-		"offset": struct{}{},
-		"size":   struct{}{},
+		"if_modified_since":   struct{}{},
+		"if_unmodified_since": struct{}{},
+		"offset":              struct{}{},
+		"size":                struct{}{},
+		"version_id":          struct{}{},
+	},
+	"stat": {
+		"if_modified_since":   struct{}{},
+		"if_unmodified_since": struct{}{},
+		"version_id":          struct{}{},
 	},
 	"write": {
-		"size": struct{}{},
+		"if_match":            struct{}{},
+		"if_none_match":       struct{}{},
+		"if_unmodified_since": struct{}{},
+		"size":                struct{}{},
 	},
 }
 
 var allowedServicePairs = map[string]map[string]struct{}{}
 
+type pairStorageDelete struct {
+	HasVersionId bool
+	VersionId    string
+}
+
+func parseStoragePairDelete(opts ...*types.Pair) (*pairStorageDelete, error) {
+	result := &pairStorageDelete{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["delete"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["delete"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.VersionId]
+	if ok {
+		result.HasVersionId = true
+		result.VersionId = v.(string)
+	}
+	return result, nil
+}
+
 type pairStorageInit struct {
-	HasWorkDir bool
-	WorkDir    string
+	HasCredential bool
+	Credential    *credential.Provider
+	HasEndpoint   bool
+	Endpoint      endpoint.Provider
+	HasVersioning bool
+	Versioning    bool
+	HasWorkDir    bool
+	WorkDir       string
 }
 
 func parseStoragePairInit(opts ...*types.Pair) (*pairStorageInit, error) {
@@ -57,6 +118,21 @@ func parseStoragePairInit(opts ...*types.Pair) (*pairStorageInit, error) {
 	}
 	var v interface{}
 	var ok bool
+	v, ok = values[pairs.Credential]
+	if ok {
+		result.HasCredential = true
+		result.Credential = v.(*credential.Provider)
+	}
+	v, ok = values[pairs.Endpoint]
+	if ok {
+		result.HasEndpoint = true
+		result.Endpoint = v.(endpoint.Provider)
+	}
+	v, ok = values[pairs.Versioning]
+	if ok {
+		result.HasVersioning = true
+		result.Versioning = v.(bool)
+	}
 	v, ok = values[pairs.WorkDir]
 	if !ok {
 		return nil, types.NewErrPairRequired(pairs.WorkDir)
@@ -103,11 +179,83 @@ func parseStoragePairList(opts ...*types.Pair) (*pairStorageList, error) {
 	return result, nil
 }
 
+type pairStorageListVersions struct {
+	HasFileFunc bool
+	FileFunc    types.ObjectFunc
+}
+
+func parseStoragePairListVersions(opts ...*types.Pair) (*pairStorageListVersions, error) {
+	result := &pairStorageListVersions{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["list_versions"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["list_versions"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.FileFunc]
+	if ok {
+		result.HasFileFunc = true
+		result.FileFunc = v.(types.ObjectFunc)
+	}
+	return result, nil
+}
+
+type pairStorageReach struct {
+	HasExpire bool
+	Expire    int
+	HasMethod bool
+	Method    string
+}
+
+func parseStoragePairReach(opts ...*types.Pair) (*pairStorageReach, error) {
+	result := &pairStorageReach{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["reach"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["reach"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.Expire]
+	if !ok {
+		return nil, types.NewErrPairRequired(pairs.Expire)
+	}
+	if ok {
+		result.HasExpire = true
+		result.Expire = v.(int)
+	}
+	v, ok = values[pairs.Method]
+	if ok {
+		result.HasMethod = true
+		result.Method = v.(string)
+	}
+	return result, nil
+}
+
 type pairStorageRead struct {
-	HasOffset bool
-	Offset    int64
-	HasSize   bool
-	Size      int64
+	HasIfModifiedSince   bool
+	IfModifiedSince      time.Time
+	HasIfUnmodifiedSince bool
+	IfUnmodifiedSince    time.Time
+	HasOffset            bool
+	Offset               int64
+	HasSize              bool
+	Size                 int64
+	HasVersionId         bool
+	VersionId            string
 }
 
 func parseStoragePairRead(opts ...*types.Pair) (*pairStorageRead, error) {
@@ -125,6 +273,16 @@ func parseStoragePairRead(opts ...*types.Pair) (*pairStorageRead, error) {
 	}
 	var v interface{}
 	var ok bool
+	v, ok = values[pairs.IfModifiedSince]
+	if ok {
+		result.HasIfModifiedSince = true
+		result.IfModifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.IfUnmodifiedSince]
+	if ok {
+		result.HasIfUnmodifiedSince = true
+		result.IfUnmodifiedSince = v.(time.Time)
+	}
 	v, ok = values[pairs.Offset]
 	if ok {
 		result.HasOffset = true
@@ -135,12 +293,65 @@ func parseStoragePairRead(opts ...*types.Pair) (*pairStorageRead, error) {
 		result.HasSize = true
 		result.Size = v.(int64)
 	}
+	v, ok = values[pairs.VersionId]
+	if ok {
+		result.HasVersionId = true
+		result.VersionId = v.(string)
+	}
+	return result, nil
+}
+
+type pairStorageStat struct {
+	HasIfModifiedSince   bool
+	IfModifiedSince      time.Time
+	HasIfUnmodifiedSince bool
+	IfUnmodifiedSince    time.Time
+	HasVersionId         bool
+	VersionId            string
+}
+
+func parseStoragePairStat(opts ...*types.Pair) (*pairStorageStat, error) {
+	result := &pairStorageStat{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["stat"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["stat"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.IfModifiedSince]
+	if ok {
+		result.HasIfModifiedSince = true
+		result.IfModifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.IfUnmodifiedSince]
+	if ok {
+		result.HasIfUnmodifiedSince = true
+		result.IfUnmodifiedSince = v.(time.Time)
+	}
+	v, ok = values[pairs.VersionId]
+	if ok {
+		result.HasVersionId = true
+		result.VersionId = v.(string)
+	}
 	return result, nil
 }
 
 type pairStorageWrite struct {
-	HasSize bool
-	Size    int64
+	HasIfMatch           bool
+	IfMatch              string
+	HasIfNoneMatch       bool
+	IfNoneMatch          string
+	HasIfUnmodifiedSince bool
+	IfUnmodifiedSince    time.Time
+	HasSize              bool
+	Size                 int64
 }
 
 func parseStoragePairWrite(opts ...*types.Pair) (*pairStorageWrite, error) {
@@ -158,6 +369,21 @@ func parseStoragePairWrite(opts ...*types.Pair) (*pairStorageWrite, error) {
 	}
 	var v interface{}
 	var ok bool
+	v, ok = values[pairs.IfMatch]
+	if ok {
+		result.HasIfMatch = true
+		result.IfMatch = v.(string)
+	}
+	v, ok = values[pairs.IfNoneMatch]
+	if ok {
+		result.HasIfNoneMatch = true
+		result.IfNoneMatch = v.(string)
+	}
+	v, ok = values[pairs.IfUnmodifiedSince]
+	if ok {
+		result.HasIfUnmodifiedSince = true
+		result.IfUnmodifiedSince = v.(time.Time)
+	}
 	v, ok = values[pairs.Size]
 	if ok {
 		result.HasSize = true
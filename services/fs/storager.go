@@ -1,13 +1,20 @@
 package fs
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	neturl "net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/Xuanwo/storage/pkg/credential"
 	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/pkg/server"
 	"github.com/Xuanwo/storage/types"
 	"github.com/Xuanwo/storage/types/metadata"
 )
@@ -22,6 +29,12 @@ type Storage struct {
 	// options for this storager.
 	workDir string // workDir dir for all operation.
 
+	// endpoint and signer are used to generate urls for a pkg/server which serves this dir.
+	endpoint string
+	signer   *server.Signer
+	// versioning will keep older copies of overwritten or deleted files in versionDir.
+	versioning bool
+
 	// All stdlib call will be added here for better unit test.
 	ioCopyBuffer  func(dst io.Writer, src io.Reader, buf []byte) (written int64, err error)
 	ioCopyN       func(dst io.Writer, src io.Reader, n int64) (written int64, err error)
@@ -29,6 +42,7 @@ type Storage struct {
 	osCreate      func(name string) (*os.File, error)
 	osMkdirAll    func(path string, perm os.FileMode) error
 	osOpen        func(name string) (*os.File, error)
+	osOpenFile    func(name string, flag int, perm os.FileMode) (*os.File, error)
 	osRemove      func(name string) error
 	osRename      func(oldpath, newpath string) error
 	osStat        func(name string) (os.FileInfo, error)
@@ -43,6 +57,7 @@ func New() *Storage {
 		osCreate:      os.Create,
 		osMkdirAll:    os.MkdirAll,
 		osOpen:        os.Open,
+		osOpenFile:    os.OpenFile,
 		osRemove:      os.Remove,
 		osRename:      os.Rename,
 		osStat:        os.Stat,
@@ -56,17 +71,28 @@ func (s *Storage) String() string {
 
 // Init implements Storager.Init
 func (s *Storage) Init(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Init: %w"
-
 	opt, err := parseStoragePairInit(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "Init", err)
 	}
 
 	if opt.HasWorkDir {
 		// TODO: validate workDir.
 		s.workDir = opt.WorkDir
 	}
+	if opt.HasEndpoint {
+		s.endpoint = opt.Endpoint.Value().String()
+	}
+	if opt.HasVersioning {
+		s.versioning = opt.Versioning
+	}
+	if opt.HasCredential {
+		credProtocol, cred := opt.Credential.Protocol(), opt.Credential.Value()
+		if credProtocol != credential.ProtocolHmac {
+			return types.NewError(s, "Init", credential.ErrUnsupportedProtocol)
+		}
+		s.signer = server.NewSigner([]byte(cred[1]))
+	}
 	return nil
 }
 
@@ -81,8 +107,11 @@ func (s *Storage) Metadata() (m metadata.Storage, err error) {
 }
 
 // Stat implements Storager.Stat
-func (s *Storage) Stat(path string, option ...*types.Pair) (o *types.Object, err error) {
-	const errorMessage = "%s Stat [%s]: %w"
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	opt, err := parseStoragePairStat(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
 
 	if path == "-" {
 		return &types.Object{
@@ -93,11 +122,28 @@ func (s *Storage) Stat(path string, option ...*types.Pair) (o *types.Object, err
 		}, nil
 	}
 
+	err = s.checkPath(path)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+
 	rp := s.getAbsPath(path)
+	if opt.HasVersionId {
+		rp, err = s.getVersionPath(path, opt.VersionId)
+		if err != nil {
+			return nil, types.NewError(s, "Stat", err, path)
+		}
+	}
 
 	fi, err := s.osStat(rp)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, handleOsError(err))
+		return nil, types.NewError(s, "Stat", handleOsError(err), path)
+	}
+	if opt.HasIfModifiedSince && !isModifiedSince(fi.ModTime(), opt.IfModifiedSince) {
+		return nil, types.NewError(s, "Stat", types.ErrPreconditionFailed, path)
+	}
+	if opt.HasIfUnmodifiedSince && isModifiedSince(fi.ModTime(), opt.IfUnmodifiedSince) {
+		return nil, types.NewError(s, "Stat", types.ErrPreconditionFailed, path)
 	}
 
 	o = &types.Object{
@@ -106,6 +152,9 @@ func (s *Storage) Stat(path string, option ...*types.Pair) (o *types.Object, err
 		UpdatedAt: fi.ModTime(),
 		Metadata:  make(metadata.Metadata),
 	}
+	if opt.HasVersionId {
+		o.SetVersionId(opt.VersionId)
+	}
 
 	if fi.IsDir() {
 		o.Type = types.ObjectTypeDir
@@ -124,22 +173,91 @@ func (s *Storage) Stat(path string, option ...*types.Pair) (o *types.Object, err
 	return o, nil
 }
 
+// Reach implements Storager.Reach
+//
+// fs can't be reached by itself, a pkg/server should serve the same dir at endpoint, and credential's
+// secret key should be the same as the server's signer if signature is required.
+func (s *Storage) Reach(path string, pairs ...*types.Pair) (url string, err error) {
+	opt, err := parseStoragePairReach(pairs...)
+	if err != nil {
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	if s.endpoint == "" {
+		err = fmt.Errorf("endpoint not set: %w", types.ErrNotSupported)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	method := http.MethodGet
+	if opt.HasMethod {
+		method = opt.Method
+	}
+	if method != http.MethodGet && method != http.MethodPut {
+		err = fmt.Errorf("method %s: %w", method, types.ErrNotSupported)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	// Server will validate signature with the cleaned path, so the same path should be signed here.
+	p, ok := server.CleanPath(path)
+	if !ok {
+		err = fmt.Errorf("%w: %s contains .. segment", types.ErrInvalidPath, path)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	u := &neturl.URL{Path: "/" + p}
+	url = strings.TrimSuffix(s.endpoint, "/") + u.EscapedPath()
+	if s.signer != nil {
+		url += "?" + s.signer.Sign(method, p, time.Duration(opt.Expire)*time.Second).Encode()
+	}
+	return url, nil
+}
+
 // Delete implements Storager.Delete
+//
+// File will be moved into versions if versioning is enabled, and the version will be deleted permanently if
+// version_id is given.
 func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
+	opt, err := parseStoragePairDelete(pairs...)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+
+	err = s.checkPath(path)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
 
 	rp := s.getAbsPath(path)
+	if opt.HasVersionId {
+		rp, err = s.getVersionPath(path, opt.VersionId)
+		if err != nil {
+			return types.NewError(s, "Delete", err, path)
+		}
+	} else if s.versioning {
+		ok, err := s.archive(path)
+		if err != nil {
+			return types.NewError(s, "Delete", err, path)
+		}
+		if ok {
+			return nil
+		}
+	}
 
 	err = s.osRemove(rp)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, handleOsError(err))
+		return types.NewError(s, "Delete", handleOsError(err), path)
 	}
 	return nil
 }
 
 // Copy implements Storager.Copy
 func (s *Storage) Copy(src, dst string, option ...*types.Pair) (err error) {
-	const errorMessage = "%s Copy from [%s] to [%s]: %w"
+	for _, v := range []string{src, dst} {
+		err = s.checkPath(v)
+		if err != nil {
+			return types.NewError(s, "Copy", err, src, dst)
+		}
+	}
 
 	rs := s.getAbsPath(src)
 	rd := s.getAbsPath(dst)
@@ -147,31 +265,43 @@ func (s *Storage) Copy(src, dst string, option ...*types.Pair) (err error) {
 	// Create dir for dst.
 	err = s.createDir(dst)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, src, dst, err)
+		return types.NewError(s, "Copy", err, src, dst)
 	}
 
 	srcFile, err := s.osOpen(rs)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, src, dst, handleOsError(err))
+		return types.NewError(s, "Copy", handleOsError(err), src, dst)
 	}
 	defer srcFile.Close()
 
+	if s.versioning {
+		_, err = s.archive(dst)
+		if err != nil {
+			return types.NewError(s, "Copy", err, src, dst)
+		}
+	}
+
 	dstFile, err := s.osCreate(rd)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, src, dst, handleOsError(err))
+		return types.NewError(s, "Copy", handleOsError(err), src, dst)
 	}
 	defer dstFile.Close()
 
 	_, err = s.ioCopyBuffer(dstFile, srcFile, make([]byte, 1024*1024))
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, src, dst, handleOsError(err))
+		return types.NewError(s, "Copy", handleOsError(err), src, dst)
 	}
 	return
 }
 
 // Move implements Storager.Move
 func (s *Storage) Move(src, dst string, option ...*types.Pair) (err error) {
-	const errorMessage = "%s Move from [%s] to [%s]: %w"
+	for _, v := range []string{src, dst} {
+		err = s.checkPath(v)
+		if err != nil {
+			return types.NewError(s, "Move", err, src, dst)
+		}
+	}
 
 	rs := s.getAbsPath(src)
 	rd := s.getAbsPath(dst)
@@ -179,33 +309,52 @@ func (s *Storage) Move(src, dst string, option ...*types.Pair) (err error) {
 	// Create dir for dst path.
 	err = s.createDir(dst)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, src, dst, err)
+		return types.NewError(s, "Move", err, src, dst)
+	}
+
+	if s.versioning {
+		// Check src before archiving, so that dst will not be moved away if src doesn't exist.
+		_, err = s.osStat(rs)
+		if err != nil {
+			return types.NewError(s, "Move", handleOsError(err), src, dst)
+		}
+		_, err = s.archive(dst)
+		if err != nil {
+			return types.NewError(s, "Move", err, src, dst)
+		}
 	}
 
 	err = s.osRename(rs, rd)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, src, dst, handleOsError(err))
+		return types.NewError(s, "Move", handleOsError(err), src, dst)
 	}
 	return
 }
 
 // List implements Storager.List
 func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List [%s]: %w"
-
 	opt, err := parseStoragePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "List", err, path)
+	}
+
+	err = s.checkPath(path)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
 	}
 
 	rp := s.getAbsPath(path)
 
 	fi, err := s.ioutilReadDir(rp)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, handleOsError(err))
+		return types.NewError(s, "List", handleOsError(err), path)
 	}
 
 	for _, v := range fi {
+		if s.versioning && v.Name() == versionDir && rp == s.getAbsPath("") {
+			continue
+		}
+
 		o := &types.Object{
 			Name:      filepath.Join(path, v.Name()),
 			Size:      v.Size(),
@@ -231,11 +380,9 @@ func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 
 // Read implements Storager.Read
 func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
-	const errorMessage = "%s Read [%s]: %w"
-
 	opt, err := parseStoragePairRead(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		return nil, types.NewError(s, "Read", err, path)
 	}
 
 	// If path is "-", return stdin directly.
@@ -247,11 +394,35 @@ func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err
 		return f, nil
 	}
 
+	err = s.checkPath(path)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
 	rp := s.getAbsPath(path)
+	if opt.HasVersionId {
+		rp, err = s.getVersionPath(path, opt.VersionId)
+		if err != nil {
+			return nil, types.NewError(s, "Read", err, path)
+		}
+	}
 
 	f, err := s.osOpen(rp)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, handleOsError(err))
+		return nil, types.NewError(s, "Read", handleOsError(err), path)
+	}
+	// Check preconditions on the opened file so that it can't be replaced after check.
+	if opt.HasIfModifiedSince || opt.HasIfUnmodifiedSince {
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, types.NewError(s, "Read", handleOsError(err), path)
+		}
+		if (opt.HasIfModifiedSince && !isModifiedSince(fi.ModTime(), opt.IfModifiedSince)) ||
+			(opt.HasIfUnmodifiedSince && isModifiedSince(fi.ModTime(), opt.IfUnmodifiedSince)) {
+			_ = f.Close()
+			return nil, types.NewError(s, "Read", types.ErrPreconditionFailed, path)
+		}
 	}
 	if opt.HasSize && opt.HasOffset {
 		return iowrap.SectionReadCloser(f, opt.Offset, opt.Size), nil
@@ -262,7 +433,7 @@ func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err
 	if opt.HasOffset {
 		_, err = f.Seek(opt.Offset, 0)
 		if err != nil {
-			return nil, fmt.Errorf(errorMessage, s, path, handleOsError(err))
+			return nil, types.NewError(s, "Read", handleOsError(err), path)
 		}
 	}
 	return f, nil
@@ -270,29 +441,69 @@ func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err
 
 // WriteFile implements Storager.WriteFile
 func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Write [%s]: %w"
-
 	opt, err := parseStoragePairWrite(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
 	}
 
 	var f io.WriteCloser
+	// created is the file created by create-only write, it will be removed if write failed, so that the
+	// next create-only write will not be blocked by a partial file.
+	var created string
 	// If path is "-", use stdout directly.
 	if path == "-" {
 		f = os.Stdout
 	} else {
+		err = s.checkPath(path)
+		if err != nil {
+			return types.NewError(s, "Write", err, path)
+		}
+
 		// Create dir for path.
 		err = s.createDir(path)
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, path, err)
+			return types.NewError(s, "Write", err, path)
 		}
 
 		rp := s.getAbsPath(path)
 
-		f, err = s.osCreate(rp)
+		if opt.HasIfUnmodifiedSince {
+			fi, err := s.osStat(rp)
+			if err != nil {
+				return types.NewError(s, "Write", handleOsError(err), path)
+			}
+			if isModifiedSince(fi.ModTime(), opt.IfUnmodifiedSince) {
+				return types.NewError(s, "Write", types.ErrPreconditionFailed, path)
+			}
+		}
+
+		// fs doesn't have etag, so only if_none_match "*" which means create-only is supported.
+		if opt.HasIfMatch {
+			err = fmt.Errorf("%w: if_match %s", types.ErrNotSupported, opt.IfMatch)
+			return types.NewError(s, "Write", err, path)
+		}
+		if opt.HasIfNoneMatch && opt.IfNoneMatch != "*" {
+			err = fmt.Errorf("%w: if_none_match %s", types.ErrNotSupported, opt.IfNoneMatch)
+			return types.NewError(s, "Write", err, path)
+		}
+
+		if opt.HasIfNoneMatch {
+			f, err = s.osOpenFile(rp, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+			if errors.Is(err, os.ErrExist) {
+				return types.NewError(s, "Write", types.ErrPreconditionFailed, path)
+			}
+			created = rp
+		} else {
+			if s.versioning {
+				_, err = s.archive(path)
+				if err != nil {
+					return types.NewError(s, "Write", err, path)
+				}
+			}
+			f, err = s.osCreate(rp)
+		}
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, path, handleOsError(err))
+			return types.NewError(s, "Write", handleOsError(err), path)
 		}
 	}
 
@@ -302,7 +513,119 @@ func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err err
 		_, err = s.ioCopyBuffer(f, r, make([]byte, 1024*1024))
 	}
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, handleOsError(err))
+		if created != "" {
+			_ = f.Close()
+			_ = s.osRemove(created)
+		}
+		return types.NewError(s, "Write", handleOsError(err), path)
 	}
 	return
 }
+
+// ListVersions implements Storager.ListVersions
+//
+// The current file will be listed first without version id, and then versions from newest to oldest.
+func (s *Storage) ListVersions(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairListVersions(pairs...)
+	if err != nil {
+		return types.NewError(s, "ListVersions", err, path)
+	}
+
+	err = s.checkPath(path)
+	if err != nil {
+		return types.NewError(s, "ListVersions", err, path)
+	}
+	vp, err := s.getVersionPath(path, "")
+	if err != nil {
+		return types.NewError(s, "ListVersions", err, path)
+	}
+
+	objects := make([]*types.Object, 0)
+
+	fi, err := s.osStat(s.getAbsPath(path))
+	if err == nil && fi.Mode().IsRegular() {
+		objects = append(objects, &types.Object{
+			Name:      path,
+			Type:      types.ObjectTypeFile,
+			Size:      fi.Size(),
+			UpdatedAt: fi.ModTime(),
+			Metadata:  make(metadata.Metadata),
+		})
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return types.NewError(s, "ListVersions", handleOsError(err), path)
+	}
+
+	versions, err := s.ioutilReadDir(vp)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return types.NewError(s, "ListVersions", handleOsError(err), path)
+	}
+	// Version ids are ordered by time.
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		if !v.Mode().IsRegular() {
+			continue
+		}
+
+		o := &types.Object{
+			Name:      path,
+			Type:      types.ObjectTypeFile,
+			Size:      v.Size(),
+			UpdatedAt: v.ModTime(),
+			Metadata:  make(metadata.Metadata),
+		}
+		o.SetVersionId(v.Name())
+		objects = append(objects, o)
+	}
+
+	if len(objects) == 0 {
+		return types.NewError(s, "ListVersions", types.ErrObjectNotExist, path)
+	}
+	if opt.HasFileFunc {
+		for _, o := range objects {
+			opt.FileFunc(o)
+		}
+	}
+	return nil
+}
+
+// Restore implements Storager.Restore
+//
+// The current file will be moved into versions before the version is copied over it.
+func (s *Storage) Restore(path, version string, pairs ...*types.Pair) (err error) {
+	err = s.checkPath(path)
+	if err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+	vp, err := s.getVersionPath(path, version)
+	if err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+
+	src, err := s.osOpen(vp)
+	if err != nil {
+		return types.NewError(s, "Restore", handleOsError(err), path)
+	}
+	defer src.Close()
+
+	_, err = s.archive(path)
+	if err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+
+	err = s.createDir(path)
+	if err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+
+	dst, err := s.osCreate(s.getAbsPath(path))
+	if err != nil {
+		return types.NewError(s, "Restore", handleOsError(err), path)
+	}
+	defer dst.Close()
+
+	_, err = s.ioCopyBuffer(dst, src, make([]byte, 1024*1024))
+	if err != nil {
+		return types.NewError(s, "Restore", handleOsError(err), path)
+	}
+	return nil
+}
@@ -3,9 +3,14 @@ package fs
 import (
 	"errors"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +20,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/server"
 	"github.com/Xuanwo/storage/types"
 	"github.com/Xuanwo/storage/types/pairs"
 )
@@ -212,6 +220,93 @@ func TestStorage_Stat(t *testing.T) {
 	}
 }
 
+func TestStorage_StatWithPrecondition(t *testing.T) {
+	modTime := time.Now()
+
+	tests := []struct {
+		name    string
+		pairs   []*types.Pair
+		wantErr error
+	}{
+		{"modified since before", []*types.Pair{pairs.WithIfModifiedSince(modTime.Add(-time.Hour))}, nil},
+		{"modified since after", []*types.Pair{pairs.WithIfModifiedSince(modTime.Add(time.Hour))}, types.ErrPreconditionFailed},
+		{"modified since equal", []*types.Pair{pairs.WithIfModifiedSince(modTime)}, types.ErrPreconditionFailed},
+		{"unmodified since before", []*types.Pair{pairs.WithIfUnmodifiedSince(modTime.Add(-time.Hour))}, types.ErrPreconditionFailed},
+		{"unmodified since after", []*types.Pair{pairs.WithIfUnmodifiedSince(modTime.Add(time.Hour))}, nil},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			client := Storage{
+				osStat: func(name string) (os.FileInfo, error) {
+					return fileInfo{name: name, mode: 0777, modTime: modTime}, nil
+				},
+			}
+			_, err := client.Stat(v.name, v.pairs...)
+			if v.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, v.wantErr))
+			}
+		})
+	}
+}
+
+func TestStorage_WriteWithIfNoneMatch(t *testing.T) {
+	path := uuid.New().String()
+
+	client := Storage{
+		osMkdirAll: func(path string, perm os.FileMode) error {
+			return nil
+		},
+		osOpenFile: func(name string, flag int, perm os.FileMode) (*os.File, error) {
+			assert.Equal(t, path, name)
+			assert.Equal(t, os.O_EXCL, flag&os.O_EXCL)
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+		},
+	}
+
+	err := client.Write(path, nil, pairs.WithIfNoneMatch("*"))
+	assert.True(t, errors.Is(err, types.ErrPreconditionFailed))
+}
+
+func TestStorage_WriteWithUnsupportedPreconditions(t *testing.T) {
+	client := Storage{
+		osMkdirAll: func(path string, perm os.FileMode) error {
+			return nil
+		},
+	}
+
+	err := client.Write("test", nil, pairs.WithIfMatch("etag"))
+	assert.True(t, errors.Is(err, types.ErrNotSupported))
+
+	err = client.Write("test", nil, pairs.WithIfNoneMatch("etag"))
+	assert.True(t, errors.Is(err, types.ErrNotSupported))
+}
+
+func TestStorage_WriteWithIfNoneMatchFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := New()
+	err = client.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Input is shorter than size.
+	err = client.Write("test", strings.NewReader("a"), pairs.WithSize(2), pairs.WithIfNoneMatch("*"))
+	assert.Error(t, err)
+	_, err = client.Stat("test")
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+	err = client.Write("test", strings.NewReader("ab"), pairs.WithSize(2), pairs.WithIfNoneMatch("*"))
+	assert.NoError(t, err)
+}
+
 func TestStorage_WriteStream(t *testing.T) {
 	err := os.Remove("/tmp/test")
 	var e *os.PathError
@@ -734,3 +829,246 @@ func TestStorage_Write(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_Reach(t *testing.T) {
+	t.Run("without endpoint", func(t *testing.T) {
+		client := Storage{}
+
+		_, err := client.Reach("test", pairs.WithExpire(100))
+		assert.True(t, errors.Is(err, types.ErrNotSupported))
+	})
+
+	t.Run("without credential", func(t *testing.T) {
+		client := Storage{}
+		err := client.Init(
+			pairs.WithWorkDir("/tmp"),
+			pairs.WithEndpoint(endpoint.NewHTTP("127.0.0.1", 8080)),
+		)
+		assert.NoError(t, err)
+
+		u, err := client.Reach("test dir/file", pairs.WithExpire(100))
+		assert.NoError(t, err)
+		assert.Equal(t, "http://127.0.0.1:8080/test%20dir/file", u)
+	})
+
+	t.Run("with credential", func(t *testing.T) {
+		cred, err := credential.NewHmac("test_access_key", "test_secret_key")
+		assert.NoError(t, err)
+
+		client := Storage{}
+		err = client.Init(
+			pairs.WithWorkDir("/tmp"),
+			pairs.WithEndpoint(endpoint.NewHTTP("127.0.0.1", 8080)),
+			pairs.WithCredential(cred),
+		)
+		assert.NoError(t, err)
+
+		u, err := client.Reach("test", pairs.WithExpire(100), pairs.WithMethod(http.MethodPut))
+		assert.NoError(t, err)
+
+		parsed, err := url.Parse(u)
+		assert.NoError(t, err)
+		signer := server.NewSigner([]byte("test_secret_key"))
+		assert.NoError(t, signer.Validate(http.MethodPut, "test", parsed.Query()))
+	})
+
+	t.Run("unclean path", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		cred, err := credential.NewHmac("test_access_key", "test_secret_key")
+		assert.NoError(t, err)
+
+		client := New()
+		err = client.Init(
+			pairs.WithWorkDir(dir),
+			pairs.WithEndpoint(endpoint.NewHTTP("127.0.0.1", 8080)),
+			pairs.WithCredential(cred),
+		)
+		assert.NoError(t, err)
+		err = client.Write("a/b", strings.NewReader("b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := client.Reach("/a//b", pairs.WithExpire(100))
+		assert.NoError(t, err)
+
+		srv := server.New(client, server.NewSigner([]byte("test_secret_key")))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "b", w.Body.String())
+
+		_, err = client.Reach("a/../b", pairs.WithExpire(100))
+		assert.True(t, errors.Is(err, types.ErrInvalidPath))
+	})
+
+	t.Run("unsupported method", func(t *testing.T) {
+		client := Storage{endpoint: "http://127.0.0.1:8080"}
+
+		_, err := client.Reach("test", pairs.WithExpire(100), pairs.WithMethod(http.MethodDelete))
+		assert.True(t, errors.Is(err, types.ErrNotSupported))
+	})
+}
+
+func TestStorage_Versioning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := New()
+	err = client.Init(pairs.WithWorkDir(dir), pairs.WithVersioning(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []string{"a", "b"} {
+		err = client.Write("test", strings.NewReader(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions := make([]*types.Object, 0)
+	err = client.ListVersions("test", pairs.WithFileFunc(func(o *types.Object) {
+		versions = append(versions, o)
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(versions))
+	_, ok := versions[0].GetVersionId()
+	assert.False(t, ok)
+	id, ok := versions[1].GetVersionId()
+	assert.True(t, ok)
+
+	r, err := client.Read("test", pairs.WithVersionId(id))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "a", string(content))
+
+	// Versions dir should not be listed.
+	names := make([]string, 0)
+	err = client.List("", pairs.WithFileFunc(func(o *types.Object) {
+		names = append(names, o.Name)
+	}), pairs.WithDirFunc(func(o *types.Object) {
+		names = append(names, o.Name)
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test"}, names)
+
+	err = client.Delete("test")
+	assert.NoError(t, err)
+	_, err = client.Stat("test")
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+	err = client.Restore("test", id)
+	assert.NoError(t, err)
+	r, err = client.Read("test")
+	assert.NoError(t, err)
+	content, _ = ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "a", string(content))
+
+	err = client.Delete("test", pairs.WithVersionId(id))
+	assert.NoError(t, err)
+	_, err = client.Stat("test", pairs.WithVersionId(id))
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+	// Version ids which are not generated by fs should not reach other files.
+	for _, v := range []string{"../../test", "..", "1"} {
+		_, err = client.Read("test", pairs.WithVersionId(v))
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		_, err = client.Stat("test", pairs.WithVersionId(v))
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = client.Delete("test", pairs.WithVersionId(v))
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = client.Restore("test", v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+	}
+	_, err = client.Read("../test", pairs.WithVersionId(id))
+	assert.True(t, errors.Is(err, types.ErrInvalidPath))
+
+	// Versions dir could not be operated as user paths.
+	for _, v := range []string{".versions", "/.versions/test", "a/../.versions/test"} {
+		_, err = client.Stat(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		_, err = client.Read(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = client.Write(v, strings.NewReader("x"))
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = client.Delete(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = client.List(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+	}
+}
+
+func TestStorage_VersioningCopyAndMove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := New()
+	err = client.Init(pairs.WithWorkDir(dir), pairs.WithVersioning(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readVersions := func(path string) []string {
+		contents := make([]string, 0)
+		err := client.ListVersions(path, pairs.WithFileFunc(func(o *types.Object) {
+			ps := make([]*types.Pair, 0)
+			if v, ok := o.GetVersionId(); ok {
+				ps = append(ps, pairs.WithVersionId(v))
+			}
+			r, err := client.Read(path, ps...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			content, _ := ioutil.ReadAll(r)
+			contents = append(contents, string(content))
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return contents
+	}
+
+	for _, v := range []string{"src", "dst"} {
+		err = client.Write(v, strings.NewReader(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("copy", func(t *testing.T) {
+		err := client.Copy("src", "dst")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"src", "dst"}, readVersions("dst"))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		err := client.Write("moved", strings.NewReader("moved"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = client.Move("moved", "dst")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"moved", "src", "dst"}, readVersions("dst"))
+
+		// dst should be kept if src doesn't exist.
+		err = client.Move("not_exist", "dst")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		assert.Equal(t, []string{"moved", "src", "dst"}, readVersions("dst"))
+	})
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Xuanwo/storage/types"
 )
@@ -34,6 +37,9 @@ func (s *Storage) createDir(path string) (err error) {
 	return
 }
 
+// versionDir is the hidden dir under work dir to keep versions of files.
+const versionDir = ".versions"
+
 func (s *Storage) getAbsPath(path string) string {
 	return filepath.Join(s.workDir, path)
 }
@@ -45,6 +51,76 @@ func (s *Storage) getDirPath(path string) string {
 	return filepath.Join(s.workDir, filepath.Dir(path))
 }
 
+// versionIdRegexp matches version ids generated by archive.
+var versionIdRegexp = regexp.MustCompile(`^\d{19}$`)
+
+// checkPath will reject paths under versionDir while versioning is enabled, so that versions could only be
+// operated via version_id.
+func (s *Storage) checkPath(path string) (err error) {
+	if !s.versioning {
+		return nil
+	}
+
+	p := strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+path)), "/")
+	if p == versionDir || strings.HasPrefix(p, versionDir+"/") {
+		return fmt.Errorf("%w: %s is reserved for versions", types.ErrInvalidPath, path)
+	}
+	return nil
+}
+
+// getVersionPath will return the path of a version of path, the dir of all versions will be returned if id is
+// empty.
+//
+// ErrInvalidPath will be returned if id is not generated by archive or path is out of work dir, so that
+// versions could not be used to reach other files.
+func (s *Storage) getVersionPath(path, id string) (vp string, err error) {
+	if id != "" && !versionIdRegexp.MatchString(id) {
+		return "", fmt.Errorf("%w: invalid version id %s", types.ErrInvalidPath, id)
+	}
+
+	p := filepath.Clean(path)
+	if p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is out of work dir", types.ErrInvalidPath, path)
+	}
+	return filepath.Join(s.workDir, versionDir, p, id), nil
+}
+
+// archive will move the current file of path into versions, ok will be false if path is not a regular file.
+func (s *Storage) archive(path string) (ok bool, err error) {
+	rp := s.getAbsPath(path)
+
+	fi, err := s.osStat(rp)
+	if err != nil && (errors.Is(err, os.ErrNotExist) || os.IsNotExist(err)) {
+		return false, nil
+	}
+	if err != nil {
+		return false, handleOsError(err)
+	}
+	if !fi.Mode().IsRegular() {
+		return false, nil
+	}
+
+	// Version ids have the same width, so that they can be ordered by name.
+	vp, err := s.getVersionPath(path, fmt.Sprintf("%019d", time.Now().UnixNano()))
+	if err != nil {
+		return false, err
+	}
+	err = s.osMkdirAll(filepath.Dir(vp), 0755)
+	if err != nil {
+		return false, handleOsError(err)
+	}
+	err = s.osRename(rp, vp)
+	if err != nil {
+		return false, handleOsError(err)
+	}
+	return true, nil
+}
+
+// isModifiedSince will check whether t is after since in http date's second precision.
+func isModifiedSince(t, since time.Time) bool {
+	return t.Truncate(time.Second).After(since.Truncate(time.Second))
+}
+
 func handleOsError(err error) error {
 	if err == nil {
 		panic("error must not be nil")
@@ -54,6 +130,24 @@ func handleOsError(err error) error {
 	if errors.Is(err, os.ErrNotExist) || os.IsNotExist(err) {
 		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
 	}
-	// TODO: handle other osError here.
+
+	// ENOTEMPTY will be treated as os.ErrExist, so we need to check errno first.
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case syscall.ENOTEMPTY:
+			return fmt.Errorf("%w: %v", types.ErrDirNotEmpty, err)
+		case syscall.ENAMETOOLONG, syscall.EINVAL, syscall.ENOTDIR, syscall.EISDIR:
+			return fmt.Errorf("%w: %v", types.ErrInvalidPath, err)
+		case syscall.ENOSPC, syscall.EDQUOT:
+			return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+		}
+	}
+	if errors.Is(err, os.ErrExist) || os.IsExist(err) {
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	}
+	if errors.Is(err, os.ErrPermission) || os.IsPermission(err) {
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	}
 	return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
 }
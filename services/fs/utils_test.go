@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 
 	"github.com/Xuanwo/storage/types/pairs"
@@ -97,6 +98,31 @@ func TestHandleOsError(t *testing.T) {
 				fmt.Errorf("%w: some other infos", os.ErrNotExist),
 				types.ErrObjectNotExist,
 			},
+			{
+				"already exist",
+				&os.PathError{Op: "open", Path: "test", Err: os.ErrExist},
+				types.ErrObjectAlreadyExist,
+			},
+			{
+				"permission denied",
+				&os.PathError{Op: "open", Path: "test", Err: os.ErrPermission},
+				types.ErrPermissionDenied,
+			},
+			{
+				"dir not empty",
+				&os.PathError{Op: "remove", Path: "test", Err: syscall.ENOTEMPTY},
+				types.ErrDirNotEmpty,
+			},
+			{
+				"name too long",
+				&os.PathError{Op: "open", Path: "test", Err: syscall.ENAMETOOLONG},
+				types.ErrInvalidPath,
+			},
+			{
+				"no space",
+				&os.PathError{Op: "write", Path: "test", Err: syscall.ENOSPC},
+				types.ErrQuotaExceeded,
+			},
 			{
 				"other errors",
 				errors.New("expect unhandled error"),
@@ -0,0 +1,454 @@
+package ftp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage/types"
+)
+
+// All reply codes we need, see RFC 959 and RFC 2228.
+const (
+	codeFileStatusOK       = 150
+	codeCommandOK          = 200
+	codeFileStatus         = 213
+	codeServiceReady       = 220
+	codeClosingDataConn    = 226
+	codePassiveMode        = 227
+	codeExtendedPassive    = 229
+	codeLoggedIn           = 230
+	codeAuthOK             = 234
+	codeFileActionOK       = 250
+	codePathCreated        = 257
+	codeNeedPassword       = 331
+	codeFileActionPending  = 350
+	codeServiceUnavailable = 421
+	codeCannotOpenDataConn = 425
+	codeTransferAborted    = 426
+	codeNotImplemented     = 502
+	codeNotImplementedArg  = 504
+	codeNotLoggedIn        = 530
+	codeNeedAccount        = 532
+	codeFileUnavailable    = 550
+	codeExceededStorage    = 552
+	codeBadFileName        = 553
+)
+
+// entry is a file or dir returned by MLSD, MLST or LIST.
+type entry struct {
+	name    string
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// conn is a minimal FTP client connection which only speaks the commands we need.
+//
+// A conn can only run one command at the same time, so it must not be shared between goroutines.
+type conn struct {
+	netConn net.Conn
+	text    *textproto.Conn
+	// host is the host of control connection which will be used to dial data connection.
+	host string
+	// tlsConfig will be used for both control and data connections, nil means plain FTP.
+	tlsConfig *tls.Config
+
+	mlstSupported bool
+	epsvDisabled  bool
+}
+
+// dial will connect to addr, and upgrade to TLS via AUTH TLS if tlsConfig is not nil.
+func dial(addr string, tlsConfig *tls.Config) (c *conn, err error) {
+	netConn, err := net.DialTimeout("tcp", addr, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &conn{
+		netConn:   netConn,
+		text:      textproto.NewConn(netConn),
+		host:      netConn.RemoteAddr().(*net.TCPAddr).IP.String(),
+		tlsConfig: tlsConfig,
+	}
+	if _, _, err = c.text.ReadResponse(codeServiceReady); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return c, nil
+	}
+
+	if _, _, err = c.cmd(codeAuthOK, "AUTH TLS"); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	tlsConn := tls.Client(netConn, tlsConfig)
+	if err = tlsConn.Handshake(); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	c.netConn, c.text = tlsConn, textproto.NewConn(tlsConn)
+
+	// Data connections should be protected too.
+	if _, _, err = c.cmd(codeCommandOK, "PBSZ 0"); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	if _, _, err = c.cmd(codeCommandOK, "PROT P"); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// login will authenticate this conn, switch into binary mode and detect server features.
+func (c *conn) login(user, password string) (err error) {
+	code, _, err := c.cmd(-1, "USER %s", user)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case codeLoggedIn:
+	case codeNeedPassword:
+		if _, _, err = c.cmd(codeLoggedIn, "PASS %s", password); err != nil {
+			return err
+		}
+	default:
+		return &textproto.Error{Code: code, Msg: "unexpected reply for USER"}
+	}
+
+	if _, _, err = c.cmd(codeCommandOK, "TYPE I"); err != nil {
+		return err
+	}
+
+	// FEAT is optional, server doesn't support it will be treated as no features.
+	code, msg, err := c.cmd(-1, "FEAT")
+	if err != nil {
+		return err
+	}
+	if code/100 == 2 {
+		for _, v := range strings.Split(msg, "\n") {
+			if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(v)), "MLST") {
+				c.mlstSupported = true
+			}
+		}
+	}
+	return nil
+}
+
+// pwd will return current dir.
+func (c *conn) pwd() (string, error) {
+	_, msg, err := c.cmd(codePathCreated, "PWD")
+	if err != nil {
+		return "", err
+	}
+	// Reply is formatted as `"/path" is current directory`, and quote in path will be doubled.
+	start, end := strings.Index(msg, `"`), strings.LastIndex(msg, `"`)
+	if start < 0 || end <= start {
+		return "", fmt.Errorf("invalid reply for PWD: %s", msg)
+	}
+	return strings.Replace(msg[start+1:end], `""`, `"`, -1), nil
+}
+
+// list will list entries under dir via MLSD, LIST will be used if MLSD is not supported.
+func (c *conn) list(dir string) (entries []*entry, err error) {
+	command, parse := "LIST", parseListLine
+	if c.mlstSupported {
+		command, parse = "MLSD", parseMLSxLine
+	}
+
+	dataConn, err := c.dataCmd(0, "%s %s", command, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(dataConn)
+	for scanner.Scan() {
+		e, ok := parse(scanner.Text())
+		if !ok {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	err = scanner.Err()
+	_ = dataConn.Close()
+	if _, _, rerr := c.text.ReadResponse(codeClosingDataConn); rerr != nil && err == nil {
+		err = rerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// stat will return entry of p via MLST, the parent dir will be listed if MLST is not supported.
+func (c *conn) stat(p string) (*entry, error) {
+	if !c.mlstSupported {
+		return c.statByList(p)
+	}
+
+	_, msg, err := c.cmd(codeFileActionOK, "MLST %s", p)
+	if err != nil {
+		return nil, err
+	}
+	// Reply is formatted as "250-Listing p\n facts; p\n250 End", textproto only keeps the middle line.
+	for _, v := range strings.Split(msg, "\n") {
+		if !strings.HasPrefix(v, " ") {
+			continue
+		}
+		if e, ok := parseMLSxLine(v[1:]); ok {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid reply for MLST: %s", msg)
+}
+
+func (c *conn) statByList(p string) (*entry, error) {
+	dir, name := splitPath(p)
+	entries, err := c.list(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.name == name {
+			return e, nil
+		}
+	}
+	return nil, &textproto.Error{Code: codeFileUnavailable, Msg: "no such file or directory"}
+}
+
+// retr will retrieve p from offset via REST and RETR.
+func (c *conn) retr(p string, offset int64) (io.ReadCloser, error) {
+	dataConn, err := c.dataCmd(offset, "RETR %s", p)
+	if err != nil {
+		return nil, err
+	}
+	return &response{conn: dataConn, c: c}, nil
+}
+
+// stor will store r into p via STOR, or append to p via APPE if append is true.
+func (c *conn) stor(p string, r io.Reader, append bool) (err error) {
+	command := "STOR"
+	if append {
+		command = "APPE"
+	}
+
+	dataConn, err := c.dataCmd(0, "%s %s", command, p)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dataConn, r)
+	if cerr := dataConn.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if _, _, rerr := c.text.ReadResponse(codeClosingDataConn); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// size will return the size of file p.
+func (c *conn) size(p string) (int64, error) {
+	_, msg, err := c.cmd(codeFileStatus, "SIZE %s", p)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(msg), 10, 64)
+}
+
+// rename will rename from to to via RNFR and RNTO.
+func (c *conn) rename(from, to string) (err error) {
+	if _, _, err = c.cmd(codeFileActionPending, "RNFR %s", from); err != nil {
+		return err
+	}
+	_, _, err = c.cmd(codeFileActionOK, "RNTO %s", to)
+	return err
+}
+
+func (c *conn) delete(p string) (err error) {
+	_, _, err = c.cmd(codeFileActionOK, "DELE %s", p)
+	return err
+}
+
+func (c *conn) removeDir(p string) (err error) {
+	_, _, err = c.cmd(codeFileActionOK, "RMD %s", p)
+	return err
+}
+
+func (c *conn) makeDir(p string) (err error) {
+	_, _, err = c.cmd(codePathCreated, "MKD %s", p)
+	return err
+}
+
+// quit will send QUIT and close the connection.
+func (c *conn) quit() error {
+	_, _, _ = c.cmd(-1, "QUIT")
+	return c.close()
+}
+
+func (c *conn) close() error {
+	return c.netConn.Close()
+}
+
+// cmd will send a command and read its reply, expected code < 0 means any code is acceptable.
+//
+// Args containing CR or LF will be rejected before sending, or they could inject other commands.
+func (c *conn) cmd(expected int, format string, args ...interface{}) (code int, msg string, err error) {
+	for _, v := range args {
+		if s, ok := v.(string); ok && strings.ContainsAny(s, "\r\n") {
+			return 0, "", fmt.Errorf("%w: %q contains CR or LF", types.ErrInvalidPath, s)
+		}
+	}
+	if _, err = c.text.Cmd(format, args...); err != nil {
+		return 0, "", err
+	}
+	return c.text.ReadResponse(expected)
+}
+
+// dataCmd will open a passive data connection and send the command which transfers data over it.
+//
+// REST will be sent before command if offset is not zero.
+func (c *conn) dataCmd(offset int64, format string, args ...interface{}) (net.Conn, error) {
+	dataConn, err := c.openDataConn()
+	if err != nil {
+		return nil, err
+	}
+
+	if offset != 0 {
+		if _, _, err = c.cmd(codeFileActionPending, "REST %d", offset); err != nil {
+			_ = dataConn.Close()
+			return nil, err
+		}
+	}
+
+	// Both 125 and 150 are acceptable, so only check the first digit.
+	if _, _, err = c.cmd(1, format, args...); err != nil {
+		_ = dataConn.Close()
+		return nil, err
+	}
+
+	if c.tlsConfig != nil {
+		tlsConn := tls.Client(dataConn, c.tlsConfig)
+		if err = tlsConn.Handshake(); err != nil {
+			_ = tlsConn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+	return dataConn, nil
+}
+
+// openDataConn will try EPSV first, and fallback to PASV if server doesn't support it.
+func (c *conn) openDataConn() (net.Conn, error) {
+	var port int
+	var err error
+
+	if !c.epsvDisabled {
+		port, err = c.epsv()
+		if err != nil && isNotImplemented(err) {
+			c.epsvDisabled = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if c.epsvDisabled {
+		port, err = c.pasv()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return net.DialTimeout("tcp", net.JoinHostPort(c.host, strconv.Itoa(port)), 30*time.Second)
+}
+
+// epsv will parse reply like "Entering Extended Passive Mode (|||6446|)".
+func (c *conn) epsv() (port int, err error) {
+	_, msg, err := c.cmd(codeExtendedPassive, "EPSV")
+	if err != nil {
+		return 0, err
+	}
+
+	start, end := strings.Index(msg, "(|||"), strings.LastIndex(msg, "|)")
+	if start < 0 || end <= start {
+		return 0, fmt.Errorf("invalid reply for EPSV: %s", msg)
+	}
+	return strconv.Atoi(msg[start+4 : end])
+}
+
+// pasv will parse reply like "Entering Passive Mode (h1,h2,h3,h4,p1,p2)".
+//
+// Host in reply will be ignored because it could be a private address behind NAT.
+func (c *conn) pasv() (port int, err error) {
+	_, msg, err := c.cmd(codePassiveMode, "PASV")
+	if err != nil {
+		return 0, err
+	}
+
+	start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+	if start < 0 || end <= start {
+		return 0, fmt.Errorf("invalid reply for PASV: %s", msg)
+	}
+	s := strings.Split(msg[start+1:end], ",")
+	if len(s) != 6 {
+		return 0, fmt.Errorf("invalid reply for PASV: %s", msg)
+	}
+	p1, err := strconv.Atoi(s[4])
+	if err != nil {
+		return 0, fmt.Errorf("invalid reply for PASV: %s", msg)
+	}
+	p2, err := strconv.Atoi(s[5])
+	if err != nil {
+		return 0, fmt.Errorf("invalid reply for PASV: %s", msg)
+	}
+	return p1<<8 + p2, nil
+}
+
+// response is the data connection of RETR, the final reply will be read while closing.
+type response struct {
+	conn net.Conn
+	c    *conn
+
+	eof    bool
+	closed bool
+}
+
+func (r *response) Read(p []byte) (n int, err error) {
+	n, err = r.conn.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return
+}
+
+// Close will close the data connection and read the final reply.
+//
+// Server will reply 426 or 451 if data connection is closed before EOF, which is expected.
+func (r *response) Close() (err error) {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	err = r.conn.Close()
+	_, _, rerr := r.c.text.ReadResponse(codeClosingDataConn)
+	if rerr != nil && r.eof && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+func isNotImplemented(err error) bool {
+	var e *textproto.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == codeNotImplemented || e.Code == codeNotImplementedArg || e.Code == 500
+}
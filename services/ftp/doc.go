@@ -0,0 +1,11 @@
+/*
+Package ftp provided support for FTP servers.
+
+Endpoint's protocol could be "ftp" or "ftps", and "ftps" means explicit TLS which upgrades the connection
+via AUTH TLS. Credential is optional: hmac credential will be used as user and password, and anonymous
+login will be used if credential not given.
+
+Only passive mode is supported. Objects will be listed via MLSD, and LIST will be used if server doesn't
+support it, only unix style LIST output could be parsed.
+*/
+package ftp
@@ -0,0 +1,420 @@
+package ftp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// testServer is a minimal FTP server which only supports commands used by this package.
+type testServer struct {
+	root     string
+	listener net.Listener
+
+	user     string
+	password string
+	// AUTH TLS will be rejected if tlsConfig is nil.
+	tlsConfig   *tls.Config
+	disableMLST bool
+	disableEPSV bool
+}
+
+func (ts *testServer) start() (port int, err error) {
+	ts.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+
+	go func() {
+		for {
+			nc, err := ts.listener.Accept()
+			if err != nil {
+				return
+			}
+			s := &testSession{ts: ts, cwd: "/"}
+			s.setConn(nc)
+			go s.serve()
+		}
+	}()
+	return ts.listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (ts *testServer) close() {
+	_ = ts.listener.Close()
+}
+
+type testSession struct {
+	ts *testServer
+
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+
+	loggedIn     bool
+	user         string
+	cwd          string
+	protected    bool
+	dataListener net.Listener
+	restOffset   int64
+	renameFrom   string
+}
+
+func (s *testSession) setConn(nc net.Conn) {
+	s.conn, s.r, s.w = nc, bufio.NewReader(nc), bufio.NewWriter(nc)
+}
+
+func (s *testSession) reply(code int, format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(s.w, "%d %s\r\n", code, fmt.Sprintf(format, args...))
+	_ = s.w.Flush()
+}
+
+// replyLines will send a multi-line reply, every line in lines will be prefixed with a space.
+func (s *testSession) replyLines(code int, first string, lines []string, last string) {
+	_, _ = fmt.Fprintf(s.w, "%d-%s\r\n", code, first)
+	for _, v := range lines {
+		_, _ = fmt.Fprintf(s.w, " %s\r\n", v)
+	}
+	s.reply(code, last)
+}
+
+func (s *testSession) localPath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join(s.cwd, p)
+	}
+	return filepath.Join(s.ts.root, filepath.FromSlash(path.Clean(p)))
+}
+
+func (s *testSession) serve() {
+	defer s.conn.Close()
+
+	s.reply(220, "test server ready")
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command, arg := line, ""
+		if idx := strings.Index(line, " "); idx >= 0 {
+			command, arg = line[:idx], line[idx+1:]
+		}
+		command = strings.ToUpper(command)
+
+		switch command {
+		case "USER", "PASS", "AUTH", "PBSZ", "PROT", "FEAT", "QUIT":
+		default:
+			if !s.loggedIn {
+				s.reply(530, "not logged in")
+				continue
+			}
+		}
+
+		if !s.handle(command, arg) {
+			return
+		}
+		// REST only affects the next command.
+		if command != "REST" {
+			s.restOffset = 0
+		}
+	}
+}
+
+// handle will handle a command, false will be returned if session should be closed.
+func (s *testSession) handle(command, arg string) bool {
+	switch command {
+	case "USER":
+		s.user = arg
+		s.reply(331, "need password")
+	case "PASS":
+		if s.user != s.ts.user || arg != s.ts.password {
+			s.reply(530, "login incorrect")
+			return true
+		}
+		s.loggedIn = true
+		s.reply(230, "logged in")
+	case "AUTH":
+		if s.ts.tlsConfig == nil || strings.ToUpper(arg) != "TLS" {
+			s.reply(502, "not implemented")
+			return true
+		}
+		s.reply(234, "proceed with negotiation")
+		tlsConn := tls.Server(s.conn, s.ts.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return false
+		}
+		s.setConn(tlsConn)
+	case "PBSZ":
+		s.reply(200, "PBSZ=0")
+	case "PROT":
+		s.protected = strings.ToUpper(arg) == "P"
+		s.reply(200, "protection level set")
+	case "FEAT":
+		features := []string{"PASV", "SIZE", "REST STREAM"}
+		if !s.ts.disableEPSV {
+			features = append(features, "EPSV")
+		}
+		if !s.ts.disableMLST {
+			features = append(features, "MLST type*;size*;modify*;")
+		}
+		s.replyLines(211, "Features:", features, "End")
+	case "TYPE", "NOOP":
+		s.reply(200, "ok")
+	case "PWD":
+		s.reply(257, `"%s" is current directory`, s.cwd)
+	case "EPSV":
+		if s.ts.disableEPSV {
+			s.reply(502, "not implemented")
+			return true
+		}
+		port, err := s.listenData()
+		if err != nil {
+			s.reply(425, "can't open data connection")
+			return true
+		}
+		s.reply(229, "Entering Extended Passive Mode (|||%d|)", port)
+	case "PASV":
+		port, err := s.listenData()
+		if err != nil {
+			s.reply(425, "can't open data connection")
+			return true
+		}
+		s.reply(227, "Entering Passive Mode (127,0,0,1,%d,%d)", port>>8, port&0xff)
+	case "MLSD", "LIST":
+		if command == "MLSD" && s.ts.disableMLST {
+			s.reply(502, "not implemented")
+			return true
+		}
+		fis, err := ioutil.ReadDir(s.localPath(arg))
+		if err != nil {
+			s.closeData()
+			s.reply(550, "no such file or directory")
+			return true
+		}
+		lines := make([]string, 0, len(fis)+1)
+		if command == "MLSD" {
+			lines = append(lines, "type=cdir;modify=20200101000000; .")
+			for _, fi := range fis {
+				lines = append(lines, formatMLSx(fi, fi.Name()))
+			}
+		} else {
+			lines = append(lines, "total "+strconv.Itoa(len(fis)))
+			for _, fi := range fis {
+				lines = append(lines, formatList(fi))
+			}
+		}
+		s.transfer(func(w io.Writer) error {
+			for _, v := range lines {
+				if _, err := fmt.Fprintf(w, "%s\r\n", v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case "MLST":
+		if s.ts.disableMLST {
+			s.reply(502, "not implemented")
+			return true
+		}
+		fi, err := os.Stat(s.localPath(arg))
+		if err != nil {
+			s.reply(550, "no such file or directory")
+			return true
+		}
+		s.replyLines(250, "Listing "+arg, []string{formatMLSx(fi, arg)}, "End")
+	case "SIZE":
+		fi, err := os.Stat(s.localPath(arg))
+		if err != nil || fi.IsDir() {
+			s.reply(550, "no such file")
+			return true
+		}
+		s.reply(213, "%d", fi.Size())
+	case "REST":
+		offset, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			s.reply(501, "invalid offset")
+			return true
+		}
+		s.restOffset = offset
+		s.reply(350, "restarting at %d", offset)
+	case "RETR":
+		offset := s.restOffset
+		f, err := os.Open(s.localPath(arg))
+		if err != nil {
+			s.closeData()
+			s.reply(550, "no such file")
+			return true
+		}
+		defer f.Close()
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			s.closeData()
+			s.reply(550, "seek failed")
+			return true
+		}
+		s.transfer(func(w io.Writer) error {
+			_, err := io.Copy(w, f)
+			return err
+		})
+	case "STOR", "APPE":
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if command == "APPE" {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(s.localPath(arg), flag, 0644)
+		if err != nil {
+			s.closeData()
+			s.reply(550, "can't create file")
+			return true
+		}
+		defer f.Close()
+		s.receive(f)
+	case "RNFR":
+		if _, err := os.Stat(s.localPath(arg)); err != nil {
+			s.reply(550, "no such file")
+			return true
+		}
+		s.renameFrom = arg
+		s.reply(350, "ready for RNTO")
+	case "RNTO":
+		from := s.renameFrom
+		s.renameFrom = ""
+		if from == "" {
+			s.reply(503, "RNFR required")
+			return true
+		}
+		if err := os.Rename(s.localPath(from), s.localPath(arg)); err != nil {
+			s.reply(550, "rename failed")
+			return true
+		}
+		s.reply(250, "renamed")
+	case "DELE":
+		fi, err := os.Stat(s.localPath(arg))
+		if err != nil || fi.IsDir() {
+			s.reply(550, "no such file")
+			return true
+		}
+		if err = os.Remove(s.localPath(arg)); err != nil {
+			s.reply(550, "delete failed")
+			return true
+		}
+		s.reply(250, "deleted")
+	case "RMD":
+		if err := os.Remove(s.localPath(arg)); err != nil {
+			s.reply(550, "remove failed")
+			return true
+		}
+		s.reply(250, "removed")
+	case "MKD":
+		if err := os.Mkdir(s.localPath(arg), 0755); err != nil {
+			s.reply(550, "create failed")
+			return true
+		}
+		s.reply(257, `"%s" created`, arg)
+	case "QUIT":
+		s.reply(221, "bye")
+		return false
+	default:
+		s.reply(502, "not implemented")
+	}
+	return true
+}
+
+func (s *testSession) listenData() (int, error) {
+	s.closeData()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	s.dataListener = l
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (s *testSession) closeData() {
+	if s.dataListener != nil {
+		_ = s.dataListener.Close()
+		s.dataListener = nil
+	}
+}
+
+func (s *testSession) acceptData() (net.Conn, error) {
+	if s.dataListener == nil {
+		return nil, fmt.Errorf("no data listener")
+	}
+	defer s.closeData()
+
+	_ = s.dataListener.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	nc, err := s.dataListener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !s.protected {
+		return nc, nil
+	}
+	tlsConn := tls.Server(nc, s.ts.tlsConfig)
+	if err = tlsConn.Handshake(); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// transfer will send data to client via fn.
+func (s *testSession) transfer(fn func(w io.Writer) error) {
+	s.reply(150, "opening data connection")
+	dataConn, err := s.acceptData()
+	if err != nil {
+		s.reply(425, "can't open data connection")
+		return
+	}
+	err = fn(dataConn)
+	_ = dataConn.Close()
+	if err != nil {
+		s.reply(426, "transfer aborted")
+		return
+	}
+	s.reply(226, "transfer complete")
+}
+
+// receive will receive data from client into w.
+func (s *testSession) receive(w io.Writer) {
+	s.reply(150, "opening data connection")
+	dataConn, err := s.acceptData()
+	if err != nil {
+		s.reply(425, "can't open data connection")
+		return
+	}
+	_, err = io.Copy(w, dataConn)
+	_ = dataConn.Close()
+	if err != nil {
+		s.reply(426, "transfer aborted")
+		return
+	}
+	s.reply(226, "transfer complete")
+}
+
+func formatMLSx(fi os.FileInfo, name string) string {
+	typ := "file"
+	if fi.IsDir() {
+		typ = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s; %s",
+		typ, fi.Size(), fi.ModTime().UTC().Format("20060102150405"), name)
+}
+
+func formatList(fi os.FileInfo) string {
+	mode := "-rw-r--r--"
+	if fi.IsDir() {
+		mode = "drwxr-xr-x"
+	}
+	return fmt.Sprintf("%s 1 owner group %d %s %s",
+		mode, fi.Size(), fi.ModTime().UTC().Format("Jan _2 15:04"), fi.Name())
+}
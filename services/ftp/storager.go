@@ -0,0 +1,386 @@
+package ftp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// Storage is the ftp client.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	addr     string
+	user     string
+	password string
+	// tlsConfig will be used only if endpoint's protocol is ftps.
+	tlsConfig *tls.Config
+	useTLS    bool
+
+	workDir string
+
+	// idle holds connections which could be reused, FTP connection can't run commands concurrently.
+	mu   sync.Mutex
+	idle []*conn
+}
+
+// New will create a ftp client.
+func New() *Storage {
+	return &Storage{
+		tlsConfig: &tls.Config{},
+	}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager ftp {Addr: %s, WorkDir: %s}", s.addr, s.workDir)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	ep := opt.Endpoint.Value()
+	switch ep.Protocol {
+	case endpoint.ProtocolFTP:
+	case endpoint.ProtocolFTPS:
+		s.useTLS = true
+		s.tlsConfig.ServerName = ep.Host
+		// Most servers require data connections to reuse control connection's TLS session.
+		if s.tlsConfig.ClientSessionCache == nil {
+			s.tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+		}
+	default:
+		return types.NewError(s, "Init", endpoint.ErrUnsupportedProtocol)
+	}
+	s.addr = ep.Addr()
+
+	// Login as anonymous user if credential not given.
+	s.user, s.password = "anonymous", "anonymous"
+	if opt.HasCredential {
+		credProtocol, cred := opt.Credential.Protocol(), opt.Credential.Value()
+		if credProtocol != credential.ProtocolHmac {
+			return types.NewError(s, "Init", credential.ErrUnsupportedProtocol)
+		}
+		s.user, s.password = cred[0], cred[1]
+	}
+
+	c, err := s.newConn()
+	if err != nil {
+		return types.NewError(s, "Init", handleFtpError(err))
+	}
+	defer func() {
+		s.putConn(c, err)
+	}()
+
+	// Use user's login dir as work dir if not given.
+	if opt.HasWorkDir {
+		s.workDir = opt.WorkDir
+	} else {
+		s.workDir, err = c.pwd()
+		if err != nil {
+			return types.NewError(s, "Init", handleFtpError(err))
+		}
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     "",
+		WorkDir:  s.workDir,
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	c, err := s.getConn()
+	if err != nil {
+		return types.NewError(s, "List", handleFtpError(err), path)
+	}
+	entries, err := c.list(s.getAbsPath(path))
+	s.putConn(c, err)
+	if err != nil {
+		return types.NewError(s, "List", handleFtpError(err), path)
+	}
+
+	for _, v := range entries {
+		o := formatEntry(v)
+		o.Name = storageutil.JoinPath(path, v.name)
+
+		if o.Type == types.ObjectTypeDir {
+			if opt.HasDirFunc {
+				opt.DirFunc(o)
+			}
+			continue
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return
+}
+
+// Read implements Storager.Read
+//
+// Offset will be sent via REST, and the connection will be occupied until r closed.
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	c, err := s.getConn()
+	if err != nil {
+		return nil, types.NewError(s, "Read", handleFtpError(err), path)
+	}
+	rc, err := c.retr(s.getAbsPath(path), opt.Offset)
+	if err != nil {
+		s.putConn(c, err)
+		return nil, types.NewError(s, "Read", handleFtpError(err), path)
+	}
+
+	r = &connReadCloser{ReadCloser: rc, s: s, c: c}
+	if opt.HasSize {
+		r = iowrap.LimitReadCloser(r, opt.Size)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// If offset is given, content will be appended via APPE to resume an interrupted upload, and offset must
+// equal to the current size of path.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairWrite(pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	c, err := s.getConn()
+	if err != nil {
+		return types.NewError(s, "Write", handleFtpError(err), path)
+	}
+	defer func() {
+		s.putConn(c, err)
+	}()
+
+	rp := s.getAbsPath(path)
+
+	err = s.createDir(c, rp)
+	if err != nil {
+		return types.NewError(s, "Write", handleFtpError(err), path)
+	}
+
+	isAppend := opt.HasOffset && opt.Offset > 0
+	if isAppend {
+		size, err := c.size(rp)
+		if err != nil {
+			return types.NewError(s, "Write", handleFtpError(err), path)
+		}
+		if size != opt.Offset {
+			err = fmt.Errorf("%w: offset %d mismatch with size %d", types.ErrPreconditionFailed, opt.Offset, size)
+			return types.NewError(s, "Write", err, path)
+		}
+	}
+
+	if opt.HasSize {
+		r = iowrap.SizeReader(r, opt.Size)
+	}
+	err = c.stor(rp, r, isAppend)
+	if err != nil {
+		return types.NewError(s, "Write", handleFtpError(err), path)
+	}
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	c, err := s.getConn()
+	if err != nil {
+		return nil, types.NewError(s, "Stat", handleFtpError(err), path)
+	}
+	e, err := c.stat(s.getAbsPath(path))
+	s.putConn(c, err)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", handleFtpError(err), path)
+	}
+
+	o = formatEntry(e)
+	o.Name = path
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// Empty dir could be deleted via RMD.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	c, err := s.getConn()
+	if err != nil {
+		return types.NewError(s, "Delete", handleFtpError(err), path)
+	}
+	defer func() {
+		s.putConn(c, err)
+	}()
+
+	rp := s.getAbsPath(path)
+
+	err = c.delete(rp)
+	if err != nil && isReusable(err) {
+		// Path could be a dir, try RMD but return the error of DELE if still failed.
+		rerr := c.removeDir(rp)
+		if rerr == nil {
+			err = nil
+		} else if !isReusable(rerr) {
+			err = rerr
+		}
+	}
+	if err != nil {
+		return types.NewError(s, "Delete", handleFtpError(err), path)
+	}
+	return nil
+}
+
+// Move implements Storager.Move
+//
+// Whether dst will be replaced depends on server's implementation of RNTO.
+func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
+	c, err := s.getConn()
+	if err != nil {
+		return types.NewError(s, "Move", handleFtpError(err), src, dst)
+	}
+	defer func() {
+		s.putConn(c, err)
+	}()
+
+	rd := s.getAbsPath(dst)
+
+	err = s.createDir(c, rd)
+	if err != nil {
+		return types.NewError(s, "Move", handleFtpError(err), src, dst)
+	}
+
+	err = c.rename(s.getAbsPath(src), rd)
+	if err != nil {
+		return types.NewError(s, "Move", handleFtpError(err), src, dst)
+	}
+	return nil
+}
+
+// createDir will create all parent dirs of rp which are under work dir.
+func (s *Storage) createDir(c *conn, rp string) (err error) {
+	workDir := path.Clean(s.workDir)
+	dir := path.Dir(rp)
+	// Compare with trailing slash, so that "/ab" will not be treated as inside "/a".
+	prefix := workDir
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if dir == workDir || !strings.HasPrefix(dir, prefix) {
+		return nil
+	}
+
+	// MKD on an existing dir will fail, so reply errors are ignored and the following command will
+	// report the real error.
+	p := workDir
+	for _, v := range strings.Split(strings.TrimPrefix(dir, prefix), "/") {
+		if v == "" {
+			continue
+		}
+		p = path.Join(p, v)
+
+		err = c.makeDir(p)
+		var e *textproto.Error
+		if err != nil && !errors.As(err, &e) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) newConn() (c *conn, err error) {
+	var tlsConfig *tls.Config
+	if s.useTLS {
+		tlsConfig = s.tlsConfig
+	}
+
+	c, err = dial(s.addr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	err = c.login(s.user, s.password)
+	if err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// getConn will return an idle connection or create a new one.
+func (s *Storage) getConn() (*conn, error) {
+	for {
+		s.mu.Lock()
+		if len(s.idle) == 0 {
+			s.mu.Unlock()
+			return s.newConn()
+		}
+		c := s.idle[len(s.idle)-1]
+		s.idle = s.idle[:len(s.idle)-1]
+		s.mu.Unlock()
+
+		// Idle connection could be closed by server after timeout.
+		if _, _, err := c.cmd(-1, "NOOP"); err == nil {
+			return c, nil
+		}
+		_ = c.close()
+	}
+}
+
+// putConn will put c back to idle connections if it's still usable after err, or close it.
+func (s *Storage) putConn(c *conn, err error) {
+	if !isReusable(err) {
+		_ = c.close()
+		return
+	}
+
+	s.mu.Lock()
+	s.idle = append(s.idle, c)
+	s.mu.Unlock()
+}
+
+// connReadCloser will put the connection back while closing.
+type connReadCloser struct {
+	io.ReadCloser
+
+	s *Storage
+	c *conn
+}
+
+func (r *connReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.s.putConn(r.c, err)
+	return err
+}
@@ -0,0 +1,342 @@
+package ftp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// newTestCertificate will create a self-signed certificate for 127.0.0.1.
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func newTestEndpoint(t *testing.T, protocol string, port int) endpoint.Provider {
+	ep, err := endpoint.Parse(protocol + ":127.0.0.1:" + strconv.Itoa(port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ep
+}
+
+func TestStorage(t *testing.T) {
+	cert, pool := newTestCertificate(t)
+
+	cases := []struct {
+		name   string
+		server *testServer
+	}{
+		{"mlsd and epsv", &testServer{}},
+		{"list and pasv", &testServer{disableMLST: true, disableEPSV: true}},
+		{"explicit tls", &testServer{tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}}}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "ftp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			ts := tt.server
+			ts.root, ts.user, ts.password = dir, "test_user", "test_password"
+			port, err := ts.start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ts.close()
+
+			protocol := endpoint.ProtocolFTP
+			if ts.tlsConfig != nil {
+				protocol = endpoint.ProtocolFTPS
+			}
+			store := New()
+			store.tlsConfig.RootCAs = pool
+			err = store.Init(
+				pairs.WithEndpoint(newTestEndpoint(t, protocol, port)),
+				pairs.WithCredential(credential.MustNewHmac("test_user", "test_password")),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testStorage(t, store, dir)
+		})
+	}
+}
+
+func testStorage(t *testing.T, store *Storage, dir string) {
+	content := []byte("0123456789")
+
+	m, err := store.Metadata()
+	assert.NoError(t, err)
+	assert.Equal(t, "/", m.WorkDir)
+
+	t.Run("write", func(t *testing.T) {
+		err := store.Write("a/b/test file", bytes.NewReader(content), pairs.WithSize(int64(len(content))))
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "a", "b", "test file"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("path with crlf", func(t *testing.T) {
+		_, err := store.Stat("x\r\nDELE a/b/test file")
+		assert.True(t, errors.Is(err, types.ErrInvalidPath))
+		err = store.Delete("x\nRMD a")
+		assert.True(t, errors.Is(err, types.ErrInvalidPath))
+
+		_, err = os.Stat(filepath.Join(dir, "a", "b", "test file"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("write with offset", func(t *testing.T) {
+		err := store.Write("resume", bytes.NewReader(content[:4]))
+		assert.NoError(t, err)
+
+		err = store.Write("resume", bytes.NewReader(content[4:]), pairs.WithOffset(4))
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "resume"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+
+		err = store.Write("resume", bytes.NewReader(content), pairs.WithOffset(4))
+		assert.True(t, errors.Is(err, types.ErrPreconditionFailed))
+	})
+
+	t.Run("write with short input", func(t *testing.T) {
+		err := store.Write("short_input", bytes.NewReader(content), pairs.WithSize(20))
+		assert.True(t, errors.Is(err, iowrap.ErrShortInput))
+
+		// Partial content could be left as fs does.
+		_ = store.Delete("short_input")
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		o, err := store.Stat("a/b/test file")
+		assert.NoError(t, err)
+		assert.Equal(t, "a/b/test file", o.Name)
+		assert.Equal(t, types.ObjectTypeFile, o.Type)
+		assert.Equal(t, int64(len(content)), o.Size)
+		assert.False(t, o.UpdatedAt.IsZero())
+
+		o, err = store.Stat("a")
+		assert.NoError(t, err)
+		assert.Equal(t, types.ObjectTypeDir, o.Type)
+
+		_, err = store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			pairs  []*types.Pair
+			expect string
+		}{
+			{"full", nil, "0123456789"},
+			{"offset", []*types.Pair{pairs.WithOffset(7)}, "789"},
+			{"size", []*types.Pair{pairs.WithSize(3)}, "012"},
+			{"offset and size", []*types.Pair{pairs.WithOffset(2), pairs.WithSize(3)}, "234"},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				r, err := store.Read("a/b/test file", tt.pairs...)
+				if !assert.NoError(t, err) {
+					return
+				}
+				b, err := ioutil.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, string(b))
+				assert.NoError(t, r.Close())
+			})
+		}
+
+		_, err := store.Read("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("concurrent read", func(t *testing.T) {
+		r1, err := store.Read("a/b/test file")
+		assert.NoError(t, err)
+		r2, err := store.Read("resume")
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadAll(r2)
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+		b, err = ioutil.ReadAll(r1)
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+
+		assert.NoError(t, r1.Close())
+		assert.NoError(t, r2.Close())
+	})
+
+	t.Run("move", func(t *testing.T) {
+		err := store.Move("resume", "c/moved")
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, "resume"))
+		assert.True(t, os.IsNotExist(err))
+		b, err := ioutil.ReadFile(filepath.Join(dir, "c", "moved"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+
+		err = store.Move("not_exist", "moved")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := make([]string, 0), make([]string, 0)
+		err := store.List("",
+			pairs.WithFileFunc(func(o *types.Object) {
+				files = append(files, o.Name)
+			}),
+			pairs.WithDirFunc(func(o *types.Object) {
+				dirs = append(dirs, o.Name)
+			}),
+		)
+		assert.NoError(t, err)
+		sort.Strings(dirs)
+		assert.Equal(t, []string{}, files)
+		assert.Equal(t, []string{"a", "c"}, dirs)
+
+		err = store.List("a/b", pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+			assert.Equal(t, int64(len(content)), o.Size)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a/b/test file"}, files)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := store.Delete("c/moved")
+		assert.NoError(t, err)
+
+		err = store.Delete("c/moved")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+		// Empty dir could be deleted too.
+		err = store.Delete("c")
+		assert.NoError(t, err)
+	})
+}
+
+func TestStorage_Init(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := &testServer{root: dir, user: "test_user", password: "test_password"}
+	port, err := ts.start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.close()
+
+	t.Run("invalid password", func(t *testing.T) {
+		store := New()
+		err := store.Init(
+			pairs.WithEndpoint(newTestEndpoint(t, endpoint.ProtocolFTP, port)),
+			pairs.WithCredential(credential.MustNewHmac("test_user", "invalid_password")),
+		)
+		assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+	})
+
+	t.Run("tls not supported", func(t *testing.T) {
+		store := New()
+		err := store.Init(
+			pairs.WithEndpoint(newTestEndpoint(t, endpoint.ProtocolFTPS, port)),
+			pairs.WithCredential(credential.MustNewHmac("test_user", "test_password")),
+		)
+		assert.True(t, errors.Is(err, types.ErrNotSupported))
+	})
+
+	t.Run("unsupported protocol", func(t *testing.T) {
+		store := New()
+		err := store.Init(pairs.WithEndpoint(endpoint.NewHTTP("127.0.0.1", port)))
+		assert.True(t, errors.Is(err, endpoint.ErrUnsupportedProtocol))
+	})
+
+	t.Run("work dir", func(t *testing.T) {
+		store := New()
+		err := store.Init(
+			pairs.WithEndpoint(newTestEndpoint(t, endpoint.ProtocolFTP, port)),
+			pairs.WithCredential(credential.MustNewHmac("test_user", "test_password")),
+			pairs.WithWorkDir("/work"),
+		)
+		assert.NoError(t, err)
+
+		err = store.Write("test_file", bytes.NewReader([]byte("content")))
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+		err = os.Mkdir(filepath.Join(dir, "work"), 0755)
+		assert.NoError(t, err)
+		err = store.Write("test_file", bytes.NewReader([]byte("content")))
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(dir, "work", "test_file"))
+		assert.NoError(t, err)
+
+		// Dir outside work dir which shares the same prefix will not be created under work dir.
+		err = os.Mkdir(filepath.Join(dir, "workspace"), 0755)
+		assert.NoError(t, err)
+		err = store.Write("../workspace/a/test_file", bytes.NewReader([]byte("content")))
+		assert.Error(t, err)
+		_, err = os.Stat(filepath.Join(dir, "work", "space"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
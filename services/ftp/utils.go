@@ -0,0 +1,196 @@
+package ftp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+func (s *Storage) getAbsPath(p string) string {
+	return path.Join(s.workDir, p)
+}
+
+// splitPath will split p into dir and name.
+func splitPath(p string) (dir, name string) {
+	dir, name = path.Split(path.Clean(p))
+	if dir == "" {
+		dir = "."
+	}
+	return dir, name
+}
+
+// parseMLSxLine will parse a line returned by MLSD or MLST, see RFC 3659.
+//
+// Line is formatted as "type=file;size=10;modify=20200101120000; name".
+func parseMLSxLine(line string) (e *entry, ok bool) {
+	idx := strings.Index(line, " ")
+	if idx < 0 {
+		return nil, false
+	}
+	facts, name := line[:idx], line[idx+1:]
+
+	e = &entry{name: path.Base(name)}
+	for _, v := range strings.Split(facts, ";") {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.ToLower(kv[0]), kv[1]
+
+		switch key {
+		case "type":
+			switch strings.ToLower(value) {
+			case "cdir", "pdir":
+				// Current and parent dir should be skipped.
+				return nil, false
+			case "dir":
+				e.isDir = true
+			}
+		case "size":
+			e.size, _ = strconv.ParseInt(value, 10, 64)
+		case "modify":
+			// Modify time could contain optional fractions like "20200101120000.123".
+			if idx := strings.Index(value, "."); idx >= 0 {
+				value = value[:idx]
+			}
+			e.modTime, _ = time.Parse("20060102150405", value)
+		}
+	}
+	return e, true
+}
+
+// parseListLine will parse a unix style line returned by LIST.
+//
+// Line is formatted as "-rw-r--r-- 1 owner group 10 Jan 01 12:00 name", and modify time could be
+// "Jan 01 2020" if it's not in current year.
+func parseListLine(line string) (e *entry, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 9 || len(fields[0]) != 10 {
+		return nil, false
+	}
+
+	e = &entry{}
+	switch fields[0][0] {
+	case '-', 'l':
+	case 'd':
+		e.isDir = true
+	default:
+		return nil, false
+	}
+
+	var err error
+	e.size, err = strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	e.modTime = parseListTime(fields[5], fields[6], fields[7])
+
+	// Name could contain spaces, so we need to find it in the original line.
+	idx := 0
+	for i := 0; i < 8; i++ {
+		idx += strings.Index(line[idx:], fields[i]) + len(fields[i])
+	}
+	e.name = strings.TrimLeft(line[idx:], " ")
+	// Link will be formatted as "name -> target".
+	if fields[0][0] == 'l' {
+		if idx := strings.Index(e.name, " -> "); idx >= 0 {
+			e.name = e.name[:idx]
+		}
+	}
+	if e.name == "." || e.name == ".." {
+		return nil, false
+	}
+	return e, true
+}
+
+// parseListTime will parse time in LIST which has no year if it's in last six months.
+func parseListTime(month, day, yearOrTime string) time.Time {
+	if strings.Contains(yearOrTime, ":") {
+		now := time.Now().UTC()
+		t, err := time.Parse("Jan 2 15:04 2006", fmt.Sprintf("%s %s %s %d", month, day, yearOrTime, now.Year()))
+		if err != nil {
+			return time.Time{}
+		}
+		// Time in the future belongs to last year.
+		if t.After(now.AddDate(0, 0, 1)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t
+	}
+
+	t, err := time.Parse("Jan 2 2006", fmt.Sprintf("%s %s %s", month, day, yearOrTime))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func formatEntry(e *entry) *types.Object {
+	o := &types.Object{
+		Size:      e.size,
+		UpdatedAt: e.modTime,
+		Metadata:  make(metadata.Metadata),
+	}
+	if e.isDir {
+		o.Type = types.ObjectTypeDir
+	} else {
+		o.Type = types.ObjectTypeFile
+	}
+	return o
+}
+
+func handleFtpError(err error) error {
+	if err == nil {
+		panic("error must not be nil")
+	}
+	// Invalid path is rejected before sending, and short input is caller's fault.
+	if errors.Is(err, types.ErrInvalidPath) || errors.Is(err, iowrap.ErrShortInput) {
+		return err
+	}
+
+	var e *textproto.Error
+	if errors.As(err, &e) {
+		switch e.Code {
+		case codeFileUnavailable:
+			return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+		case codeNotLoggedIn, codeNeedAccount:
+			return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+		case codeExceededStorage:
+			return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+		case codeBadFileName:
+			return fmt.Errorf("%w: %v", types.ErrInvalidPath, err)
+		case codeServiceUnavailable, codeCannotOpenDataConn, codeTransferAborted:
+			return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+		case codeNotImplemented, codeNotImplementedArg:
+			return fmt.Errorf("%w: %v", types.ErrNotSupported, err)
+		}
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+	return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+}
+
+// isReusable will check whether conn is still usable after err returned.
+//
+// Only the error replied by server or the invalid path rejected before sending means the control connection is
+// still in a good state.
+func isReusable(err error) bool {
+	if err == nil || errors.Is(err, types.ErrInvalidPath) {
+		return true
+	}
+	var e *textproto.Error
+	return errors.As(err, &e) && e.Code != codeServiceUnavailable
+}
@@ -0,0 +1,90 @@
+package ftp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMLSxLine(t *testing.T) {
+	cases := []struct {
+		name   string
+		line   string
+		expect *entry
+	}{
+		{
+			"file",
+			"type=file;size=10;modify=20200102030405; test file",
+			&entry{name: "test file", size: 10, modTime: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+		{
+			"dir with fraction",
+			"Type=dir;Modify=20200102030405.123;UNIX.mode=0755; dir",
+			&entry{name: "dir", isDir: true, modTime: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+		{
+			"absolute path from MLST",
+			"type=file;size=1; /a/b/c",
+			&entry{name: "c", size: 1},
+		},
+		{"current dir", "type=cdir; .", nil},
+		{"parent dir", "type=pdir; ..", nil},
+		{"invalid", "invalid", nil},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := parseMLSxLine(tt.line)
+			assert.Equal(t, tt.expect != nil, ok)
+			assert.Equal(t, tt.expect, e)
+		})
+	}
+}
+
+func TestParseListLine(t *testing.T) {
+	cases := []struct {
+		name   string
+		line   string
+		expect *entry
+	}{
+		{
+			"file with year",
+			"-rw-r--r--   1 owner group      1024 Jan  2  2019 test file",
+			&entry{name: "test file", size: 1024, modTime: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			"dir",
+			"drwxr-xr-x 2 owner group 4096 Feb 10 2019 dir",
+			&entry{name: "dir", isDir: true, size: 4096, modTime: time.Date(2019, 2, 10, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			"link",
+			"lrwxrwxrwx 1 owner group 6 Feb 10 2019 link -> target",
+			&entry{name: "link", size: 6, modTime: time.Date(2019, 2, 10, 0, 0, 0, 0, time.UTC)},
+		},
+		{"total", "total 10", nil},
+		{"current dir", "drwxr-xr-x 2 owner group 4096 Feb 10 2019 .", nil},
+		{"invalid size", "-rw-r--r-- 1 owner group size Feb 10 2019 file", nil},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := parseListLine(tt.line)
+			assert.Equal(t, tt.expect != nil, ok)
+			assert.Equal(t, tt.expect, e)
+		})
+	}
+}
+
+func TestParseListTime(t *testing.T) {
+	now := time.Now().UTC()
+
+	got := parseListTime(now.Format("Jan"), now.Format("2"), now.Format("15:04"))
+	assert.Equal(t, now.Truncate(time.Minute), got)
+
+	// Time in the future should belong to last year.
+	future := now.AddDate(0, 0, 7)
+	got = parseListTime(future.Format("Jan"), future.Format("2"), future.Format("15:04"))
+	assert.True(t, got.Before(now))
+}
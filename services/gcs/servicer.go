@@ -3,8 +3,10 @@ package gcs
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 
 	gs "cloud.google.com/go/storage"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
@@ -17,17 +19,19 @@ import (
 type Service struct {
 	service   *gs.Client
 	projectID string
+
+	// googleAccessID and privateKey come from service account, which are required to sign urls.
+	googleAccessID string
+	privateKey     []byte
 }
 
 // New will create a new aliyun oss service.
 func New(pairs ...*types.Pair) (s *Service, err error) {
-	const errorMessage = "%s New: %w"
-
 	s = &Service{}
 
 	opt, err := parseServicePairNew(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	ctx := context.Background()
@@ -40,14 +44,24 @@ func New(pairs ...*types.Pair) (s *Service, err error) {
 		options = append(options, option.WithAPIKey(cred[0]))
 	case credential.ProtocolFile:
 		options = append(options, option.WithCredentialsFile(cred[0]))
+
+		content, err := ioutil.ReadFile(cred[0])
+		if err != nil {
+			return nil, types.NewError(s, "New", err)
+		}
+		// Only service account could be used to sign urls, ignore the error for other credentials.
+		if cfg, err := google.JWTConfigFromJSON(content); err == nil {
+			s.googleAccessID = cfg.Email
+			s.privateKey = cfg.PrivateKey
+		}
 	default:
-		return nil, fmt.Errorf(errorMessage, s, credential.ErrUnsupportedProtocol)
+		return nil, types.NewError(s, "New", credential.ErrUnsupportedProtocol)
 	}
 
 	client, err := gs.NewClient(ctx, options...)
 
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	s.service = client
@@ -62,11 +76,9 @@ func (s *Service) String() string {
 
 // List implements Servicer.List
 func (s *Service) List(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List: %w"
-
 	opt, err := parseServicePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "List", err)
 	}
 
 	it := s.service.Buckets(context.TODO(), s.projectID)
@@ -77,10 +89,11 @@ func (s *Service) List(pairs ...*types.Pair) (err error) {
 			return nil
 		}
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, err)
+			err = handleGcsError(err)
+			return types.NewError(s, "List", err)
 		}
 		bucket := s.service.Bucket(bucketAttr.Name)
-		c := newStorage(bucket, bucketAttr.Name)
+		c := newStorage(bucket, bucketAttr.Name, s.googleAccessID, s.privateKey)
 		opt.StoragerFunc(c)
 	}
 }
@@ -90,33 +103,31 @@ func (s *Service) Get(name string, pairs ...*types.Pair) (storage.Storager, erro
 	const _ = "%s Get [%s]: %w"
 
 	bucket := s.service.Bucket(name)
-	c := newStorage(bucket, name)
+	c := newStorage(bucket, name, s.googleAccessID, s.privateKey)
 	return c, nil
 }
 
 // Create implements Servicer.Create
 func (s *Service) Create(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s Create [%s]: %w"
-
 	bucket := s.service.Bucket(name)
 
 	err := bucket.Create(context.TODO(), s.projectID, nil)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		err = handleGcsError(err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
-	c := newStorage(bucket, name)
+	c := newStorage(bucket, name, s.googleAccessID, s.privateKey)
 	return c, nil
 }
 
 // Delete implements Servicer.Delete
 func (s *Service) Delete(name string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
-
 	bucket := s.service.Bucket(name)
 
 	err = bucket.Delete(context.TODO())
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, name, err)
+		err = handleGcsError(err)
+		return types.NewError(s, "Delete", err, name)
 	}
 	return nil
 }
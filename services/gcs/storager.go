@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	gs "cloud.google.com/go/storage"
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/iowrap"
 	"github.com/Xuanwo/storage/types"
 	"github.com/Xuanwo/storage/types/metadata"
 	"google.golang.org/api/iterator"
@@ -20,19 +24,24 @@ type Storage struct {
 
 	name    string
 	workDir string
+
+	googleAccessID string
+	privateKey     []byte
 }
 
 // newStorage will create a new client.
-func newStorage(bucket *gs.BucketHandle, name string) *Storage {
+func newStorage(bucket *gs.BucketHandle, name, googleAccessID string, privateKey []byte) *Storage {
 	c := &Storage{
-		bucket: bucket,
-		name:   name,
+		bucket:         bucket,
+		name:           name,
+		googleAccessID: googleAccessID,
+		privateKey:     privateKey,
 	}
 	return c
 }
 
 // String implements Storager.String
-func (s Storage) String() string {
+func (s *Storage) String() string {
 	return fmt.Sprintf(
 		"Storager gcs {Name: %s, WorkDir: %s}",
 		s.name, "/"+s.workDir,
@@ -40,12 +49,10 @@ func (s Storage) String() string {
 }
 
 // Init implements Storager.Init
-func (s Storage) Init(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Init: %w"
-
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
 	opt, err := parseStoragePairInit(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "Init", err)
 	}
 
 	if opt.HasWorkDir {
@@ -57,7 +64,7 @@ func (s Storage) Init(pairs ...*types.Pair) (err error) {
 }
 
 // Metadata implements Storager.Metadata
-func (s Storage) Metadata() (m metadata.Storage, err error) {
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
 	m = metadata.Storage{
 		Name:     s.name,
 		WorkDir:  s.workDir,
@@ -67,31 +74,30 @@ func (s Storage) Metadata() (m metadata.Storage, err error) {
 }
 
 // List implements Storager.List
-func (s Storage) List(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List [%s]: %w"
-
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 	opt, err := parseStoragePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "List", err, path)
 	}
 
 	rp := s.getAbsPath(path)
 
+	it := s.bucket.Objects(context.TODO(), &gs.Query{
+		Prefix: rp,
+	})
 	for {
-		it := s.bucket.Objects(context.TODO(), &gs.Query{
-			Prefix: rp,
-		})
 		object, err := it.Next()
 		if err != nil && err == iterator.Done {
 			return nil
 		}
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, path, err)
+			err = handleGcsError(err)
+			return types.NewError(s, "List", err, path)
 		}
 
 		o := &types.Object{
 			Name:      s.getRelPath(object.Name),
-			Type:      types.ObjectTypeDir,
+			Type:      types.ObjectTypeFile,
 			Size:      object.Size,
 			UpdatedAt: object.Updated,
 			Metadata:  make(metadata.Metadata),
@@ -99,37 +105,86 @@ func (s Storage) List(path string, pairs ...*types.Pair) (err error) {
 		o.SetType(object.ContentType)
 		o.SetClass(object.StorageClass)
 		o.SetChecksum(string(object.MD5))
+		if len(object.Metadata) > 0 {
+			o.SetUserMetadata(object.Metadata)
+		}
 
 		opt.FileFunc(o)
 	}
 }
 
 // Read implements Storager.Read
-func (s Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
-	const errorMessage = "%s Read [%s]: %w"
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
 
 	rp := s.getAbsPath(path)
 
 	object := s.bucket.Object(rp)
-	r, err = object.NewReader(context.TODO())
+	if opt.HasVersionId {
+		gen, err := parseGeneration(opt.VersionId)
+		if err != nil {
+			return nil, types.NewError(s, "Read", err, path)
+		}
+		object = object.Generation(gen)
+	}
+	// gcs doesn't support time based preconditions, so we need to check them by ourselves
+	// and pin the generation to make sure we read the object we have checked.
+	if opt.HasIfModifiedSince || opt.HasIfUnmodifiedSince {
+		attr, err := object.Attrs(context.TODO())
+		if err != nil {
+			err = handleGcsError(err)
+			return nil, types.NewError(s, "Read", err, path)
+		}
+		if opt.HasIfModifiedSince && !isModifiedSince(attr.Updated, opt.IfModifiedSince) {
+			return nil, types.NewError(s, "Read", types.ErrPreconditionFailed, path)
+		}
+		if opt.HasIfUnmodifiedSince && isModifiedSince(attr.Updated, opt.IfUnmodifiedSince) {
+			return nil, types.NewError(s, "Read", types.ErrPreconditionFailed, path)
+		}
+		object = object.Generation(attr.Generation)
+	}
+
+	length := int64(-1)
+	if opt.HasSize {
+		length = opt.Size
+	}
+	r, err = object.NewRangeReader(context.TODO(), opt.Offset, length)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		err = handleGcsError(err)
+		return nil, types.NewError(s, "Read", err, path)
 	}
 	return
 }
 
 // Write implements Storager.Write
-func (s Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Write [%s]: %w"
-
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
 	opt, err := parseStoragePairWrite(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
+	}
+
+	if opt.HasDetectContentType && opt.DetectContentType && !opt.HasContentType {
+		opt.ContentType, r, err = iowrap.SniffContentType(r)
+		if err != nil {
+			return types.NewError(s, "Write", err, path)
+		}
+		opt.HasContentType = true
 	}
 
 	rp := s.getAbsPath(path)
 
 	object := s.bucket.Object(rp)
+	// gcs doesn't have etag based preconditions, only create-only "*" is supported.
+	if opt.HasIfNoneMatch {
+		if opt.IfNoneMatch != "*" {
+			err = fmt.Errorf("%w: if_none_match %s", types.ErrNotSupported, opt.IfNoneMatch)
+			return types.NewError(s, "Write", err, path)
+		}
+		object = object.If(gs.Conditions{DoesNotExist: true})
+	}
 	w := object.NewWriter(context.TODO())
 	if opt.HasChecksum {
 		w.MD5 = []byte(opt.Checksum)
@@ -140,23 +195,114 @@ func (s Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err erro
 	if opt.HasStorageClass {
 		w.StorageClass = opt.StorageClass
 	}
+	if opt.HasUserMetadata {
+		w.Metadata = opt.UserMetadata
+	}
+	if opt.HasContentType {
+		w.ContentType = opt.ContentType
+	}
+	if opt.HasContentEncoding {
+		w.ContentEncoding = opt.ContentEncoding
+	}
+	if opt.HasContentDisposition {
+		w.ContentDisposition = opt.ContentDisposition
+	}
+	if opt.HasCacheControl {
+		w.CacheControl = opt.CacheControl
+	}
 
 	_, err = io.Copy(w, r)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		// Close writer to release resources, the error of upload has been returned.
+		_ = w.Close()
+		err = handleGcsError(err)
+		return types.NewError(s, "Write", err, path)
+	}
+	// Object will be committed only after writer closed.
+	err = w.Close()
+	if err != nil {
+		err = handleGcsError(err)
+		return types.NewError(s, "Write", err, path)
 	}
 	return nil
 }
 
+// Reach implements Storager.Reach
+func (s *Storage) Reach(path string, pairs ...*types.Pair) (url string, err error) {
+	opt, err := parseStoragePairReach(pairs...)
+	if err != nil {
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	if s.googleAccessID == "" || len(s.privateKey) == 0 {
+		err = fmt.Errorf("sign url without service account: %w", credential.ErrUnsupportedProtocol)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	method := http.MethodGet
+	if opt.HasMethod {
+		method = opt.Method
+	}
+
+	options := &gs.SignedURLOptions{
+		GoogleAccessID: s.googleAccessID,
+		PrivateKey:     s.privateKey,
+		Method:         method,
+		Expires:        time.Now().Add(time.Duration(opt.Expire) * time.Second),
+		Scheme:         gs.SigningSchemeV4,
+	}
+	switch method {
+	case http.MethodGet:
+	case http.MethodPut:
+		// Content type and length will be signed, client must send the same headers.
+		if opt.HasContentType {
+			options.ContentType = opt.ContentType
+		}
+		if opt.HasSize {
+			options.Headers = append(options.Headers, fmt.Sprintf("content-length:%d", opt.Size))
+		}
+	default:
+		err = fmt.Errorf("method %s: %w", method, types.ErrNotSupported)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	url, err = gs.SignedURL(s.name, rp, options)
+	if err != nil {
+		return "", types.NewError(s, "Reach", err, path)
+	}
+	return url, nil
+}
+
 // Stat implements Storager.Stat
-func (s Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
-	const errorMessage = "%s Stat [%s]: %w"
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	opt, err := parseStoragePairStat(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
 
 	rp := s.getAbsPath(path)
 
-	attr, err := s.bucket.Object(rp).Attrs(context.TODO())
+	object := s.bucket.Object(rp)
+	if opt.HasVersionId {
+		gen, err := parseGeneration(opt.VersionId)
+		if err != nil {
+			return nil, types.NewError(s, "Stat", err, path)
+		}
+		object = object.Generation(gen)
+	}
+
+	attr, err := object.Attrs(context.TODO())
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		err = handleGcsError(err)
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	if opt.HasIfModifiedSince && !isModifiedSince(attr.Updated, opt.IfModifiedSince) {
+		return nil, types.NewError(s, "Stat", types.ErrPreconditionFailed, path)
+	}
+	if opt.HasIfUnmodifiedSince && isModifiedSince(attr.Updated, opt.IfUnmodifiedSince) {
+		return nil, types.NewError(s, "Stat", types.ErrPreconditionFailed, path)
 	}
 
 	o = &types.Object{
@@ -166,18 +312,130 @@ func (s Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err e
 		UpdatedAt: attr.Updated,
 		Metadata:  make(metadata.Metadata),
 	}
+
+	if attr.ContentType != "" {
+		o.SetType(attr.ContentType)
+	}
+	if attr.StorageClass != "" {
+		o.SetClass(attr.StorageClass)
+	}
+	if len(attr.MD5) > 0 {
+		o.SetChecksum(string(attr.MD5))
+	}
+	if len(attr.Metadata) > 0 {
+		o.SetUserMetadata(attr.Metadata)
+	}
+	if attr.ContentEncoding != "" {
+		o.SetContentEncoding(attr.ContentEncoding)
+	}
+	if attr.ContentDisposition != "" {
+		o.SetContentDisposition(attr.ContentDisposition)
+	}
+	if attr.CacheControl != "" {
+		o.SetCacheControl(attr.CacheControl)
+	}
+	o.SetVersionId(formatGeneration(attr.Generation))
 	return o, nil
 }
 
 // Delete implements Storager.Delete
-func (s Storage) Delete(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
+//
+// The live version will become noncurrent if versioning is enabled, and the version will be deleted
+// permanently if version_id is given.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairDelete(pairs...)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
 
 	rp := s.getAbsPath(path)
 
-	err = s.bucket.Object(rp).Delete(context.TODO())
+	object := s.bucket.Object(rp)
+	if opt.HasVersionId {
+		gen, err := parseGeneration(opt.VersionId)
+		if err != nil {
+			return types.NewError(s, "Delete", err, path)
+		}
+		object = object.Generation(gen)
+	}
+
+	err = object.Delete(context.TODO())
+	if err != nil {
+		err = handleGcsError(err)
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// ListVersions implements Storager.ListVersions
+//
+// Generations will be used as version ids.
+func (s *Storage) ListVersions(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairListVersions(pairs...)
+	if err != nil {
+		return types.NewError(s, "ListVersions", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	objects := make([]*types.Object, 0)
+	it := s.bucket.Objects(context.TODO(), &gs.Query{
+		Prefix:   rp,
+		Versions: true,
+	})
+	for {
+		object, err := it.Next()
+		if err != nil && err == iterator.Done {
+			break
+		}
+		if err != nil {
+			err = handleGcsError(err)
+			return types.NewError(s, "ListVersions", err, path)
+		}
+		// Objects which have path as prefix will also be listed.
+		if object.Name != rp {
+			continue
+		}
+
+		o := &types.Object{
+			Name:      path,
+			Type:      types.ObjectTypeFile,
+			Size:      object.Size,
+			UpdatedAt: object.Updated,
+			Metadata:  make(metadata.Metadata),
+		}
+		o.SetVersionId(formatGeneration(object.Generation))
+		o.SetType(object.ContentType)
+		o.SetClass(object.StorageClass)
+		o.SetChecksum(string(object.MD5))
+		objects = append(objects, o)
+	}
+
+	// gcs lists generations from oldest to newest.
+	if opt.HasFileFunc {
+		for i := len(objects) - 1; i >= 0; i-- {
+			opt.FileFunc(objects[i])
+		}
+	}
+	return nil
+}
+
+// Restore implements Storager.Restore
+//
+// The generation will be copied into a new generation, so that the live version is kept.
+func (s *Storage) Restore(path, version string, pairs ...*types.Pair) (err error) {
+	gen, err := parseGeneration(version)
+	if err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	object := s.bucket.Object(rp)
+	_, err = object.CopierFrom(object.Generation(gen)).Run(context.TODO())
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		err = handleGcsError(err)
+		return types.NewError(s, "Restore", err, path)
 	}
 	return nil
 }
@@ -1,7 +1,17 @@
 package gcs
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
+
+	gs "cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+
+	"github.com/Xuanwo/storage/types"
 )
 
 func (s *Storage) getAbsPath(path string) string {
@@ -11,3 +21,55 @@ func (s *Storage) getAbsPath(path string) string {
 func (s *Storage) getRelPath(path string) string {
 	return strings.TrimPrefix(path, s.workDir+"/")
 }
+
+// isModifiedSince will check whether t is after since in http date's second precision.
+func isModifiedSince(t, since time.Time) bool {
+	return t.Truncate(time.Second).After(since.Truncate(time.Second))
+}
+
+func handleGcsError(err error) error {
+	if err == nil {
+		panic("error must not be nil")
+	}
+
+	if errors.Is(err, gs.ErrObjectNotExist) || errors.Is(err, gs.ErrBucketNotExist) {
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	}
+
+	var e *googleapi.Error
+	if !errors.As(err, &e) {
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+
+	switch e.Code {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %v", types.ErrConfigIncorrect, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case http.StatusServiceUnavailable, http.StatusInternalServerError:
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	default:
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+}
+
+// parseGeneration will parse version id into gcs generation, malformed version id is treated as an invalid path.
+func parseGeneration(version string) (int64, error) {
+	gen, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid version %s", types.ErrInvalidPath, version)
+	}
+	return gen, nil
+}
+
+func formatGeneration(gen int64) string {
+	return strconv.FormatInt(gen, 10)
+}
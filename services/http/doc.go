@@ -0,0 +1,18 @@
+/*
+Package http provided read-only support for files published via plain HTTP(S) servers.
+
+Read will be sent as GET with Range header, and Stat will be sent as HEAD. List is optional and depends on
+server: the dir's index page will be fetched, which could be an autoindex HTML page, or a JSON manifest in the
+format of nginx's `autoindex_format json`:
+
+	[
+	  {"name": "dir", "type": "directory", "mtime": "Wed, 01 Jan 2020 00:00:00 GMT"},
+	  {"name": "file", "type": "file", "mtime": "Wed, 01 Jan 2020 00:00:00 GMT", "size": 1024}
+	]
+
+Write and Delete will always return types.ErrReadOnly.
+
+Credential is optional: hmac credential will be sent via basic auth, and apikey credential will be sent as
+bearer token.
+*/
+package http
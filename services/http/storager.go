@@ -0,0 +1,181 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	nethttp "net/http"
+	"net/url"
+	"path"
+
+	"github.com/Xuanwo/storage/pkg/httputil"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// Storage is the read-only http client.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	client *httputil.Client
+}
+
+// New will create a http client.
+func New() *Storage {
+	return &Storage{
+		client: httputil.NewClient(),
+	}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager http {Endpoint: %s, WorkDir: %s}", s.client.Endpoint, s.client.WorkDir)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.client.Endpoint, err = url.Parse(opt.Endpoint.Value().String())
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.client.WorkDir = "/"
+	if opt.HasWorkDir {
+		s.client.WorkDir = path.Join("/", opt.WorkDir)
+	}
+
+	if opt.HasCredential {
+		s.client.Authorization, err = httputil.Authorization(opt.Credential)
+		if err != nil {
+			return types.NewError(s, "Init", err)
+		}
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     "",
+		WorkDir:  s.client.WorkDir,
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+//
+// List depends on the dir's index page, types.ErrNotSupported will be returned if it's neither a HTML page
+// nor a JSON manifest.
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	req, err := s.client.NewRequest(nethttp.MethodGet, s.client.DirURL(path), nil)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	req.Header.Set("Accept", "application/json, text/html;q=0.9")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	if err = httputil.CheckResponse(resp, nethttp.StatusOK); err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	defer resp.Body.Close()
+
+	var entries []*entry
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		entries, err = parseJSONIndex(resp.Body)
+	case "text/html":
+		// Links in index page should be resolved against the final url after redirects.
+		entries, err = parseHTMLIndex(resp.Body, resp.Request.URL)
+	default:
+		err = fmt.Errorf("index page with content type [%s]: %w", mediaType, types.ErrNotSupported)
+	}
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	for _, v := range entries {
+		o := v.format()
+		o.Name = storageutil.JoinPath(path, v.Name)
+
+		if o.Type == types.ObjectTypeDir {
+			if opt.HasDirFunc {
+				opt.DirFunc(o)
+			}
+			continue
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	r, err = s.client.Read(s.client.FileURL(path), opt.Offset, opt.Size)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// http storager is read-only, types.ErrReadOnly will always be returned.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	return types.NewError(s, "Write", types.ErrReadOnly, path)
+}
+
+// Stat implements Storager.Stat
+//
+// Object will be treated as a dir if server redirects it to a path with trailing "/".
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	req, err := s.client.NewRequest(nethttp.MethodHead, s.client.FileURL(path), nil)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	if err = httputil.CheckResponse(resp, nethttp.StatusOK); err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	_ = resp.Body.Close()
+
+	o, err = formatResponse(resp)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	o.Name = path
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// http storager is read-only, types.ErrReadOnly will always be returned.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	return types.NewError(s, "Delete", types.ErrReadOnly, path)
+}
@@ -0,0 +1,271 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+var content = []byte("0123456789")
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(dir, "work", "a", "b"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "work", "a", "b", "test file"), content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "work", "c"), content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// jsonIndex will serve dir's index as nginx's JSON autoindex.
+func jsonIndex(dir string, h nethttp.Handler) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		fis, err := ioutil.ReadDir(filepath.Join(dir, r.URL.Path))
+		if err != nil || r.URL.Path[len(r.URL.Path)-1] != '/' {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		entries := make([]map[string]interface{}, 0, len(fis))
+		for _, fi := range fis {
+			e := map[string]interface{}{
+				"name":  fi.Name(),
+				"type":  "file",
+				"mtime": fi.ModTime().UTC().Format(nethttp.TimeFormat),
+				"size":  fi.Size(),
+			}
+			if fi.IsDir() {
+				e["type"] = "directory"
+				delete(e, "size")
+			}
+			entries = append(entries, e)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
+
+// ignoreRange will serve as a server which doesn't support Range.
+func ignoreRange(h nethttp.Handler) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		r.Header.Del("Range")
+		h.ServeHTTP(w, r)
+	})
+}
+
+func withAuth(h nethttp.Handler) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "test_user" || pass != "test_password" {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("ETag", `"test_etag"`)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newTestStorage(t *testing.T, url, password string) *Storage {
+	ep, err := endpoint.ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := New()
+	err = store.Init(
+		pairs.WithEndpoint(ep),
+		pairs.WithCredential(credential.MustNewHmac("test_user", password)),
+		pairs.WithWorkDir("/work"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStorage(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fs := nethttp.FileServer(nethttp.Dir(dir))
+	cases := []struct {
+		name    string
+		handler nethttp.Handler
+	}{
+		{"html index", fs},
+		{"json index", jsonIndex(dir, fs)},
+		{"range ignored", ignoreRange(fs)},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(withAuth(tt.handler))
+			defer srv.Close()
+
+			testStorage(t, newTestStorage(t, srv.URL, "test_password"))
+		})
+	}
+}
+
+func testStorage(t *testing.T, store *Storage) {
+	t.Run("stat", func(t *testing.T) {
+		o, err := store.Stat("a/b/test file")
+		assert.NoError(t, err)
+		assert.Equal(t, "a/b/test file", o.Name)
+		assert.Equal(t, types.ObjectTypeFile, o.Type)
+		assert.Equal(t, int64(len(content)), o.Size)
+		assert.False(t, o.UpdatedAt.IsZero())
+		checksum, ok := o.GetChecksum()
+		assert.True(t, ok)
+		assert.Equal(t, `"test_etag"`, checksum)
+
+		o, err = store.Stat("a")
+		assert.NoError(t, err)
+		assert.Equal(t, types.ObjectTypeDir, o.Type)
+
+		_, err = store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			pairs  []*types.Pair
+			expect string
+		}{
+			{"full", nil, "0123456789"},
+			{"offset", []*types.Pair{pairs.WithOffset(7)}, "789"},
+			{"size", []*types.Pair{pairs.WithSize(3)}, "012"},
+			{"offset and size", []*types.Pair{pairs.WithOffset(2), pairs.WithSize(3)}, "234"},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				r, err := store.Read("a/b/test file", tt.pairs...)
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer r.Close()
+
+				b, err := ioutil.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, string(b))
+			})
+		}
+
+		_, err := store.Read("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := make([]string, 0), make([]string, 0)
+		err := store.List("",
+			pairs.WithFileFunc(func(o *types.Object) {
+				files = append(files, o.Name)
+			}),
+			pairs.WithDirFunc(func(o *types.Object) {
+				dirs = append(dirs, o.Name)
+			}),
+		)
+		assert.NoError(t, err)
+		sort.Strings(files)
+		assert.Equal(t, []string{"c"}, files)
+		assert.Equal(t, []string{"a"}, dirs)
+
+		files = files[:0]
+		err = store.List("a/b", pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a/b/test file"}, files)
+
+		err = store.List("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		err := store.Write("c", bytes.NewReader(content))
+		assert.True(t, errors.Is(err, types.ErrReadOnly))
+
+		err = store.Delete("c")
+		assert.True(t, errors.Is(err, types.ErrReadOnly))
+	})
+}
+
+func TestStorage_Init(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	srv := httptest.NewServer(withAuth(nethttp.FileServer(nethttp.Dir(dir))))
+	defer srv.Close()
+
+	t.Run("permission denied", func(t *testing.T) {
+		store := newTestStorage(t, srv.URL, "invalid_password")
+
+		_, err := store.Stat("c")
+		assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+	})
+
+	t.Run("unsupported credential", func(t *testing.T) {
+		store := New()
+		err := store.Init(
+			pairs.WithEndpoint(endpoint.NewHTTP("127.0.0.1", 80)),
+			pairs.WithCredential(credential.MustNewFile("/path/to/file")),
+		)
+		assert.True(t, errors.Is(err, credential.ErrUnsupportedProtocol))
+	})
+}
+
+func TestParseHTMLIndex(t *testing.T) {
+	page := `<html><body>
+<a href="?C=N;O=D">Name</a>
+<a href="/">Parent Directory</a>
+<a href="../">../</a>
+<a href="dir/">dir/</a>
+<a href="file%20name">file name</a>
+<a href="/data/abs">abs</a>
+<a href="dir/nested">nested</a>
+<a href="https://example.com/data/other">other</a>
+<a name="anchor">anchor</a>
+<a href="abs">abs</a>
+</body></html>`
+
+	dir, err := url.Parse("http://127.0.0.1/data/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := parseHTMLIndex(bytes.NewReader([]byte(page)), dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entry{
+		{Name: "dir", Type: "directory"},
+		{Name: "file name", Type: "file"},
+		{Name: "abs", Type: "file"},
+	}, entries)
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// entry is an entry in dir's index page, the json tags follow nginx's `autoindex_format json`.
+type entry struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	MTime string `json:"mtime"`
+	Size  int64  `json:"size"`
+}
+
+func (e *entry) format() *types.Object {
+	o := &types.Object{
+		Type:     types.ObjectTypeFile,
+		Size:     e.Size,
+		Metadata: make(metadata.Metadata),
+	}
+	if e.Type == "directory" {
+		o.Type = types.ObjectTypeDir
+	}
+	// Modified time is optional, so we will ignore the invalid one.
+	if t, err := nethttp.ParseTime(e.MTime); err == nil {
+		o.UpdatedAt = t
+	}
+	return o
+}
+
+// parseJSONIndex will parse a JSON manifest into entries.
+func parseJSONIndex(r io.Reader) ([]*entry, error) {
+	entries := make([]*entry, 0)
+	err := json.NewDecoder(r).Decode(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("decode json index: %w", err)
+	}
+	return entries, nil
+}
+
+// parseHTMLIndex will parse an autoindex HTML page of dir into entries.
+//
+// Only links to dir's direct children will be returned, and links ending with "/" will be treated as dirs.
+func parseHTMLIndex(r io.Reader, dir *url.URL) ([]*entry, error) {
+	entries := make([]*entry, 0)
+	seen := make(map[string]struct{})
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				return entries, nil
+			}
+			return nil, fmt.Errorf("parse html index: %w", z.Err())
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		t := z.Token()
+		if t.Data != "a" {
+			continue
+		}
+
+		for _, attr := range t.Attr {
+			if attr.Key != "href" {
+				continue
+			}
+			e, ok := parseHref(attr.Val, dir)
+			if !ok {
+				break
+			}
+			if _, ok := seen[e.Name]; !ok {
+				seen[e.Name] = struct{}{}
+				entries = append(entries, e)
+			}
+			break
+		}
+	}
+}
+
+// parseHref will convert href into an entry if it links to a direct child of dir.
+func parseHref(href string, dir *url.URL) (*entry, bool) {
+	u, err := dir.Parse(href)
+	if err != nil {
+		return nil, false
+	}
+	// Links to other sites or sorting links like "?C=N;O=D" should be skipped.
+	if u.Scheme != dir.Scheme || u.Host != dir.Host || u.RawQuery != "" {
+		return nil, false
+	}
+
+	base := strings.TrimSuffix(dir.Path, "/") + "/"
+	if !strings.HasPrefix(u.Path, base) {
+		return nil, false
+	}
+	name := strings.TrimPrefix(u.Path, base)
+
+	e := &entry{Type: "file"}
+	if strings.HasSuffix(name, "/") {
+		e.Type = "directory"
+		name = strings.TrimSuffix(name, "/")
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return nil, false
+	}
+	e.Name = name
+	return e, true
+}
+
+// formatResponse will convert a HEAD response into object.
+func formatResponse(resp *nethttp.Response) (o *types.Object, err error) {
+	o = &types.Object{
+		Type:     types.ObjectTypeFile,
+		Metadata: make(metadata.Metadata),
+	}
+	if strings.HasSuffix(resp.Request.URL.Path, "/") {
+		o.Type = types.ObjectTypeDir
+		return o, nil
+	}
+
+	// ContentLength will be -1 if unknown.
+	if resp.ContentLength > 0 {
+		o.Size = resp.ContentLength
+	}
+	if v := resp.Header.Get("Last-Modified"); v != "" {
+		o.UpdatedAt, err = nethttp.ParseTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse last modified [%s]: %w", v, err)
+		}
+	}
+	if v := resp.Header.Get("Content-Type"); v != "" {
+		o.SetType(v)
+	}
+	if v := resp.Header.Get("ETag"); v != "" {
+		o.SetChecksum(v)
+	}
+	return o, nil
+}
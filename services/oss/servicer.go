@@ -17,24 +17,22 @@ type Service struct {
 
 // New will create a new aliyun oss service.
 func New(pairs ...*types.Pair) (s *Service, err error) {
-	const errorMessage = "%s New: %w"
-
 	s = &Service{}
 
 	opt, err := parseServicePairNew(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	credProtocol, cred := opt.Credential.Protocol(), opt.Credential.Value()
 	if credProtocol != credential.ProtocolHmac {
-		return nil, fmt.Errorf(errorMessage, s, credential.ErrUnsupportedProtocol)
+		return nil, types.NewError(s, "New", credential.ErrUnsupportedProtocol)
 	}
 	ep := opt.Endpoint.Value()
 
 	s.service, err = oss.New(ep.String(), cred[0], cred[1])
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 	return
 }
@@ -49,11 +47,9 @@ func (s *Service) String() string {
 
 // List implements Servicer.List
 func (s *Service) List(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List: %w"
-
 	opt, err := parseServicePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "List", err)
 	}
 
 	marker := ""
@@ -64,13 +60,15 @@ func (s *Service) List(pairs ...*types.Pair) (err error) {
 			oss.MaxKeys(1000),
 		)
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, err)
+			err = handleOssError(err)
+			return types.NewError(s, "List", err)
 		}
 
 		for _, v := range output.Buckets {
 			bucket, err := s.service.Bucket(v.Name)
 			if err != nil {
-				return fmt.Errorf(errorMessage, s, err)
+				err = handleOssError(err)
+				return types.NewError(s, "List", err)
 			}
 			if opt.HasStoragerFunc {
 				c := newStorage(bucket)
@@ -88,37 +86,35 @@ func (s *Service) List(pairs ...*types.Pair) (err error) {
 
 // Get implements Servicer.Get
 func (s *Service) Get(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s Get [%s]: %w"
-
 	bucket, err := s.service.Bucket(name)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		err = handleOssError(err)
+		return nil, types.NewError(s, "Get", err, name)
 	}
 	return newStorage(bucket), nil
 }
 
 // Create implements Servicer.Create
 func (s *Service) Create(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s Create [%s]: %w"
-
 	err := s.service.CreateBucket(name)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		err = handleOssError(err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 	bucket, err := s.service.Bucket(name)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		err = handleOssError(err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 	return newStorage(bucket), nil
 }
 
 // Delete implements Servicer.Delete
 func (s *Service) Delete(name string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
-
 	err = s.service.DeleteBucket(name)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, name, err)
+		err = handleOssError(err)
+		return types.NewError(s, "Delete", err, name)
 	}
 	return nil
 }
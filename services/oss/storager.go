@@ -3,12 +3,13 @@ package oss
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 
+	"github.com/Xuanwo/storage/pkg/iowrap"
 	"github.com/Xuanwo/storage/types"
 	"github.com/Xuanwo/storage/types/metadata"
 )
@@ -41,11 +42,9 @@ func (s *Storage) String() string {
 
 // Init implements Storager.Init
 func (s *Storage) Init(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Init: %w"
-
 	opt, err := parseStoragePairInit(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "Init", err)
 	}
 
 	if opt.HasWorkDir {
@@ -68,11 +67,9 @@ func (s *Storage) Metadata() (m metadata.Storage, err error) {
 
 // List implements Storager.List
 func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List [%s]: %w"
-
 	opt, err := parseStoragePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "List", err, path)
 	}
 
 	marker := ""
@@ -88,7 +85,8 @@ func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 			oss.Prefix(rp),
 		)
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, path, err)
+			err = handleOssError(err)
+			return types.NewError(s, "List", err, path)
 		}
 
 		for _, v := range output.CommonPrefixes {
@@ -131,24 +129,58 @@ func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 
 // Read implements Storager.Read
 func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
-	const errorMessage = "%s Read [%s]: %w"
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	options := make([]oss.Option, 0)
+	if opt.HasIfMatch {
+		options = append(options, oss.IfMatch(opt.IfMatch))
+	}
+	if opt.HasIfNoneMatch {
+		options = append(options, oss.IfNoneMatch(opt.IfNoneMatch))
+	}
+	if opt.HasIfModifiedSince {
+		options = append(options, oss.IfModifiedSince(opt.IfModifiedSince))
+	}
+	if opt.HasIfUnmodifiedSince {
+		options = append(options, oss.IfUnmodifiedSince(opt.IfUnmodifiedSince))
+	}
+	if opt.HasOffset || opt.HasSize {
+		nr := fmt.Sprintf("%d-", opt.Offset)
+		if opt.HasSize {
+			nr = fmt.Sprintf("%d-%d", opt.Offset, opt.Offset+opt.Size-1)
+		}
+		options = append(options, oss.NormalizedRange(nr))
+	}
+	if opt.HasVersionId {
+		options = append(options, oss.VersionId(opt.VersionId))
+	}
 
 	rp := s.getAbsPath(path)
 
-	output, err := s.bucket.GetObject(rp)
+	output, err := s.bucket.GetObject(rp, options...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		err = handleOssError(err)
+		return nil, types.NewError(s, "Read", err, path)
 	}
 	return output, nil
 }
 
 // Write implements Storager.Write
 func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Write [%s]: %w"
-
 	opt, err := parseStoragePairWrite(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
+	}
+
+	if opt.HasDetectContentType && opt.DetectContentType && !opt.HasContentType {
+		opt.ContentType, r, err = iowrap.SniffContentType(r)
+		if err != nil {
+			return types.NewError(s, "Write", err, path)
+		}
+		opt.HasContentType = true
 	}
 
 	options := make([]oss.Option, 0)
@@ -162,39 +194,117 @@ func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err err
 		// TODO: we need to handle different storage class name between services.
 		options = append(options, oss.StorageClass(oss.StorageClassType(opt.StorageClass)))
 	}
+	if opt.HasUserMetadata {
+		for k, v := range opt.UserMetadata {
+			options = append(options, oss.Meta(k, v))
+		}
+	}
+	if opt.HasContentType {
+		options = append(options, oss.ContentType(opt.ContentType))
+	}
+	if opt.HasContentEncoding {
+		options = append(options, oss.ContentEncoding(opt.ContentEncoding))
+	}
+	if opt.HasContentDisposition {
+		options = append(options, oss.ContentDisposition(opt.ContentDisposition))
+	}
+	if opt.HasCacheControl {
+		options = append(options, oss.CacheControl(opt.CacheControl))
+	}
 
 	rp := s.getAbsPath(path)
 
 	err = s.bucket.PutObject(rp, r, options...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		err = handleOssError(err)
+		return types.NewError(s, "Write", err, path)
 	}
 	return nil
 }
 
+// Reach implements Storager.Reach
+func (s *Storage) Reach(path string, pairs ...*types.Pair) (url string, err error) {
+	opt, err := parseStoragePairReach(pairs...)
+	if err != nil {
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	method := http.MethodGet
+	if opt.HasMethod {
+		method = opt.Method
+	}
+
+	options := make([]oss.Option, 0)
+	switch method {
+	case http.MethodGet:
+	case http.MethodPut:
+		// Content type will be signed, client must send the same header.
+		if opt.HasContentType {
+			options = append(options, oss.ContentType(opt.ContentType))
+		}
+		// Content length is not covered by the signature, so it couldn't be constrained.
+		if opt.HasSize {
+			err = fmt.Errorf("size %d: %w", opt.Size, types.ErrNotSupported)
+			return "", types.NewError(s, "Reach", err, path)
+		}
+	default:
+		err = fmt.Errorf("method %s: %w", method, types.ErrNotSupported)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	url, err = s.bucket.SignURL(rp, oss.HTTPMethod(method), int64(opt.Expire), options...)
+	if err != nil {
+		err = handleOssError(err)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+	return url, nil
+}
+
 // Stat implements Storager.Stat
 func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
-	const errorMessage = "%s Stat [%s]: %w"
+	opt, err := parseStoragePairStat(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+
+	options := make([]oss.Option, 0)
+	if opt.HasIfMatch {
+		options = append(options, oss.IfMatch(opt.IfMatch))
+	}
+	if opt.HasIfNoneMatch {
+		options = append(options, oss.IfNoneMatch(opt.IfNoneMatch))
+	}
+	if opt.HasIfModifiedSince {
+		options = append(options, oss.IfModifiedSince(opt.IfModifiedSince))
+	}
+	if opt.HasIfUnmodifiedSince {
+		options = append(options, oss.IfUnmodifiedSince(opt.IfUnmodifiedSince))
+	}
+	if opt.HasVersionId {
+		options = append(options, oss.VersionId(opt.VersionId))
+	}
 
 	rp := s.getAbsPath(path)
 
-	output, err := s.bucket.GetObjectMeta(rp)
+	output, err := s.bucket.GetObjectDetailedMeta(rp, options...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		err = handleOssError(err)
+		return nil, types.NewError(s, "Stat", err, path)
 	}
 
 	// Parse content length.
-	size, err := strconv.ParseInt(output.Get("Content-Length"), 10, 64)
+	size, err := strconv.ParseInt(output.Get(oss.HTTPHeaderContentLength), 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		return nil, types.NewError(s, "Stat", err, path)
 	}
 	// Parse last modified.
-	lastModified, err := time.Parse(time.RFC822, output.Get("Last-Modified"))
+	lastModified, err := http.ParseTime(output.Get(oss.HTTPHeaderLastModified))
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		return nil, types.NewError(s, "Stat", err, path)
 	}
 
-	// TODO: get object's checksum and storage class.
 	o = &types.Object{
 		Name:      path,
 		Type:      types.ObjectTypeFile,
@@ -202,18 +312,122 @@ func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err
 		UpdatedAt: lastModified,
 		Metadata:  make(metadata.Metadata),
 	}
+
+	if v := output.Get(oss.HTTPHeaderContentType); v != "" {
+		o.SetType(v)
+	}
+	if v := output.Get(oss.HTTPHeaderEtag); v != "" {
+		o.SetChecksum(v)
+	}
+	if v := output.Get(oss.HTTPHeaderOssStorageClass); v != "" {
+		o.SetClass(v)
+	}
+	if m := decodeUserMetadata(output); len(m) > 0 {
+		o.SetUserMetadata(m)
+	}
+	if v := output.Get(oss.HTTPHeaderContentEncoding); v != "" {
+		o.SetContentEncoding(v)
+	}
+	if v := output.Get(oss.HTTPHeaderContentDisposition); v != "" {
+		o.SetContentDisposition(v)
+	}
+	if v := output.Get(oss.HTTPHeaderCacheControl); v != "" {
+		o.SetCacheControl(v)
+	}
+	if v := output.Get(headerVersionId); v != "" {
+		o.SetVersionId(v)
+	}
 	return o, nil
 }
 
 // Delete implements Storager.Delete
+//
+// A delete marker will be created if versioning is enabled, and the version will be deleted permanently if
+// version_id is given.
 func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
+	opt, err := parseStoragePairDelete(pairs...)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+
+	options := make([]oss.Option, 0)
+	if opt.HasVersionId {
+		options = append(options, oss.VersionId(opt.VersionId))
+	}
+
+	rp := s.getAbsPath(path)
+
+	err = s.bucket.DeleteObject(rp, options...)
+	if err != nil {
+		err = handleOssError(err)
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// ListVersions implements Storager.ListVersions
+func (s *Storage) ListVersions(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairListVersions(pairs...)
+	if err != nil {
+		return types.NewError(s, "ListVersions", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	keyMarker, versionIdMarker := "", ""
+	var output oss.ListObjectVersionsResult
+	for {
+		options := []oss.Option{oss.Prefix(rp)}
+		if keyMarker != "" {
+			options = append(options, oss.KeyMarker(keyMarker), oss.VersionIdMarker(versionIdMarker))
+		}
+
+		output, err = s.bucket.ListObjectVersions(options...)
+		if err != nil {
+			err = handleOssError(err)
+			return types.NewError(s, "ListVersions", err, path)
+		}
+
+		for _, v := range output.ObjectVersions {
+			// Objects which have path as prefix will also be listed.
+			if v.Key != rp {
+				continue
+			}
+
+			o := &types.Object{
+				Name:      path,
+				Type:      types.ObjectTypeFile,
+				Size:      v.Size,
+				UpdatedAt: v.LastModified,
+				Metadata:  make(metadata.Metadata),
+			}
+			o.SetVersionId(v.VersionId)
+			o.SetClass(v.StorageClass)
+			o.SetChecksum(v.ETag)
 
+			if opt.HasFileFunc {
+				opt.FileFunc(o)
+			}
+		}
+
+		if !output.IsTruncated {
+			break
+		}
+		keyMarker, versionIdMarker = output.NextKeyMarker, output.NextVersionIdMarker
+	}
+	return nil
+}
+
+// Restore implements Storager.Restore
+//
+// The version will be copied into a new version, so that the current version is kept.
+func (s *Storage) Restore(path, version string, pairs ...*types.Pair) (err error) {
 	rp := s.getAbsPath(path)
 
-	err = s.bucket.DeleteObject(rp)
+	_, err = s.bucket.CopyObject(rp, rp, oss.VersionId(version))
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		err = handleOssError(err)
+		return types.NewError(s, "Restore", err, path)
 	}
 	return nil
 }
@@ -1,9 +1,18 @@
 package oss
 
 import (
+	"fmt"
+	"net/http"
 	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+
+	"github.com/Xuanwo/storage/types"
 )
 
+// headerVersionId is the response header for object's version id.
+const headerVersionId = "x-oss-version-id"
+
 func (s *Storage) getAbsPath(path string) string {
 	return strings.TrimPrefix(s.workDir+"/"+path, "/")
 }
@@ -11,3 +20,69 @@ func (s *Storage) getAbsPath(path string) string {
 func (s *Storage) getRelPath(path string) string {
 	return strings.TrimPrefix(path, s.workDir+"/")
 }
+
+// decodeUserMetadata will extract user metadata from response headers, keys will be in lower case.
+func decodeUserMetadata(h http.Header) map[string]string {
+	x := make(map[string]string)
+	for k, v := range h {
+		if !strings.HasPrefix(k, oss.HTTPHeaderOssMetaPrefix) || len(v) == 0 {
+			continue
+		}
+		x[strings.ToLower(strings.TrimPrefix(k, oss.HTTPHeaderOssMetaPrefix))] = v[0]
+	}
+	return x
+}
+
+func handleOssError(err error) error {
+	if err == nil {
+		panic("error must not be nil")
+	}
+
+	e, ok := err.(oss.ServiceError)
+	if !ok {
+		// oss sdk returns 3xx responses as plain errors, and 304 means the read precondition failed.
+		if strings.HasPrefix(err.Error(), "oss: service returned 304") {
+			return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+		}
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+
+	switch e.Code {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NoSuchVersion":
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case "AccessDenied":
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case "InvalidAccessKeyId", "SignatureDoesNotMatch", "InvalidBucketName":
+		return fmt.Errorf("%w: %v", types.ErrConfigIncorrect, err)
+	case "BucketAlreadyExists", "FileAlreadyExists":
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	case "BucketNotEmpty":
+		return fmt.Errorf("%w: %v", types.ErrDirNotEmpty, err)
+	case "InvalidObjectName":
+		return fmt.Errorf("%w: %v", types.ErrInvalidPath, err)
+	case "PreconditionFailed":
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case "TooManyBuckets", "EntityTooLarge":
+		return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+	case "QpsLimitExceeded", "DownloadTrafficRateLimitExceeded", "UploadTrafficRateLimitExceeded":
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case "InternalError", "ServiceUnavailable":
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+
+	// HEAD requests don't have body, so we can only handle them via status code.
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	default:
+		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	}
+}
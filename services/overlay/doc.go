@@ -0,0 +1,12 @@
+/*
+Package overlay provided a storager which layers a writable upper storager over read-only lower storagers.
+
+Read, Stat and List will fall through layers from top to bottom, and the first layer which has the object wins.
+Write and Delete will only touch the upper storager, lower storagers will never be modified.
+
+Deleting an object which exists in lower storagers will create a whiteout marker named ".wh.<name>" beside it in
+upper storager, so that it stays hidden. Writing into a dir which has been deleted will replace its whiteout
+marker with an opaque marker ".wh..wh..opq" in the dir, so that the old content in lower storagers will not
+come back. Paths containing ".wh." prefixed elements are reserved and can't be used by caller.
+*/
+package overlay
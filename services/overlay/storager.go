@@ -0,0 +1,285 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// Storage is the overlay storager.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	upper storage.Storager
+	// lowers are ordered from top to bottom.
+	lowers []storage.Storager
+}
+
+// New will create an overlay storager.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager overlay {Upper: %s, Lowers: %v}", s.upper, s.lowers)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.upper = opt.Storager
+	if opt.HasStoragers {
+		s.lowers = opt.Storagers
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+//
+// Upper storager's metadata will be returned.
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m, err = s.upper.Metadata()
+	if err != nil {
+		return m, types.NewError(s, "Metadata", err)
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+//
+// Objects will be merged by name, and the upper one wins.
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	if isReserved(path) {
+		return types.NewError(s, "List", types.ErrObjectNotExist, path)
+	}
+
+	l := &lister{
+		opt:      opt,
+		seen:     make(map[string]struct{}),
+		whiteout: make(map[string]struct{}),
+	}
+
+	err = l.list(s.upper, path, true)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return types.NewError(s, "List", err, path)
+	}
+	found := err == nil
+
+	hidden := l.opaque
+	if !hidden {
+		hidden, err = s.hidden(path)
+		if err != nil {
+			return types.NewError(s, "List", err, path)
+		}
+	}
+	if hidden {
+		if !found {
+			return types.NewError(s, "List", types.ErrObjectNotExist, path)
+		}
+		return nil
+	}
+
+	for _, store := range s.lowers {
+		err = l.list(store, path, false)
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return types.NewError(s, "List", err, path)
+		}
+		found = found || err == nil
+	}
+	if !found {
+		return types.NewError(s, "List", types.ErrObjectNotExist, path)
+	}
+	return nil
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	if isReserved(path) {
+		return nil, types.NewError(s, "Read", types.ErrObjectNotExist, path)
+	}
+
+	err = s.lookup(path, func(store storage.Storager) (err error) {
+		r, err = store.Read(path, pairs...)
+		return
+	})
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// Object will be written into upper storager, and whiteout markers which hide it will be removed.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	if isReserved(path) {
+		return types.NewError(s, "Write", types.ErrInvalidPath, path)
+	}
+
+	err = s.upper.Write(path, r, pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	err = s.unhide(path)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	if isReserved(path) {
+		return nil, types.NewError(s, "Stat", types.ErrObjectNotExist, path)
+	}
+
+	err = s.lookup(path, func(store storage.Storager) (err error) {
+		o, err = store.Stat(path, pairs...)
+		return
+	})
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// Object will be deleted from upper storager, and a whiteout marker will be created if it exists in lower
+// storagers.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	if isReserved(path) {
+		return types.NewError(s, "Delete", types.ErrObjectNotExist, path)
+	}
+
+	err = s.upper.Delete(path, pairs...)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return types.NewError(s, "Delete", err, path)
+	}
+	inUpper := err == nil
+
+	inLower, err := s.inLowers(path)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	if inLower {
+		err = s.upper.Write(whiteoutPath(path), emptyReader(), ps.WithSize(0))
+		if err != nil {
+			return types.NewError(s, "Delete", err, path)
+		}
+	}
+
+	if !inUpper && !inLower {
+		return types.NewError(s, "Delete", types.ErrObjectNotExist, path)
+	}
+	return nil
+}
+
+// lookup will call fn on layers from top to bottom until fn succeeds or returns an error other than
+// ErrObjectNotExist.
+func (s *Storage) lookup(p string, fn func(store storage.Storager) error) (err error) {
+	err = fn(s.upper)
+	if !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+
+	hidden, err := s.hidden(p)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		return types.ErrObjectNotExist
+	}
+
+	for _, store := range s.lowers {
+		err = fn(store)
+		if !errors.Is(err, types.ErrObjectNotExist) {
+			return err
+		}
+	}
+	return types.ErrObjectNotExist
+}
+
+// inLowers will check whether p exists in lower storagers and is not hidden.
+func (s *Storage) inLowers(p string) (bool, error) {
+	hidden, err := s.hidden(p)
+	if err != nil || hidden {
+		return false, err
+	}
+
+	for _, store := range s.lowers {
+		ok, err := exists(store, p)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// hidden will check whether p in lower storagers has been hidden by whiteout markers of p or its parents, or
+// opaque markers in its parents.
+func (s *Storage) hidden(p string) (bool, error) {
+	for _, v := range parents(p) {
+		ok, err := exists(s.upper, opaquePath(v))
+		if err != nil || ok {
+			return ok, err
+		}
+		ok, err = exists(s.upper, whiteoutPath(v))
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	p = storageutil.CleanPath(p)
+	if p == "" {
+		return false, nil
+	}
+	return exists(s.upper, whiteoutPath(p))
+}
+
+// unhide will remove whiteout markers of p and its parents after p has been written into upper storager.
+//
+// Whiteout marker of a parent dir will be replaced by an opaque marker, so that old content in lower storagers
+// is still hidden.
+func (s *Storage) unhide(p string) (err error) {
+	for _, v := range parents(p) {
+		ok, err := exists(s.upper, whiteoutPath(v))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		err = s.upper.Write(opaquePath(v), emptyReader(), ps.WithSize(0))
+		if err != nil {
+			return err
+		}
+		err = s.upper.Delete(whiteoutPath(v))
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return err
+		}
+	}
+
+	err = s.upper.Delete(whiteoutPath(p))
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
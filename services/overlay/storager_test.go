@@ -0,0 +1,168 @@
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storagetest"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func newTestLayer(t *testing.T, files map[string]string) (string, storage.Storager) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(p, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, store
+}
+
+func TestStorage(t *testing.T) {
+	upperDir, upper := newTestLayer(t, map[string]string{
+		"a": "upper a",
+	})
+	defer os.RemoveAll(upperDir)
+	lowerDir1, lower1 := newTestLayer(t, map[string]string{
+		"a":     "lower1 a",
+		"b":     "lower1 b",
+		"d/e":   "lower1 e",
+		"d/f/g": "lower1 g",
+	})
+	defer os.RemoveAll(lowerDir1)
+	lowerDir2, lower2 := newTestLayer(t, map[string]string{
+		"b": "lower2 b",
+		"c": "lower2 c",
+	})
+	defer os.RemoveAll(lowerDir2)
+
+	store := New()
+	err := store.Init(pairs.WithStorager(upper), pairs.WithStoragers([]storage.Storager{lower1, lower2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("fall through", func(t *testing.T) {
+		assert.Equal(t, "upper a", storagetest.Read(t, store, "a"))
+		assert.Equal(t, "lower1 b", storagetest.Read(t, store, "b"))
+		assert.Equal(t, "lower2 c", storagetest.Read(t, store, "c"))
+
+		o, err := store.Stat("d/e")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len("lower1 e")), o.Size)
+
+		_, err = store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := storagetest.List(t, store, "")
+		assert.Equal(t, []string{"a", "b", "c"}, files)
+		assert.Equal(t, []string{"d"}, dirs)
+
+		err := store.List("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("write", func(t *testing.T) {
+		err := store.Write("c", bytes.NewReader([]byte("upper c")))
+		assert.NoError(t, err)
+		assert.Equal(t, "upper c", storagetest.Read(t, store, "c"))
+
+		// Lower storagers should never be modified.
+		b, err := ioutil.ReadFile(filepath.Join(lowerDir2, "c"))
+		assert.NoError(t, err)
+		assert.Equal(t, "lower2 c", string(b))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// Object only in lower storagers will be hidden by whiteout marker.
+		err := store.Delete("b")
+		assert.NoError(t, err)
+		_, err = store.Stat("b")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		_, err = os.Stat(filepath.Join(lowerDir1, "b"))
+		assert.NoError(t, err)
+
+		// Object in both upper and lower storagers.
+		err = store.Delete("a")
+		assert.NoError(t, err)
+		_, err = store.Read("a")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+		files, _ := storagetest.List(t, store, "")
+		assert.Equal(t, []string{"c"}, files)
+
+		err = store.Delete("b")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+		// Write after delete will bring it back.
+		err = store.Write("b", bytes.NewReader([]byte("upper b")))
+		assert.NoError(t, err)
+		assert.Equal(t, "upper b", storagetest.Read(t, store, "b"))
+		_, err = os.Stat(filepath.Join(upperDir, whiteoutPrefix+"b"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("delete dir", func(t *testing.T) {
+		err := store.Delete("d")
+		assert.NoError(t, err)
+
+		_, err = store.Stat("d/f/g")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		err = store.List("d")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		_, dirs := storagetest.List(t, store, "")
+		assert.Equal(t, []string{}, dirs)
+
+		// Write into a deleted dir should not bring back old content.
+		err = store.Write("d/h", bytes.NewReader([]byte("upper h")))
+		assert.NoError(t, err)
+
+		files, dirs := storagetest.List(t, store, "d")
+		assert.Equal(t, []string{"d/h"}, files)
+		assert.Equal(t, []string{}, dirs)
+		_, err = store.Stat("d/e")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		_, err = store.Stat("d/f/g")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("reserved", func(t *testing.T) {
+		err := store.Write(whiteoutPrefix+"x", bytes.NewReader(nil))
+		assert.True(t, errors.Is(err, types.ErrInvalidPath))
+
+		_, err = store.Stat("d/" + opaqueMarker)
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+}
+
+func TestParents(t *testing.T) {
+	assert.Equal(t, []string{}, parents(""))
+	assert.Equal(t, []string{}, parents("a"))
+	assert.Equal(t, []string{"a", "a/b"}, parents("/a/b/c/"))
+}
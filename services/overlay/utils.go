@@ -0,0 +1,121 @@
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueMarker   = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// lister will merge List results from layers.
+type lister struct {
+	opt *pairStorageList
+
+	// seen is the names which have been returned.
+	seen map[string]struct{}
+	// whiteout is the names which have been deleted in upper storager.
+	whiteout map[string]struct{}
+	// opaque will be true if the dir has an opaque marker in upper storager.
+	opaque bool
+}
+
+// list will list path in store, markers will only be handled for the upper storager.
+func (l *lister) list(store storage.Storager, path string, upper bool) error {
+	return store.List(path,
+		ps.WithFileFunc(func(o *types.Object) {
+			name := baseName(o.Name)
+			if upper && name == opaqueMarker {
+				l.opaque = true
+				return
+			}
+			if upper && strings.HasPrefix(name, whiteoutPrefix) {
+				l.whiteout[strings.TrimPrefix(name, whiteoutPrefix)] = struct{}{}
+				return
+			}
+			if l.visit(name) && l.opt.HasFileFunc {
+				l.opt.FileFunc(o)
+			}
+		}),
+		ps.WithDirFunc(func(o *types.Object) {
+			if l.visit(baseName(o.Name)) && l.opt.HasDirFunc {
+				l.opt.DirFunc(o)
+			}
+		}),
+	)
+}
+
+// visit will check whether name should be returned, and mark it as seen.
+func (l *lister) visit(name string) bool {
+	if _, ok := l.seen[name]; ok {
+		return false
+	}
+	if _, ok := l.whiteout[name]; ok {
+		return false
+	}
+	l.seen[name] = struct{}{}
+	return true
+}
+
+func baseName(p string) string {
+	return path.Base(storageutil.CleanPath(p))
+}
+
+// parents will return all parent dirs of p from top to bottom, root is not included.
+func parents(p string) []string {
+	p = storageutil.CleanPath(p)
+	dirs := make([]string, 0)
+	for i, c := range p {
+		if c == '/' {
+			dirs = append(dirs, p[:i])
+		}
+	}
+	return dirs
+}
+
+// isReserved will check whether p contains elements used by markers.
+func isReserved(p string) bool {
+	for _, v := range strings.Split(storageutil.CleanPath(p), "/") {
+		if strings.HasPrefix(v, whiteoutPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// whiteoutPath will return the path of whiteout marker for p.
+func whiteoutPath(p string) string {
+	dir, name := path.Split(storageutil.CleanPath(p))
+	return path.Join(dir, whiteoutPrefix+name)
+}
+
+// opaquePath will return the path of opaque marker in dir.
+func opaquePath(dir string) string {
+	return path.Join(storageutil.CleanPath(dir), opaqueMarker)
+}
+
+// exists will check whether p exists in store.
+func exists(store storage.Storager, p string) (bool, error) {
+	_, err := store.Stat(p)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, types.ErrObjectNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+func emptyReader() io.Reader {
+	return bytes.NewReader(nil)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
 	"github.com/yunify/qingstor-sdk-go/v3/config"
 	iface "github.com/yunify/qingstor-sdk-go/v3/interface"
 	"github.com/yunify/qingstor-sdk-go/v3/service"
@@ -24,8 +25,6 @@ type Service struct {
 
 // New will create a new qingstor service.
 func New(pairs ...*types.Pair) (s *Service, err error) {
-	const errorMessage = "%s New: %w"
-
 	s = &Service{
 		noRedirectClient: &http.Client{
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -36,19 +35,24 @@ func New(pairs ...*types.Pair) (s *Service, err error) {
 
 	opt, err := parseServicePairNew(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	credProtocol, cred := opt.Credential.Protocol(), opt.Credential.Value()
 	if credProtocol != credential.ProtocolHmac {
-		return nil, fmt.Errorf(errorMessage, s, credential.ErrUnsupportedProtocol)
+		return nil, types.NewError(s, "New", credential.ErrUnsupportedProtocol)
 	}
 	cfg, err := config.New(cred[0], cred[1])
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 	if opt.HasEndpoint {
 		ep := opt.Endpoint.Value()
+		// QingStor SDK doesn't support sending requests under a path prefix.
+		if ep.Path != "" {
+			err = fmt.Errorf("%w: path %s is not supported", endpoint.ErrInvalidConfig, ep.Path)
+			return nil, types.NewError(s, "New", err)
+		}
 		cfg.Host = ep.Host
 		cfg.Port = ep.Port
 		cfg.Protocol = ep.Protocol
@@ -69,11 +73,9 @@ func (s *Service) String() string {
 
 // Create implements Servicer.Create
 func (s *Service) Create(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s Create [%s]: %w"
-
 	opt, err := parseServicePairCreate(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 
 	// TODO: check bucket name here.
@@ -81,62 +83,56 @@ func (s *Service) Create(name string, pairs ...*types.Pair) (storage.Storager, e
 	bucket, err := s.service.Bucket(name, opt.Location)
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 
 	_, err = bucket.Put()
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 	return newStorage(bucket)
 }
 
 // Delete implements Servicer.Delete
 func (s *Service) Delete(name string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
-
 	opt, err := parseServicePairDelete(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, name, err)
+		return types.NewError(s, "Delete", err, name)
 	}
 	bucket, err := s.get(name, opt.Location)
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, name, err)
+		return types.NewError(s, "Delete", err, name)
 	}
 	_, err = bucket.Delete()
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, name, err)
+		return types.NewError(s, "Delete", err, name)
 	}
 	return nil
 }
 
 // Get implements Servicer.Get
 func (s *Service) Get(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s Get [%s]: %w"
-
 	opt, err := parseServicePairGet(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Get", err, name)
 	}
 
 	bucket, err := s.get(name, opt.Location)
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Get", err, name)
 	}
 	return newStorage(bucket)
 }
 
 // List implements Servicer.List
 func (s *Service) List(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List: %w"
-
 	opt, err := parseServicePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "List", err)
 	}
 
 	input := &service.ListBucketsInput{}
@@ -148,18 +144,18 @@ func (s *Service) List(pairs ...*types.Pair) (err error) {
 	output, err := s.service.ListBuckets(input)
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "List", err)
 	}
 
 	for _, v := range output.Buckets {
 		store, err := s.get(*v.Name, *v.Location)
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, err)
+			return types.NewError(s, "List", err)
 		}
 		if opt.HasStoragerFunc {
 			c, err := newStorage(store)
 			if err != nil {
-				return fmt.Errorf(errorMessage, s, err)
+				return types.NewError(s, "List", err)
 			}
 			opt.StoragerFunc(c)
 		}
@@ -168,11 +164,9 @@ func (s *Service) List(pairs ...*types.Pair) (err error) {
 }
 
 func (s *Service) get(name, location string) (*service.Bucket, error) {
-	const errorMessage = "%s get [%s]: %w"
-
 	if !IsBucketNameValid(name) {
 		err := handleQingStorError(ErrInvalidBucketName)
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "get", err, name)
 	}
 
 	// TODO: add bucket name check here.
@@ -180,7 +174,7 @@ func (s *Service) get(name, location string) (*service.Bucket, error) {
 		bucket, err := s.service.Bucket(name, location)
 		if err != nil {
 			err = handleQingStorError(err)
-			return nil, fmt.Errorf(errorMessage, s, name, err)
+			return nil, types.NewError(s, "get", err, name)
 		}
 		return bucket, nil
 	}
@@ -190,11 +184,11 @@ func (s *Service) get(name, location string) (*service.Bucket, error) {
 	r, err := s.noRedirectClient.Head(url)
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "get", err, name)
 	}
 	if r.StatusCode != http.StatusTemporaryRedirect {
 		err = fmt.Errorf("head status is %d instead of %d", r.StatusCode, http.StatusTemporaryRedirect)
-		return nil, fmt.Errorf(errorMessage, s, name, handleQingStorError(err))
+		return nil, types.NewError(s, "get", handleQingStorError(err), name)
 	}
 
 	// Example URL: https://bucket.zone.qingstor.com
@@ -202,7 +196,7 @@ func (s *Service) get(name, location string) (*service.Bucket, error) {
 	bucket, err := s.service.Bucket(name, location)
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "get", err, name)
 	}
 	return bucket, nil
 }
@@ -60,6 +60,14 @@ func TestService_New(t *testing.T) {
 	assert.Equal(t, srv.config.Host, host)
 	assert.Equal(t, srv.config.Port, port)
 	assert.Equal(t, srv.config.Protocol, "http")
+
+	// Endpoint with path
+	_, err = New(
+		pairs.WithCredential(credential.MustNewHmac(accessKey, secretKey)),
+		pairs.WithEndpoint(endpoint.NewHTTP(host, port).WithPath("/qingstor")),
+	)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, endpoint.ErrInvalidConfig))
 }
 
 func TestService_Get(t *testing.T) {
@@ -3,14 +3,18 @@ package qingstor
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"sync"
 
 	"github.com/pengsrc/go-shared/convert"
 	qsconfig "github.com/yunify/qingstor-sdk-go/v3/config"
 	iface "github.com/yunify/qingstor-sdk-go/v3/interface"
+	"github.com/yunify/qingstor-sdk-go/v3/request"
 	"github.com/yunify/qingstor-sdk-go/v3/service"
 
+	"github.com/Xuanwo/storage/pkg/httputil"
+	"github.com/Xuanwo/storage/pkg/iowrap"
 	"github.com/Xuanwo/storage/pkg/segment"
 	"github.com/Xuanwo/storage/types"
 	"github.com/Xuanwo/storage/types/metadata"
@@ -45,11 +49,9 @@ func newStorage(bucket *service.Bucket) (*Storage, error) {
 
 // Init implements Storager.Init
 func (s *Storage) Init(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Init: %w"
-
 	opt, err := parseStoragePairInit(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "Init", err)
 	}
 
 	if opt.HasWorkDir {
@@ -81,12 +83,10 @@ func (s *Storage) Metadata() (m metadata.Storage, err error) {
 
 // Statistical implements Storager.Statistical
 func (s *Storage) Statistical() (m metadata.Metadata, err error) {
-	const errorMessage = "%s Statistical: %w"
-
 	output, err := s.bucket.GetStatistics()
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "Statistical", err)
 	}
 
 	m = make(metadata.Metadata)
@@ -101,16 +101,35 @@ func (s *Storage) Statistical() (m metadata.Metadata, err error) {
 
 // Stat implements Storager.Stat
 func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
-	const errorMessage = "%s Stat [%s]: %w"
+	opt, err := parseStoragePairStat(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
 
 	input := &service.HeadObjectInput{}
+	if opt.HasIfMatch {
+		input.IfMatch = &opt.IfMatch
+	}
+	if opt.HasIfNoneMatch {
+		input.IfNoneMatch = &opt.IfNoneMatch
+	}
+	if opt.HasIfModifiedSince {
+		input.IfModifiedSince = &opt.IfModifiedSince
+	}
+	if opt.HasIfUnmodifiedSince {
+		input.IfUnmodifiedSince = &opt.IfUnmodifiedSince
+	}
 
 	rp := s.getAbsPath(path)
 
-	output, err := s.bucket.HeadObject(rp, input)
+	output, header, err := s.headObject(rp, input)
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	// qingstor sdk treats 304 as a valid response.
+	if service.IntValue(output.StatusCode) == http.StatusNotModified {
+		return nil, types.NewError(s, "Stat", types.ErrPreconditionFailed, path)
 	}
 
 	// TODO: Add dir support.
@@ -132,27 +151,35 @@ func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err
 	if output.XQSStorageClass != nil {
 		o.SetClass(service.StringValue(output.XQSStorageClass))
 	}
+	if m := decodeUserMetadata(header); len(m) > 0 {
+		o.SetUserMetadata(m)
+	}
+	if v := header.Get("Content-Encoding"); v != "" {
+		o.SetContentEncoding(v)
+	}
+	if v := header.Get("Content-Disposition"); v != "" {
+		o.SetContentDisposition(v)
+	}
+	if v := header.Get("Cache-Control"); v != "" {
+		o.SetCacheControl(v)
+	}
 	return o, nil
 }
 
 // Delete implements Storager.Delete
 func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
-
 	rp := s.getAbsPath(path)
 
 	_, err = s.bucket.DeleteObject(rp)
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Delete", err, path)
 	}
 	return nil
 }
 
 // Copy implements Storager.Copy
 func (s *Storage) Copy(src, dst string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Copy from [%s] to [%s]: %w"
-
 	rs := s.getAbsPath(src)
 	rd := s.getAbsPath(dst)
 
@@ -161,15 +188,13 @@ func (s *Storage) Copy(src, dst string, pairs ...*types.Pair) (err error) {
 	})
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, src, dst, err)
+		return types.NewError(s, "Copy", err, src, dst)
 	}
 	return nil
 }
 
 // Move implements Storager.Move
 func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Move from [%s] to [%s]: %w"
-
 	rs := s.getAbsPath(src)
 	rd := s.getAbsPath(dst)
 
@@ -178,18 +203,16 @@ func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
 	})
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, src, dst, err)
+		return types.NewError(s, "Move", err, src, dst)
 	}
 	return nil
 }
 
 // Reach implements Storager.Reach
 func (s *Storage) Reach(path string, pairs ...*types.Pair) (url string, err error) {
-	const errorMessage = "%s Reach [%s]: %w"
-
 	opt, err := parseStoragePairReach(pairs...)
 	if err != nil {
-		return "", fmt.Errorf(errorMessage, s, path, err)
+		return "", types.NewError(s, "Reach", err, path)
 	}
 
 	// FIXME: sdk should export GetObjectRequest as interface too?
@@ -197,31 +220,50 @@ func (s *Storage) Reach(path string, pairs ...*types.Pair) (url string, err erro
 
 	rp := s.getAbsPath(path)
 
-	r, _, err := bucket.GetObjectRequest(rp, nil)
+	method := http.MethodGet
+	if opt.HasMethod {
+		method = opt.Method
+	}
+
+	var r *request.Request
+	switch {
+	case method == http.MethodGet:
+		r, _, err = bucket.GetObjectRequest(rp, nil)
+	case method == http.MethodPut && opt.HasSegmentId:
+		r, err = s.uploadMultipartRequest(opt.SegmentId, opt.Offset, opt.Size)
+		if err != nil {
+			return "", types.NewError(s, "Reach", err, path)
+		}
+	case method == http.MethodPut:
+		input := &service.PutObjectInput{
+			ContentLength: &opt.Size,
+		}
+		if opt.HasContentType {
+			input.ContentType = &opt.ContentType
+		}
+		r, _, err = bucket.PutObjectRequest(rp, input)
+	default:
+		err = fmt.Errorf("method %s: %w", method, types.ErrNotSupported)
+		return "", types.NewError(s, "Reach", err, path)
+	}
 	if err != nil {
 		err = handleQingStorError(err)
-		return "", fmt.Errorf(errorMessage, s, path, err)
+		return "", types.NewError(s, "Reach", err, path)
 	}
 	if err = r.Build(); err != nil {
 		err = handleQingStorError(err)
-		return "", fmt.Errorf(errorMessage, s, path, err)
+		return "", types.NewError(s, "Reach", err, path)
 	}
 
-	expire := 3600
-	if opt.HasExpire {
-		expire = opt.Expire
-	}
-	if err = r.SignQuery(expire); err != nil {
+	if err = r.SignQuery(opt.Expire); err != nil {
 		err = handleQingStorError(err)
-		return "", fmt.Errorf(errorMessage, s, path, err)
+		return "", types.NewError(s, "Reach", err, path)
 	}
 	return r.HTTPRequest.URL.String(), nil
 }
 
 // List implements Storager.List
 func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List [%s]: %w"
-
 	opt, _ := parseStoragePairList(pairs...)
 
 	marker := ""
@@ -238,7 +280,7 @@ func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 		})
 		if err != nil {
 			err = handleQingStorError(err)
-			return fmt.Errorf(errorMessage, s, path, err)
+			return types.NewError(s, "List", err, path)
 		}
 
 		for _, v := range output.CommonPrefixes {
@@ -304,27 +346,55 @@ func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 
 // Read implements Storager.Read
 func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
-	const errorMessage = "%s Read [%s]: %w"
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
 
 	input := &service.GetObjectInput{}
+	if opt.HasIfMatch {
+		input.IfMatch = &opt.IfMatch
+	}
+	if opt.HasIfNoneMatch {
+		input.IfNoneMatch = &opt.IfNoneMatch
+	}
+	if opt.HasIfModifiedSince {
+		input.IfModifiedSince = &opt.IfModifiedSince
+	}
+	if opt.HasIfUnmodifiedSince {
+		input.IfUnmodifiedSince = &opt.IfUnmodifiedSince
+	}
+	if opt.HasOffset || opt.HasSize {
+		input.Range = service.String(httputil.FormatRange(opt.Offset, opt.Size))
+	}
 
 	rp := s.getAbsPath(path)
 
 	output, err := s.bucket.GetObject(rp, input)
 	if err != nil {
 		err = handleQingStorError(err)
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	// qingstor sdk treats 304 as a valid response.
+	if service.IntValue(output.StatusCode) == http.StatusNotModified {
+		return nil, types.NewError(s, "Read", types.ErrPreconditionFailed, path)
 	}
 	return output.Body, nil
 }
 
 // WriteFile implements Storager.WriteFile
 func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Write [%s]: %w"
-
 	opt, err := parseStoragePairWrite(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
+	}
+
+	if opt.HasDetectContentType && opt.DetectContentType && !opt.HasContentType {
+		opt.ContentType, r, err = iowrap.SniffContentType(r)
+		if err != nil {
+			return types.NewError(s, "Write", err, path)
+		}
+		opt.HasContentType = true
 	}
 
 	input := &service.PutObjectInput{
@@ -337,24 +407,35 @@ func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err err
 	if opt.HasStorageClass {
 		input.XQSStorageClass = &opt.StorageClass
 	}
+	if opt.HasUserMetadata {
+		m := encodeUserMetadata(opt.UserMetadata)
+		input.XQSMetaData = &m
+	}
+	if opt.HasContentType {
+		input.ContentType = &opt.ContentType
+	}
+	if opt.HasContentEncoding {
+		input.ContentEncoding = &opt.ContentEncoding
+	}
+	if opt.HasCacheControl {
+		input.CacheControl = &opt.CacheControl
+	}
 
 	rp := s.getAbsPath(path)
 
 	_, err = s.bucket.PutObject(rp, input)
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
 	}
 	return nil
 }
 
 // ListSegments implements Storager.ListSegments
 func (s *Storage) ListSegments(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s ListSegments [%s]: %w"
-
 	opt, err := parseStoragePairListSegments(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "ListSegments", err, path)
 	}
 
 	keyMarker := ""
@@ -373,7 +454,7 @@ func (s *Storage) ListSegments(path string, pairs ...*types.Pair) (err error) {
 		})
 		if err != nil {
 			err = handleQingStorError(err)
-			return fmt.Errorf(errorMessage, s, path, err)
+			return types.NewError(s, "ListSegments", err, path)
 		}
 
 		for _, v := range output.Uploads {
@@ -403,11 +484,9 @@ func (s *Storage) ListSegments(path string, pairs ...*types.Pair) (err error) {
 
 // InitSegment implements Storager.InitSegment
 func (s *Storage) InitSegment(path string, pairs ...*types.Pair) (id string, err error) {
-	const errorMessage = "%s InitSegment [%s]: %w"
-
 	opt, err := parseStoragePairInitSegment(pairs...)
 	if err != nil {
-		return "", fmt.Errorf(errorMessage, s, path, err)
+		return "", types.NewError(s, "InitSegment", err, path)
 	}
 
 	input := &service.InitiateMultipartUploadInput{}
@@ -417,7 +496,7 @@ func (s *Storage) InitSegment(path string, pairs ...*types.Pair) (id string, err
 	output, err := s.bucket.InitiateMultipartUpload(rp, input)
 	if err != nil {
 		err = handleQingStorError(err)
-		return "", fmt.Errorf(errorMessage, s, path, err)
+		return "", types.NewError(s, "InitSegment", err, path)
 	}
 
 	id = *output.UploadID
@@ -430,18 +509,16 @@ func (s *Storage) InitSegment(path string, pairs ...*types.Pair) (id string, err
 
 // WriteSegment implements Storager.WriteSegment
 func (s *Storage) WriteSegment(id string, offset, size int64, r io.Reader, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s WriteSegment [%s]: %w"
-
 	s.segmentLock.RLock()
 	seg, ok := s.segments[id]
 	if !ok {
-		return fmt.Errorf(errorMessage, s, id, segment.ErrSegmentNotInitiated)
+		return types.NewError(s, "WriteSegment", segment.ErrSegmentNotInitiated, id)
 	}
 	s.segmentLock.RUnlock()
 
 	p, err := seg.InsertPart(offset, size)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, id, err)
+		return types.NewError(s, "WriteSegment", err, id)
 	}
 
 	rp := s.getAbsPath(seg.Path)
@@ -454,25 +531,23 @@ func (s *Storage) WriteSegment(id string, offset, size int64, r io.Reader, pairs
 	})
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, id, err)
+		return types.NewError(s, "WriteSegment", err, id)
 	}
 	return
 }
 
 // CompleteSegment implements Storager.CompleteSegment
 func (s *Storage) CompleteSegment(id string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s CompleteSegment [%s]: %w"
-
 	s.segmentLock.RLock()
 	seg, ok := s.segments[id]
 	if !ok {
-		return fmt.Errorf(errorMessage, s, id, segment.ErrSegmentNotInitiated)
+		return types.NewError(s, "CompleteSegment", segment.ErrSegmentNotInitiated, id)
 	}
 	s.segmentLock.RUnlock()
 
 	err = seg.ValidateParts()
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, id, err)
+		return types.NewError(s, "CompleteSegment", err, id)
 	}
 
 	parts := seg.SortedParts()
@@ -493,7 +568,7 @@ func (s *Storage) CompleteSegment(id string, pairs ...*types.Pair) (err error) {
 	})
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, id, err)
+		return types.NewError(s, "CompleteSegment", err, id)
 	}
 
 	s.segmentLock.Lock()
@@ -504,12 +579,10 @@ func (s *Storage) CompleteSegment(id string, pairs ...*types.Pair) (err error) {
 
 // AbortSegment implements Storager.AbortSegment
 func (s *Storage) AbortSegment(id string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s AbortSegment [%s]: %w"
-
 	s.segmentLock.RLock()
 	seg, ok := s.segments[id]
 	if !ok {
-		return fmt.Errorf(errorMessage, s, id, segment.ErrSegmentNotInitiated)
+		return types.NewError(s, "AbortSegment", segment.ErrSegmentNotInitiated, id)
 	}
 	s.segmentLock.RUnlock()
 
@@ -520,7 +593,7 @@ func (s *Storage) AbortSegment(id string, pairs ...*types.Pair) (err error) {
 	})
 	if err != nil {
 		err = handleQingStorError(err)
-		return fmt.Errorf(errorMessage, s, id, err)
+		return types.NewError(s, "AbortSegment", err, id)
 	}
 
 	s.segmentLock.Lock()
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/pengsrc/go-shared/convert"
 	"github.com/stretchr/testify/assert"
+	qsconfig "github.com/yunify/qingstor-sdk-go/v3/config"
 	qerror "github.com/yunify/qingstor-sdk-go/v3/request/errors"
 	"github.com/yunify/qingstor-sdk-go/v3/service"
 
@@ -583,6 +586,18 @@ func TestStorage_Read(t *testing.T) {
 			},
 			false, nil,
 		},
+		{
+			"not modified",
+			"test_src",
+			func(inputPath string, input *service.GetObjectInput) (*service.GetObjectOutput, error) {
+				assert.Equal(t, "test_src", inputPath)
+				assert.NotNil(t, input.IfModifiedSince)
+				return &service.GetObjectOutput{
+					StatusCode: service.Int(http.StatusNotModified),
+				}, nil
+			},
+			true, types.ErrPreconditionFailed,
+		},
 	}
 
 	for _, v := range tests {
@@ -592,7 +607,7 @@ func TestStorage_Read(t *testing.T) {
 			bucket: mockBucket,
 		}
 
-		r, err := client.Read(v.path)
+		r, err := client.Read(v.path, pairs.WithIfModifiedSince(time.Now()))
 		if v.hasError {
 			assert.Error(t, err)
 			assert.Nil(t, r)
@@ -708,6 +723,59 @@ func TestStorage_Write(t *testing.T) {
 	}
 }
 
+func TestStorage_WriteWithContentType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBucket := NewMockBucket(ctrl)
+
+	content := "<html><body>hello</body></html>"
+
+	tests := []struct {
+		name  string
+		pairs []*types.Pair
+		want  string
+	}{
+		{
+			"content type",
+			[]*types.Pair{pairs.WithContentType("text/css")},
+			"text/css",
+		},
+		{
+			"detect content type",
+			[]*types.Pair{pairs.WithDetectContentType(true)},
+			"text/html; charset=utf-8",
+		},
+		{
+			"content type takes precedence over detection",
+			[]*types.Pair{pairs.WithContentType("text/css"), pairs.WithDetectContentType(true)},
+			"text/css",
+		},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			mockBucket.EXPECT().PutObject(gomock.Any(), gomock.Any()).DoAndReturn(func(inputPath string, input *service.PutObjectInput) (*service.PutObjectOutput, error) {
+				assert.Equal(t, v.want, service.StringValue(input.ContentType))
+				assert.Equal(t, "no-cache", service.StringValue(input.CacheControl))
+
+				b, err := ioutil.ReadAll(input.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(b))
+				return nil, nil
+			})
+
+			client := Storage{
+				bucket: mockBucket,
+			}
+
+			ps := append(v.pairs, pairs.WithSize(int64(len(content))), pairs.WithCacheControl("no-cache"))
+			err := client.Write("test", strings.NewReader(content), ps...)
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestStorage_WriteSegment(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -855,3 +923,83 @@ func TestStorage_ListSegments(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_Reach(t *testing.T) {
+	cfg, err := qsconfig.New("test_access_key", "test_secret_key")
+	assert.NoError(t, err)
+	srv, err := service.Init(cfg)
+	assert.NoError(t, err)
+	bucket, err := srv.Bucket("test_bucket", "test_zone")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		pairs   []*types.Pair
+		want    []string
+		wantErr error
+	}{
+		{
+			"get",
+			"test_get",
+			[]*types.Pair{pairs.WithExpire(100)},
+			[]string{"/test_get?", "signature="},
+			nil,
+		},
+		{
+			"put",
+			"test_put",
+			[]*types.Pair{pairs.WithExpire(100), pairs.WithMethod(http.MethodPut), pairs.WithContentType("text/plain")},
+			[]string{"/test_put?", "signature="},
+			nil,
+		},
+		{
+			"put segment",
+			"test_put",
+			[]*types.Pair{
+				pairs.WithExpire(100), pairs.WithMethod(http.MethodPut),
+				pairs.WithSegmentId("test_id"), pairs.WithOffset(0), pairs.WithSize(1),
+			},
+			[]string{"/test_segment?", "part_number=0", "upload_id=test_id", "signature="},
+			nil,
+		},
+		{
+			"put segment not initiated",
+			"test_put",
+			[]*types.Pair{
+				pairs.WithExpire(100), pairs.WithMethod(http.MethodPut),
+				pairs.WithSegmentId("not_exist"), pairs.WithOffset(0), pairs.WithSize(1),
+			},
+			nil,
+			segment.ErrSegmentNotInitiated,
+		},
+		{
+			"unsupported method",
+			"test_delete",
+			[]*types.Pair{pairs.WithExpire(100), pairs.WithMethod(http.MethodDelete)},
+			nil,
+			types.ErrNotSupported,
+		},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			client := Storage{
+				bucket: bucket,
+				segments: map[string]*segment.Segment{
+					"test_id": segment.NewSegment("test_segment", "test_id", 1),
+				},
+			}
+
+			url, err := client.Reach(v.path, v.pairs...)
+			if v.wantErr != nil {
+				assert.True(t, errors.Is(err, v.wantErr))
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range v.want {
+				assert.Contains(t, url, s)
+			}
+		})
+	}
+}
@@ -2,12 +2,16 @@ package qingstor
 
 import (
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/Xuanwo/storage/pkg/segment"
 	"github.com/Xuanwo/storage/types"
+	"github.com/yunify/qingstor-sdk-go/v3/request"
 	qserror "github.com/yunify/qingstor-sdk-go/v3/request/errors"
+	"github.com/yunify/qingstor-sdk-go/v3/service"
 )
 
 // bucketNameRegexp is the bucket name regexp, which indicates:
@@ -40,22 +44,41 @@ func handleQingStorError(err error) error {
 		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
 	}
 
-	if e.Code == "" {
-		switch e.StatusCode {
-		case 404:
-			return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
-		default:
-			return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
-		}
-	}
-
 	switch e.Code {
 	case "permission_denied":
 		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
-	case "object_not_exists":
+	case "object_not_exists", "bucket_not_exists":
 		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
-	case "invalid_access_key_id":
+	case "invalid_access_key_id", "invalid_bucket_name":
 		return fmt.Errorf("%w: %v", types.ErrConfigIncorrect, err)
+	case "bucket_already_exists":
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	case "bucket_not_empty":
+		return fmt.Errorf("%w: %v", types.ErrDirNotEmpty, err)
+	case "invalid_object_name", "object_name_too_long":
+		return fmt.Errorf("%w: %v", types.ErrInvalidPath, err)
+	case "precondition_failed":
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case "bucket_quota_exceeded", "too_many_buckets":
+		return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+	case "too_many_requests":
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case "internal_error", "service_unavailable":
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+
+	// HEAD requests don't have body, so we can only handle them via status code.
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
 	default:
 		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
 	}
@@ -68,6 +91,54 @@ func convertUnixTimestampToTime(v int) time.Time {
 	return time.Unix(int64(v), 0)
 }
 
+// userMetadataPrefix is the header prefix of user metadata in qingstor.
+const userMetadataPrefix = "x-qs-meta-"
+
+// encodeUserMetadata will add header prefix for user metadata, because qingstor sdk sends
+// metadata's keys as headers directly.
+func encodeUserMetadata(m map[string]string) map[string]string {
+	x := make(map[string]string, len(m))
+	for k, v := range m {
+		x[userMetadataPrefix+strings.ToLower(k)] = v
+	}
+	return x
+}
+
+// decodeUserMetadata will extract user metadata from response headers, keys will be in lower case.
+func decodeUserMetadata(h http.Header) map[string]string {
+	x := make(map[string]string)
+	for k, v := range h {
+		k = strings.ToLower(k)
+		if !strings.HasPrefix(k, userMetadataPrefix) || len(v) == 0 {
+			continue
+		}
+		x[strings.TrimPrefix(k, userMetadataPrefix)] = v[0]
+	}
+	return x
+}
+
+// headObject will head an object and return response headers as well.
+//
+// qingstor sdk doesn't unpack user metadata from response headers, so we need to send request
+// by ourselves. Response headers will be nil if bucket is not a *service.Bucket.
+func (s *Storage) headObject(key string, input *service.HeadObjectInput) (*service.HeadObjectOutput, http.Header, error) {
+	bucket, ok := s.bucket.(*service.Bucket)
+	if !ok {
+		output, err := s.bucket.HeadObject(key, input)
+		return output, nil, err
+	}
+
+	r, output, err := bucket.HeadObjectRequest(key, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = r.Send()
+	if err != nil {
+		return nil, nil, err
+	}
+	return output, r.HTTPResponse.Header, nil
+}
+
 // ParseNamespace will parse qingstor namespace.
 func ParseNamespace(s string) (bucketName, prefix string) {
 	x := strings.SplitN(s, "/", 2)
@@ -79,3 +150,32 @@ func ParseNamespace(s string) (bucketName, prefix string) {
 	}
 	return x[0], x[1]
 }
+
+// uploadMultipartRequest will create a request to upload a part of segment.
+//
+// The part will be inserted into segment, so that it will be completed by CompleteSegment.
+func (s *Storage) uploadMultipartRequest(id string, offset, size int64) (*request.Request, error) {
+	s.segmentLock.RLock()
+	seg, ok := s.segments[id]
+	s.segmentLock.RUnlock()
+	if !ok {
+		return nil, segment.ErrSegmentNotInitiated
+	}
+
+	p, err := seg.InsertPart(offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := s.bucket.(*service.Bucket)
+
+	r, _, err := bucket.UploadMultipartRequest(s.getAbsPath(seg.Path), &service.UploadMultipartInput{
+		PartNumber:    &p.Index,
+		UploadID:      &seg.ID,
+		ContentLength: &size,
+	})
+	if err != nil {
+		return nil, handleQingStorError(err)
+	}
+	return r, nil
+}
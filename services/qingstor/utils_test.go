@@ -2,6 +2,7 @@ package qingstor
 
 import (
 	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/Xuanwo/storage/types"
@@ -165,6 +166,50 @@ func TestHandleQingStorError(t *testing.T) {
 				},
 				types.ErrConfigIncorrect,
 			},
+			{
+				"bucket_already_exists",
+				&qserror.QingStorError{
+					StatusCode:   409,
+					Code:         "bucket_already_exists",
+					Message:      "",
+					RequestID:    "",
+					ReferenceURL: "",
+				},
+				types.ErrObjectAlreadyExist,
+			},
+			{
+				"too_many_requests",
+				&qserror.QingStorError{
+					StatusCode:   429,
+					Code:         "too_many_requests",
+					Message:      "",
+					RequestID:    "",
+					ReferenceURL: "",
+				},
+				types.ErrRateLimited,
+			},
+			{
+				"precondition failed without code",
+				&qserror.QingStorError{
+					StatusCode:   412,
+					Code:         "",
+					Message:      "",
+					RequestID:    "",
+					ReferenceURL: "",
+				},
+				types.ErrPreconditionFailed,
+			},
+			{
+				"service unavailable without code",
+				&qserror.QingStorError{
+					StatusCode:   503,
+					Code:         "",
+					Message:      "",
+					RequestID:    "",
+					ReferenceURL: "",
+				},
+				types.ErrServiceUnavailable,
+			},
 			{
 				"not handled",
 				&qserror.QingStorError{
@@ -185,3 +230,20 @@ func TestHandleQingStorError(t *testing.T) {
 		}
 	}
 }
+
+func TestUserMetadata(t *testing.T) {
+	m := map[string]string{"Foo": "bar", "abc": "def"}
+
+	encoded := encodeUserMetadata(m)
+	assert.Equal(t, map[string]string{
+		"x-qs-meta-foo": "bar",
+		"x-qs-meta-abc": "def",
+	}, encoded)
+
+	h := http.Header{}
+	for k, v := range encoded {
+		h.Set(k, v)
+	}
+	h.Set("Content-Type", "text/plain")
+	assert.Equal(t, map[string]string{"foo": "bar", "abc": "def"}, decodeUserMetadata(h))
+}
@@ -0,0 +1,10 @@
+/*
+Package replication provided a storager which replicates objects into several target storagers.
+
+Write, Delete, Copy and Move will be fanned out to all replicas concurrently, and succeed once write quorum
+replicas succeed. Read, Stat, List and Metadata will be served by the first healthy replica in order.
+
+Replications failed on some replicas will be recorded into a queue on local fs, so that they can be replayed
+by Repair later. Every record will sync the object from a replica which succeeded to the one which failed.
+*/
+package replication
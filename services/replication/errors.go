@@ -0,0 +1,10 @@
+package replication
+
+import (
+	"errors"
+)
+
+var (
+	// ErrQuorumNotReached will be returned while less than write quorum replicas succeed.
+	ErrQuorumNotReached = errors.New("quorum not reached")
+)
@@ -0,0 +1,77 @@
+// Code generated by go generate via internal/cmd/meta; DO NOT EDIT.
+package replication
+
+import (
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/segment"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+var _ credential.Provider
+var _ endpoint.Provider
+var _ segment.Segment
+var _ storage.Storager
+var _ time.Duration
+
+// Type is the type for replication
+const Type = "replication"
+
+var allowedStoragePairs = map[string]map[string]struct{}{
+	"init": {
+		"queue_path":   struct{}{},
+		"storagers":    struct{}{},
+		"write_quorum": struct{}{},
+	},
+}
+
+var allowedServicePairs = map[string]map[string]struct{}{}
+
+type pairStorageInit struct {
+	HasQueuePath   bool
+	QueuePath      string
+	HasStoragers   bool
+	Storagers      []storage.Storager
+	HasWriteQuorum bool
+	WriteQuorum    int
+}
+
+func parseStoragePairInit(opts ...*types.Pair) (*pairStorageInit, error) {
+	result := &pairStorageInit{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["init"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["init"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.QueuePath]
+	if ok {
+		result.HasQueuePath = true
+		result.QueuePath = v.(string)
+	}
+	v, ok = values[pairs.Storagers]
+	if !ok {
+		return nil, types.NewErrPairRequired(pairs.Storagers)
+	}
+	if ok {
+		result.HasStoragers = true
+		result.Storagers = v.([]storage.Storager)
+	}
+	v, ok = values[pairs.WriteQuorum]
+	if ok {
+		result.HasWriteQuorum = true
+		result.WriteQuorum = v.(int)
+	}
+	return result, nil
+}
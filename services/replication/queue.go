@@ -0,0 +1,116 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// record is a failed replication which needs to be repaired.
+type record struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	// Source is the index of a replica which succeeded.
+	Source int `json:"source"`
+	// Target is the index of the replica which failed.
+	Target    int       `json:"target"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newRecord(op, path string, source, target int, err error) *record {
+	return &record{
+		Op:        op,
+		Path:      path,
+		Source:    source,
+		Target:    target,
+		Error:     err.Error(),
+		CreatedAt: time.Now(),
+	}
+}
+
+// queue will store every record as a json file in a local dir, file names are ordered by created time.
+type queue struct {
+	store storage.Storager
+	seq   uint64
+}
+
+func newQueue(path string) (q *queue, err error) {
+	store := fs.New()
+	err = store.Init(ps.WithWorkDir(path))
+	if err != nil {
+		return nil, err
+	}
+	return &queue{store: store}, nil
+}
+
+func (q *queue) push(rec *record) (err error) {
+	content, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%019d-%010d.json", rec.CreatedAt.UnixNano(), atomic.AddUint64(&q.seq, 1))
+	return q.store.Write(name, bytes.NewReader(content), ps.WithSize(int64(len(content))))
+}
+
+// walk will call fn on records in order, and remove records for which fn succeeded.
+//
+// walk will go on after fn failed, and the first error will be returned.
+func (q *queue) walk(fn func(rec *record) error) (err error) {
+	names := make([]string, 0)
+	err = q.store.List("", ps.WithFileFunc(func(o *types.Object) {
+		names = append(names, o.Name)
+	}))
+	// Queue dir will not be created until the first record is pushed.
+	if errors.Is(err, types.ErrObjectNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	var firstErr error
+	for _, name := range names {
+		err = q.handle(name, fn)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (q *queue) handle(name string, fn func(rec *record) error) (err error) {
+	r, err := q.store.Read(name)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	rec := &record{}
+	err = json.Unmarshal(content, rec)
+	if err != nil {
+		return fmt.Errorf("record %s: %w", name, err)
+	}
+
+	err = fn(rec)
+	if err != nil {
+		return fmt.Errorf("record %s: %w", name, err)
+	}
+	return q.store.Delete(name)
+}
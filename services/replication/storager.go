@@ -0,0 +1,310 @@
+package replication
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// Storage is the replication storager.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	// replicas are ordered by read priority.
+	replicas []storage.Storager
+	quorum   int
+
+	// queue will be nil if queue_path is not set.
+	queue *queue
+}
+
+// New will create a replication storager.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager replication {Replicas: %v, Quorum: %d}", s.replicas, s.quorum)
+}
+
+// Init implements Storager.Init
+//
+// Write quorum defaults to the number of replicas, queue_path is required if it's less than that.
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	if len(opt.Storagers) == 0 {
+		return types.NewError(s, "Init", types.NewErrPairRequired(ps.Storagers))
+	}
+	s.replicas = opt.Storagers
+
+	s.quorum = len(s.replicas)
+	if opt.HasWriteQuorum {
+		if opt.WriteQuorum < 1 || opt.WriteQuorum > len(s.replicas) {
+			err = fmt.Errorf("%w: write quorum %d with %d replicas",
+				types.ErrConfigIncorrect, opt.WriteQuorum, len(s.replicas))
+			return types.NewError(s, "Init", err)
+		}
+		s.quorum = opt.WriteQuorum
+	}
+
+	if opt.HasQueuePath {
+		s.queue, err = newQueue(opt.QueuePath)
+		if err != nil {
+			return types.NewError(s, "Init", err)
+		}
+	}
+	if s.queue == nil && s.quorum < len(s.replicas) {
+		return types.NewError(s, "Init", types.NewErrPairRequired(ps.QueuePath))
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+//
+// Metadata of the first healthy replica will be returned.
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	err = s.lookup(func(store storage.Storager) (err error) {
+		m, err = store.Metadata()
+		return
+	})
+	if err != nil {
+		return m, types.NewError(s, "Metadata", err)
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+//
+// Objects will be listed from the first healthy replica. If a replica fails after some objects have been
+// listed, they could be listed again from the next replica.
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	err = s.lookup(func(store storage.Storager) error {
+		return store.List(path, pairs...)
+	})
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	return nil
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	err = s.lookup(func(store storage.Storager) (err error) {
+		r, err = store.Read(path, pairs...)
+		return
+	})
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	err = s.lookup(func(store storage.Storager) (err error) {
+		o, err = store.Stat(path, pairs...)
+		return
+	})
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	return o, nil
+}
+
+// Write implements Storager.Write
+//
+// Content will be streamed to all replicas at the same time, so the slowest replica decides the speed.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	errs, err := s.writeAll(path, r, pairs)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	err = s.settle("Write", errs, path)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	return nil
+}
+
+// Delete implements Storager.Delete
+//
+// Replicas which don't have the object will be treated as succeeded, ErrObjectNotExist will only be returned if
+// the object doesn't exist in all replicas.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	errs := s.each(func(store storage.Storager) error {
+		return store.Delete(path, pairs...)
+	})
+
+	notExist := 0
+	for _, v := range errs {
+		if errors.Is(v, types.ErrObjectNotExist) {
+			notExist++
+		}
+	}
+	if notExist == len(errs) {
+		return types.NewError(s, "Delete", errs[0], path)
+	}
+	for i, v := range errs {
+		if errors.Is(v, types.ErrObjectNotExist) {
+			errs[i] = nil
+		}
+	}
+
+	err = s.settle("Delete", errs, path)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// Copy implements Storager.Copy
+//
+// Replicas which don't implement Copier will be treated as failed.
+func (s *Storage) Copy(src, dst string, pairs ...*types.Pair) (err error) {
+	errs := s.each(func(store storage.Storager) error {
+		c, ok := store.(storage.Copier)
+		if !ok {
+			return types.ErrNotSupported
+		}
+		return c.Copy(src, dst, pairs...)
+	})
+
+	err = s.settle("Copy", errs, dst)
+	if err != nil {
+		return types.NewError(s, "Copy", err, src, dst)
+	}
+	return nil
+}
+
+// Move implements Storager.Move
+//
+// Replicas which don't implement Mover will be treated as failed.
+func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
+	errs := s.each(func(store storage.Storager) error {
+		m, ok := store.(storage.Mover)
+		if !ok {
+			return types.ErrNotSupported
+		}
+		return m.Move(src, dst, pairs...)
+	})
+
+	err = s.settle("Move", errs, src, dst)
+	if err != nil {
+		return types.NewError(s, "Move", err, src, dst)
+	}
+	return nil
+}
+
+// Repair will replay failed replications recorded in queue, records will be removed after repaired.
+//
+// Repair will go on after a record failed, and the first error will be returned.
+func (s *Storage) Repair() (err error) {
+	if s.queue == nil {
+		return nil
+	}
+
+	err = s.queue.walk(s.repair)
+	if err != nil {
+		return types.NewError(s, "Repair", err)
+	}
+	return nil
+}
+
+// repair will sync rec.Path from source replica to target replica: write it if exists in source, or delete it
+// if not.
+func (s *Storage) repair(rec *record) (err error) {
+	if !s.valid(rec.Source) || !s.valid(rec.Target) {
+		return fmt.Errorf("%w: record %s refers to replica out of range", types.ErrConfigIncorrect, rec.Path)
+	}
+	src, dst := s.replicas[rec.Source], s.replicas[rec.Target]
+
+	_, err = storageutil.CopyObject(src, rec.Path, dst, rec.Path)
+	if !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+
+	// Object has been deleted from source.
+	err = dst.Delete(rec.Path)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
+
+// settle will check results of a fanned out operation against write quorum, and record paths into queue for
+// every failed replica if at least one replica succeeded.
+func (s *Storage) settle(op string, errs []error, paths ...string) (err error) {
+	source, succeeded := -1, 0
+	var firstErr error
+	for i, v := range errs {
+		if v != nil {
+			if firstErr == nil {
+				firstErr = v
+			}
+			continue
+		}
+		if source < 0 {
+			source = i
+		}
+		succeeded++
+	}
+	if firstErr == nil {
+		return nil
+	}
+	// No replica has been changed, there is nothing to repair.
+	if succeeded == 0 {
+		return firstErr
+	}
+
+	if s.queue != nil {
+		for i, v := range errs {
+			if v == nil {
+				continue
+			}
+			for _, p := range paths {
+				err = s.queue.push(newRecord(op, p, source, i, v))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if succeeded < s.quorum {
+		return fmt.Errorf("%w: %d of %d replicas succeeded: %v", ErrQuorumNotReached, succeeded, len(errs), firstErr)
+	}
+	return nil
+}
+
+// lookup will call fn on replicas in order until fn succeeds.
+//
+// ErrObjectNotExist will only be returned if all replicas returned it, other errors take precedence.
+func (s *Storage) lookup(fn func(store storage.Storager) error) (err error) {
+	var lastErr error
+	for _, store := range s.replicas {
+		err = fn(store)
+		if err == nil {
+			return nil
+		}
+		if lastErr == nil || !errors.Is(err, types.ErrObjectNotExist) {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (s *Storage) valid(idx int) bool {
+	return idx >= 0 && idx < len(s.replicas)
+}
@@ -0,0 +1,225 @@
+package replication
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+var errBroken = errors.New("broken")
+
+// brokenStorager will fail all operations while broken is true.
+type brokenStorager struct {
+	*fs.Storage
+
+	broken bool
+}
+
+func (s *brokenStorager) Read(path string, ps ...*types.Pair) (io.ReadCloser, error) {
+	if s.broken {
+		return nil, errBroken
+	}
+	return s.Storage.Read(path, ps...)
+}
+
+func (s *brokenStorager) Write(path string, r io.Reader, ps ...*types.Pair) error {
+	if s.broken {
+		return errBroken
+	}
+	return s.Storage.Write(path, r, ps...)
+}
+
+func (s *brokenStorager) Delete(path string, ps ...*types.Pair) error {
+	if s.broken {
+		return errBroken
+	}
+	return s.Storage.Delete(path, ps...)
+}
+
+func (s *brokenStorager) Move(src, dst string, ps ...*types.Pair) error {
+	if s.broken {
+		return errBroken
+	}
+	return s.Storage.Move(src, dst, ps...)
+}
+
+func newTestReplica(t *testing.T) (string, *brokenStorager) {
+	dir, err := ioutil.TempDir("", "replication")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, &brokenStorager{Storage: store}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func queueLen(t *testing.T, dir string) int {
+	fi, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(fi)
+}
+
+func TestStorage(t *testing.T) {
+	dir1, replica1 := newTestReplica(t)
+	defer os.RemoveAll(dir1)
+	dir2, replica2 := newTestReplica(t)
+	defer os.RemoveAll(dir2)
+	dir3, replica3 := newTestReplica(t)
+	defer os.RemoveAll(dir3)
+
+	queueDir, err := ioutil.TempDir("", "replication")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(queueDir)
+
+	store := New()
+	err = store.Init(
+		pairs.WithStoragers([]storage.Storager{replica1, replica2, replica3}),
+		pairs.WithWriteQuorum(2),
+		pairs.WithQueuePath(queueDir),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("write", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), 1024*1024)
+		err := store.Write("a", bytes.NewReader(content), pairs.WithSize(int64(len(content))))
+		assert.NoError(t, err)
+		for _, dir := range []string{dir1, dir2, dir3} {
+			assert.Equal(t, string(content), readFile(t, dir, "a"))
+		}
+		assert.Equal(t, 0, queueLen(t, queueDir))
+	})
+
+	t.Run("quorum", func(t *testing.T) {
+		replica2.broken = true
+		err := store.Write("b", bytes.NewReader([]byte("b")))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, queueLen(t, queueDir))
+
+		replica3.broken = true
+		err = store.Write("c", bytes.NewReader([]byte("c")))
+		assert.True(t, errors.Is(err, ErrQuorumNotReached))
+		assert.Equal(t, 3, queueLen(t, queueDir))
+
+		replica1.broken = true
+		err = store.Write("d", bytes.NewReader([]byte("d")))
+		assert.True(t, errors.Is(err, errBroken))
+		assert.Equal(t, 3, queueLen(t, queueDir))
+
+		replica1.broken, replica2.broken, replica3.broken = false, false, false
+	})
+
+	t.Run("read", func(t *testing.T) {
+		replica1.broken = true
+
+		r, err := store.Read("b")
+		assert.NoError(t, err)
+		r.Close()
+
+		o, err := store.Stat("b")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), o.Size)
+
+		// Errors other than ErrObjectNotExist take precedence.
+		_, err = store.Read("not_exist")
+		assert.True(t, errors.Is(err, errBroken))
+
+		replica1.broken = false
+		_, err = store.Read("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		err := store.Repair()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, queueLen(t, queueDir))
+
+		for _, dir := range []string{dir1, dir2, dir3} {
+			assert.Equal(t, "b", readFile(t, dir, "b"))
+			assert.Equal(t, "c", readFile(t, dir, "c"))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := os.Remove(filepath.Join(dir1, "b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		replica3.broken = true
+
+		err = store.Delete("b")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, queueLen(t, queueDir))
+
+		replica3.broken = false
+		err = store.Repair()
+		assert.NoError(t, err)
+		assert.Equal(t, "", readFile(t, dir3, "b"))
+
+		err = store.Delete("b")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		replica2.broken = true
+		err := store.Move("a", "e")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, queueLen(t, queueDir))
+
+		replica2.broken = false
+		err = store.Repair()
+		assert.NoError(t, err)
+		for _, dir := range []string{dir1, dir2, dir3} {
+			assert.Equal(t, "", readFile(t, dir, "a"))
+			assert.NotEqual(t, "", readFile(t, dir, "e"))
+		}
+	})
+}
+
+func TestStorage_Init(t *testing.T) {
+	dir, replica := newTestReplica(t)
+	defer os.RemoveAll(dir)
+
+	err := New().Init(pairs.WithStoragers([]storage.Storager{}))
+	assert.True(t, errors.Is(err, types.ErrPairRequired))
+
+	err = New().Init(pairs.WithStoragers([]storage.Storager{replica}), pairs.WithWriteQuorum(2))
+	assert.True(t, errors.Is(err, types.ErrConfigIncorrect))
+
+	err = New().Init(pairs.WithStoragers([]storage.Storager{replica, replica}), pairs.WithWriteQuorum(1))
+	assert.True(t, errors.Is(err, types.ErrPairRequired))
+}
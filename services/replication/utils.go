@@ -0,0 +1,93 @@
+package replication
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+)
+
+// errAllClosed will be returned by fanWriter while all replicas have stopped reading.
+var errAllClosed = errors.New("all replicas closed")
+
+// each will call fn on all replicas concurrently, and return their errors in replica order.
+func (s *Storage) each(fn func(store storage.Storager) error) []error {
+	errs := make([]error, len(s.replicas))
+
+	var wg sync.WaitGroup
+	for i, store := range s.replicas {
+		wg.Add(1)
+		go func(i int, store storage.Storager) {
+			defer wg.Done()
+			errs[i] = fn(store)
+		}(i, store)
+	}
+	wg.Wait()
+	return errs
+}
+
+// writeAll will stream r to all replicas via pipes, and return their errors in replica order.
+//
+// err will only be returned if r failed, in which case all replicas are treated as failed.
+func (s *Storage) writeAll(path string, r io.Reader, pairs []*types.Pair) (errs []error, err error) {
+	pws := make([]*io.PipeWriter, len(s.replicas))
+	prs := make([]*io.PipeReader, len(s.replicas))
+	for i := range s.replicas {
+		prs[i], pws[i] = io.Pipe()
+	}
+
+	w := &fanWriter{ws: make([]*io.PipeWriter, len(pws))}
+	copy(w.ws, pws)
+
+	errs = make([]error, len(s.replicas))
+	var wg sync.WaitGroup
+	for i, store := range s.replicas {
+		wg.Add(1)
+		go func(i int, store storage.Storager) {
+			defer wg.Done()
+			errs[i] = store.Write(path, prs[i], pairs...)
+			// Replica could return without reading all content, close the pipe so that writes will not block.
+			prs[i].Close()
+		}(i, store)
+	}
+
+	_, err = io.Copy(w, r)
+	if err == errAllClosed {
+		err = nil
+	}
+	for _, pw := range pws {
+		// CloseWithError(nil) will let replicas read io.EOF.
+		pw.CloseWithError(err)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// fanWriter will write content to all pipes, and drop pipes which have been closed by replicas.
+type fanWriter struct {
+	ws []*io.PipeWriter
+}
+
+func (w *fanWriter) Write(p []byte) (n int, err error) {
+	alive := 0
+	for i, pw := range w.ws {
+		if pw == nil {
+			continue
+		}
+		_, err = pw.Write(p)
+		if err != nil {
+			w.ws[i] = nil
+			continue
+		}
+		alive++
+	}
+	if alive == 0 {
+		return 0, errAllClosed
+	}
+	return len(p), nil
+}
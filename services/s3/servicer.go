@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -21,11 +22,9 @@ type Service struct {
 
 // New will create a new s3 service.
 func New(pairs ...*types.Pair) (s *Service, err error) {
-	const errorMessage = "%s New: %w"
-
 	opt, err := parseServicePairInit(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	cfg := aws.NewConfig()
@@ -37,12 +36,21 @@ func New(pairs ...*types.Pair) (s *Service, err error) {
 	case credential.ProtocolEnv:
 		cfg = cfg.WithCredentials(credentials.NewEnvCredentials())
 	default:
-		return nil, fmt.Errorf(errorMessage, s, credential.ErrUnsupportedProtocol)
+		return nil, types.NewError(s, "New", credential.ErrUnsupportedProtocol)
+	}
+
+	if opt.HasEndpoint {
+		ep := opt.Endpoint.Value()
+		cfg = cfg.WithEndpoint(ep.String())
+		// Most S3-compatible services like MinIO only support path style.
+		if ep.Style == endpoint.StylePath {
+			cfg = cfg.WithS3ForcePathStyle(true)
+		}
 	}
 
 	sess, err := session.NewSession(cfg)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, err)
+		return nil, types.NewError(s, "New", err)
 	}
 
 	srv := s3.New(sess)
@@ -57,12 +65,10 @@ func (s *Service) String() string {
 }
 
 // List implements Servicer.List
-func (s Service) List(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List: %w"
-
+func (s *Service) List(pairs ...*types.Pair) (err error) {
 	opt, err := parseServicePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "List", err)
 	}
 
 	input := &s3.ListBucketsInput{}
@@ -70,13 +76,13 @@ func (s Service) List(pairs ...*types.Pair) (err error) {
 	output, err := s.service.ListBuckets(input)
 	if err != nil {
 		err = handleS3Error(err)
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "List", err)
 	}
 
 	for _, v := range output.Buckets {
 		store, err := newStorage(s.service, *v.Name)
 		if err != nil {
-			return fmt.Errorf(errorMessage, s, err)
+			return types.NewError(s, "List", err)
 		}
 		if opt.HasStoragerFunc {
 			opt.StoragerFunc(store)
@@ -86,23 +92,19 @@ func (s Service) List(pairs ...*types.Pair) (err error) {
 }
 
 // Get implements Servicer.Get
-func (s Service) Get(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s get [%s]: %w"
-
+func (s *Service) Get(name string, pairs ...*types.Pair) (storage.Storager, error) {
 	store, err := newStorage(s.service, name)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Get", err, name)
 	}
 	return store, nil
 }
 
 // Create implements Servicer.Create
-func (s Service) Create(name string, pairs ...*types.Pair) (storage.Storager, error) {
-	const errorMessage = "%s Create [%s]: %w"
-
+func (s *Service) Create(name string, pairs ...*types.Pair) (storage.Storager, error) {
 	opt, err := parseServicePairCreate(pairs...)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 
 	input := &s3.CreateBucketInput{
@@ -114,23 +116,22 @@ func (s Service) Create(name string, pairs ...*types.Pair) (storage.Storager, er
 
 	_, err = s.service.CreateBucket(input)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		err = handleS3Error(err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 
 	store, err := newStorage(s.service, name)
 	if err != nil {
-		return nil, fmt.Errorf(errorMessage, s, name, err)
+		return nil, types.NewError(s, "Create", err, name)
 	}
 	return store, nil
 }
 
 // Delete implements Servicer.Delete
-func (s Service) Delete(name string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
-
+func (s *Service) Delete(name string, pairs ...*types.Pair) (err error) {
 	_, err = parseServicePairDelete(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, name, err)
+		return types.NewError(s, "Delete", err, name)
 	}
 
 	input := &s3.DeleteBucketInput{
@@ -139,7 +140,8 @@ func (s Service) Delete(name string, pairs ...*types.Pair) (err error) {
 
 	_, err = s.service.DeleteBucket(input)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, name, err)
+		err = handleS3Error(err)
+		return types.NewError(s, "Delete", err, name)
 	}
 	return nil
 }
@@ -3,12 +3,17 @@ package s3
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 
+	"github.com/Xuanwo/storage/pkg/httputil"
+	"github.com/Xuanwo/storage/pkg/iowrap"
 	"github.com/Xuanwo/storage/types"
 	"github.com/Xuanwo/storage/types/metadata"
 )
@@ -34,11 +39,9 @@ func newStorage(service s3iface.S3API, bucketName string) (*Storage, error) {
 
 // Init implements Storager.Init
 func (s *Storage) Init(pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Init: %w"
-
 	opt, err := parseStoragePairInit(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, err)
+		return types.NewError(s, "Init", err)
 	}
 
 	if opt.HasWorkDir {
@@ -69,27 +72,25 @@ func (s *Storage) Metadata() (m metadata.Storage, err error) {
 
 // List implements Storager.List
 func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s List [%s]: %w"
-
 	opt, err := parseStoragePairList(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "List", err, path)
 	}
 
-	marker := ""
+	var token *string
 	rp := s.getAbsPath(path)
 
 	var output *s3.ListObjectsV2Output
 	for {
 		output, err = s.service.ListObjectsV2(&s3.ListObjectsV2Input{
-			Bucket:     aws.String(s.name),
-			Prefix:     aws.String(rp),
-			MaxKeys:    aws.Int64(1000),
-			StartAfter: aws.String(marker),
+			Bucket:            aws.String(s.name),
+			Prefix:            aws.String(rp),
+			MaxKeys:           aws.Int64(1000),
+			ContinuationToken: token,
 		})
 		if err != nil {
 			err = handleS3Error(err)
-			return fmt.Errorf(errorMessage, s, path, err)
+			return types.NewError(s, "List", err, path)
 		}
 
 		for _, v := range output.CommonPrefixes {
@@ -125,8 +126,8 @@ func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 			}
 		}
 
-		marker = aws.StringValue(output.StartAfter)
-		if aws.BoolValue(output.IsTruncated) {
+		token = output.NextContinuationToken
+		if !aws.BoolValue(output.IsTruncated) {
 			break
 		}
 	}
@@ -135,7 +136,10 @@ func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
 
 // Read implements Storager.Read
 func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
-	const errorMessage = "%s Read [%s]: %w"
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
 
 	rp := s.getAbsPath(path)
 
@@ -143,27 +147,52 @@ func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err
 		Bucket: aws.String(s.name),
 		Key:    aws.String(rp),
 	}
+	if opt.HasIfMatch {
+		input.IfMatch = &opt.IfMatch
+	}
+	if opt.HasIfNoneMatch {
+		input.IfNoneMatch = &opt.IfNoneMatch
+	}
+	if opt.HasIfModifiedSince {
+		input.IfModifiedSince = &opt.IfModifiedSince
+	}
+	if opt.HasIfUnmodifiedSince {
+		input.IfUnmodifiedSince = &opt.IfUnmodifiedSince
+	}
+	if opt.HasOffset || opt.HasSize {
+		input.Range = aws.String(httputil.FormatRange(opt.Offset, opt.Size))
+	}
+	if opt.HasVersionId {
+		input.VersionId = &opt.VersionId
+	}
 
 	output, err := s.service.GetObject(input)
 	if err != nil {
 		err = handleS3Error(err)
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		return nil, types.NewError(s, "Read", err, path)
 	}
 	return output.Body, nil
 }
 
 // Write implements Storager.Write
 func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Write [%s]: %w"
-
 	opt, err := parseStoragePairWrite(pairs...)
 	if err != nil {
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
+	}
+
+	if opt.HasDetectContentType && opt.DetectContentType && !opt.HasContentType {
+		opt.ContentType, r, err = iowrap.SniffContentType(r)
+		if err != nil {
+			return types.NewError(s, "Write", err, path)
+		}
+		opt.HasContentType = true
 	}
 
 	rp := s.getAbsPath(path)
 
 	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.name),
 		Key:           aws.String(rp),
 		ContentLength: &opt.Size,
 		Body:          aws.ReadSeekCloser(r),
@@ -174,29 +203,118 @@ func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err err
 	if opt.HasStorageClass {
 		input.StorageClass = &opt.StorageClass
 	}
+	if opt.HasUserMetadata {
+		input.Metadata = aws.StringMap(opt.UserMetadata)
+	}
+	if opt.HasContentType {
+		input.ContentType = &opt.ContentType
+	}
+	if opt.HasContentEncoding {
+		input.ContentEncoding = &opt.ContentEncoding
+	}
+	if opt.HasContentDisposition {
+		input.ContentDisposition = &opt.ContentDisposition
+	}
+	if opt.HasCacheControl {
+		input.CacheControl = &opt.CacheControl
+	}
 
-	_, err = s.service.PutObject(input)
+	// PutObjectInput doesn't have conditional fields, so we need to set headers by ourselves.
+	req, _ := s.service.PutObjectRequest(input)
+	if opt.HasIfMatch {
+		req.HTTPRequest.Header.Set("If-Match", opt.IfMatch)
+	}
+	if opt.HasIfNoneMatch {
+		req.HTTPRequest.Header.Set("If-None-Match", opt.IfNoneMatch)
+	}
+	err = req.Send()
 	if err != nil {
 		err = handleS3Error(err)
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Write", err, path)
 	}
 	return nil
 }
 
+// Reach implements Storager.Reach
+func (s *Storage) Reach(path string, pairs ...*types.Pair) (url string, err error) {
+	opt, err := parseStoragePairReach(pairs...)
+	if err != nil {
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	method := http.MethodGet
+	if opt.HasMethod {
+		method = opt.Method
+	}
+
+	var req *request.Request
+	switch method {
+	case http.MethodGet:
+		req, _ = s.service.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(s.name),
+			Key:    aws.String(rp),
+		})
+	case http.MethodPut:
+		input := &s3.PutObjectInput{
+			Bucket: aws.String(s.name),
+			Key:    aws.String(rp),
+		}
+		// Content type and length will be signed, client must send the same headers.
+		if opt.HasContentType {
+			input.ContentType = &opt.ContentType
+		}
+		if opt.HasSize {
+			input.ContentLength = &opt.Size
+		}
+		req, _ = s.service.PutObjectRequest(input)
+	default:
+		err = fmt.Errorf("method %s: %w", method, types.ErrNotSupported)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+
+	url, err = req.Presign(time.Duration(opt.Expire) * time.Second)
+	if err != nil {
+		err = handleS3Error(err)
+		return "", types.NewError(s, "Reach", err, path)
+	}
+	return url, nil
+}
+
 // Stat implements Storager.Stat
 func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
-	const errorMessage = "%s Stat [%s]: %w"
+	opt, err := parseStoragePairStat(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
 
 	rp := s.getAbsPath(path)
 
 	input := &s3.HeadObjectInput{
-		Key: aws.String(rp),
+		Bucket: aws.String(s.name),
+		Key:    aws.String(rp),
+	}
+	if opt.HasIfMatch {
+		input.IfMatch = &opt.IfMatch
+	}
+	if opt.HasIfNoneMatch {
+		input.IfNoneMatch = &opt.IfNoneMatch
+	}
+	if opt.HasIfModifiedSince {
+		input.IfModifiedSince = &opt.IfModifiedSince
+	}
+	if opt.HasIfUnmodifiedSince {
+		input.IfUnmodifiedSince = &opt.IfUnmodifiedSince
+	}
+	if opt.HasVersionId {
+		input.VersionId = &opt.VersionId
 	}
 
 	output, err := s.service.HeadObject(input)
 	if err != nil {
 		err = handleS3Error(err)
-		return nil, fmt.Errorf(errorMessage, s, path, err)
+		return nil, types.NewError(s, "Stat", err, path)
 	}
 
 	// TODO: Add dir support.
@@ -218,12 +336,33 @@ func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err
 	if output.StorageClass != nil {
 		o.SetClass(*output.StorageClass)
 	}
+	if len(output.Metadata) > 0 {
+		o.SetUserMetadata(formatUserMetadata(aws.StringValueMap(output.Metadata)))
+	}
+	if output.ContentEncoding != nil {
+		o.SetContentEncoding(*output.ContentEncoding)
+	}
+	if output.ContentDisposition != nil {
+		o.SetContentDisposition(*output.ContentDisposition)
+	}
+	if output.CacheControl != nil {
+		o.SetCacheControl(*output.CacheControl)
+	}
+	if output.VersionId != nil {
+		o.SetVersionId(*output.VersionId)
+	}
 	return o, nil
 }
 
 // Delete implements Storager.Delete
+//
+// A delete marker will be created if versioning is enabled, and the version will be deleted permanently if
+// version_id is given.
 func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
-	const errorMessage = "%s Delete [%s]: %w"
+	opt, err := parseStoragePairDelete(pairs...)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
 
 	rp := s.getAbsPath(path)
 
@@ -231,11 +370,89 @@ func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
 		Bucket: aws.String(s.name),
 		Key:    aws.String(rp),
 	}
+	if opt.HasVersionId {
+		input.VersionId = &opt.VersionId
+	}
 
 	_, err = s.service.DeleteObject(input)
 	if err != nil {
 		err = handleS3Error(err)
-		return fmt.Errorf(errorMessage, s, path, err)
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// ListVersions implements Storager.ListVersions
+func (s *Storage) ListVersions(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairListVersions(pairs...)
+	if err != nil {
+		return types.NewError(s, "ListVersions", err, path)
+	}
+
+	rp := s.getAbsPath(path)
+
+	var keyMarker, versionIDMarker *string
+	var output *s3.ListObjectVersionsOutput
+	for {
+		output, err = s.service.ListObjectVersions(&s3.ListObjectVersionsInput{
+			Bucket:          aws.String(s.name),
+			Prefix:          aws.String(rp),
+			KeyMarker:       keyMarker,
+			VersionIdMarker: versionIDMarker,
+		})
+		if err != nil {
+			err = handleS3Error(err)
+			return types.NewError(s, "ListVersions", err, path)
+		}
+
+		for _, v := range output.Versions {
+			// Objects which have path as prefix will also be listed.
+			if aws.StringValue(v.Key) != rp {
+				continue
+			}
+
+			o := &types.Object{
+				Name:      path,
+				Type:      types.ObjectTypeFile,
+				Size:      aws.Int64Value(v.Size),
+				UpdatedAt: aws.TimeValue(v.LastModified),
+				Metadata:  make(metadata.Metadata),
+			}
+			o.SetVersionId(aws.StringValue(v.VersionId))
+			if v.StorageClass != nil {
+				o.SetClass(*v.StorageClass)
+			}
+			if v.ETag != nil {
+				o.SetChecksum(*v.ETag)
+			}
+
+			if opt.HasFileFunc {
+				opt.FileFunc(o)
+			}
+		}
+
+		if !aws.BoolValue(output.IsTruncated) {
+			break
+		}
+		keyMarker, versionIDMarker = output.NextKeyMarker, output.NextVersionIdMarker
+	}
+	return nil
+}
+
+// Restore implements Storager.Restore
+//
+// The version will be copied into a new version, so that the current version is kept.
+func (s *Storage) Restore(path, version string, pairs ...*types.Pair) (err error) {
+	rp := s.getAbsPath(path)
+
+	_, err = s.service.CopyObject(&s3.CopyObjectInput{
+		Bucket:     aws.String(s.name),
+		Key:        aws.String(rp),
+		CopySource: aws.String(formatCopySource(s.name, rp, version)),
+	})
+	if err != nil {
+		err = handleS3Error(err)
+		return types.NewError(s, "Restore", err, path)
 	}
 	return nil
 }
@@ -2,6 +2,8 @@ package s3
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/Xuanwo/storage/types"
@@ -20,9 +22,44 @@ func handleS3Error(err error) error {
 	}
 
 	switch e.Code() {
-	default:
-		return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NoSuchVersion", "NotFound":
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case "AccessDenied", "AllAccessDisabled", "AccountProblem":
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case "InvalidAccessKeyId", "SignatureDoesNotMatch", "InvalidBucketName", "AuthorizationHeaderMalformed":
+		return fmt.Errorf("%w: %v", types.ErrConfigIncorrect, err)
+	case "BucketAlreadyExists", "BucketAlreadyOwnedByYou":
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	case "BucketNotEmpty":
+		return fmt.Errorf("%w: %v", types.ErrDirNotEmpty, err)
+	case "KeyTooLongError", "InvalidObjectName":
+		return fmt.Errorf("%w: %v", types.ErrInvalidPath, err)
+	case "PreconditionFailed", "NotModified", "ConditionalRequestConflict":
+		return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+	case "TooManyBuckets", "EntityTooLarge":
+		return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+	case "SlowDown", "Throttling", "ThrottlingException", "RequestLimitExceeded":
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case "ServiceUnavailable", "InternalError":
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+
+	// HEAD requests don't have body, so we can only handle them via status code.
+	if re, ok := err.(awserr.RequestFailure); ok {
+		switch re.StatusCode() {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+		case http.StatusPreconditionFailed, http.StatusNotModified:
+			return fmt.Errorf("%w: %v", types.ErrPreconditionFailed, err)
+		case http.StatusTooManyRequests:
+			return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+		case http.StatusServiceUnavailable:
+			return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+		}
 	}
+	return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
 }
 
 func (s *Storage) getAbsPath(path string) string {
@@ -32,3 +69,19 @@ func (s *Storage) getAbsPath(path string) string {
 func (s *Storage) getRelPath(path string) string {
 	return strings.TrimPrefix(path, s.workDir+"/")
 }
+
+// formatUserMetadata will convert user metadata's keys into lower case, because s3 returns them
+// in canonical header format.
+func formatUserMetadata(m map[string]string) map[string]string {
+	x := make(map[string]string, len(m))
+	for k, v := range m {
+		x[strings.ToLower(k)] = v
+	}
+	return x
+}
+
+// formatCopySource will format a url encoded copy source for a specific version.
+func formatCopySource(bucket, key, version string) string {
+	u := &url.URL{Path: bucket + "/" + key}
+	return u.EscapedPath() + "?versionId=" + url.QueryEscape(version)
+}
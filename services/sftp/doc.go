@@ -0,0 +1,12 @@
+/*
+Package sftp provided support for SFTP servers.
+
+Endpoint's protocol should be "sftp", and credential could be:
+
+  - hmac: user and password.
+  - file: path to the private key file, user should be set via pair user.
+
+Server's host key will be verified against known_hosts file, which is "~/.ssh/known_hosts" by default and
+could be changed via pair known_hosts.
+*/
+package sftp
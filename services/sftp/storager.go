@@ -0,0 +1,306 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// Storage is the sftp client.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	client *sftp.Client
+
+	addr    string
+	workDir string
+}
+
+// New will create a sftp client.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager sftp {Addr: %s, WorkDir: %s}", s.addr, s.workDir)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	ep := opt.Endpoint.Value()
+	if ep.Protocol != endpoint.ProtocolSFTP {
+		return types.NewError(s, "Init", endpoint.ErrUnsupportedProtocol)
+	}
+	s.addr = ep.Addr()
+
+	cfg := &ssh.ClientConfig{}
+
+	credProtocol, cred := opt.Credential.Protocol(), opt.Credential.Value()
+	switch credProtocol {
+	case credential.ProtocolHmac:
+		cfg.User = cred[0]
+		cfg.Auth = []ssh.AuthMethod{ssh.Password(cred[1])}
+	case credential.ProtocolFile:
+		if !opt.HasUser {
+			return types.NewError(s, "Init", types.NewErrPairRequired(ps.User))
+		}
+		signer, err := parsePrivateKey(cred[0])
+		if err != nil {
+			return types.NewError(s, "Init", err)
+		}
+		cfg.User = opt.User
+		cfg.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	default:
+		return types.NewError(s, "Init", credential.ErrUnsupportedProtocol)
+	}
+
+	knownHostsPath := opt.KnownHosts
+	if !opt.HasKnownHosts {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return types.NewError(s, "Init", err)
+		}
+		knownHostsPath = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	cfg.HostKeyCallback, err = knownhosts.New(knownHostsPath)
+	if err != nil {
+		return types.NewError(s, "Init", fmt.Errorf("%w: %v", types.ErrConfigIncorrect, err))
+	}
+
+	conn, err := ssh.Dial("tcp", s.addr, cfg)
+	if err != nil {
+		return types.NewError(s, "Init", handleSSHError(err))
+	}
+	s.client, err = sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return types.NewError(s, "Init", handleSftpError(err))
+	}
+
+	// Use user's login dir as work dir if not given.
+	if opt.HasWorkDir {
+		s.workDir = opt.WorkDir
+	} else {
+		s.workDir, err = s.client.Getwd()
+		if err != nil {
+			return types.NewError(s, "Init", handleSftpError(err))
+		}
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     "",
+		WorkDir:  s.workDir,
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	fi, err := s.client.ReadDir(s.getAbsPath(path))
+	if err != nil {
+		return types.NewError(s, "List", handleSftpError(err), path)
+	}
+
+	for _, v := range fi {
+		o := formatFileInfo(v)
+		o.Name = storageutil.JoinPath(path, v.Name())
+
+		if o.Type == types.ObjectTypeDir {
+			if opt.HasDirFunc {
+				opt.DirFunc(o)
+			}
+			continue
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	f, err := s.client.Open(s.getAbsPath(path))
+	if err != nil {
+		return nil, types.NewError(s, "Read", handleSftpError(err), path)
+	}
+	if opt.HasOffset {
+		_, err = f.Seek(opt.Offset, io.SeekStart)
+		if err != nil {
+			_ = f.Close()
+			return nil, types.NewError(s, "Read", handleSftpError(err), path)
+		}
+	}
+	if opt.HasSize {
+		return iowrap.LimitReadCloser(f, opt.Size), nil
+	}
+	return f, nil
+}
+
+// Write implements Storager.Write
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairWrite(pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	// Create dir for path.
+	err = s.createDir(path)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	f, err := s.client.Create(s.getAbsPath(path))
+	if err != nil {
+		return types.NewError(s, "Write", handleSftpError(err), path)
+	}
+
+	if opt.HasSize {
+		r = iowrap.SizeReader(r, opt.Size)
+	}
+	err = copyAndClose(f, r)
+	if err != nil {
+		return types.NewError(s, "Write", handleSftpError(err), path)
+	}
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	fi, err := s.client.Stat(s.getAbsPath(path))
+	if err != nil {
+		return nil, types.NewError(s, "Stat", handleSftpError(err), path)
+	}
+
+	o = formatFileInfo(fi)
+	o.Name = path
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	err = s.client.Remove(s.getAbsPath(path))
+	if err != nil {
+		return types.NewError(s, "Delete", handleSftpError(err), path)
+	}
+	return nil
+}
+
+// Copy implements Storager.Copy
+//
+// SFTP doesn't support server side copy, so content will be streamed from src to dst via this client.
+func (s *Storage) Copy(src, dst string, pairs ...*types.Pair) (err error) {
+	// Create dir for dst.
+	err = s.createDir(dst)
+	if err != nil {
+		return types.NewError(s, "Copy", err, src, dst)
+	}
+
+	srcFile, err := s.client.Open(s.getAbsPath(src))
+	if err != nil {
+		return types.NewError(s, "Copy", handleSftpError(err), src, dst)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := s.client.Create(s.getAbsPath(dst))
+	if err != nil {
+		return types.NewError(s, "Copy", handleSftpError(err), src, dst)
+	}
+
+	err = copyAndClose(dstFile, srcFile)
+	if err != nil {
+		return types.NewError(s, "Copy", handleSftpError(err), src, dst)
+	}
+	return nil
+}
+
+// Move implements Storager.Move
+//
+// posix-rename@openssh.com extension is used so that dst will be replaced if exists.
+func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
+	// Create dir for dst.
+	err = s.createDir(dst)
+	if err != nil {
+		return types.NewError(s, "Move", err, src, dst)
+	}
+
+	err = s.client.PosixRename(s.getAbsPath(src), s.getAbsPath(dst))
+	if err != nil {
+		return types.NewError(s, "Move", handleSftpError(err), src, dst)
+	}
+	return nil
+}
+
+// createDir will create the parent dir of path.
+func (s *Storage) createDir(p string) (err error) {
+	dir := path.Dir(s.getAbsPath(p))
+	// Don't need to create work dir.
+	if dir == path.Clean(s.workDir) {
+		return nil
+	}
+
+	err = s.client.MkdirAll(dir)
+	if err != nil {
+		return handleSftpError(err)
+	}
+	return nil
+}
+
+// parsePrivateKey will read and parse private key from file.
+func parsePrivateKey(name string) (ssh.Signer, error) {
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: read private key: %v", types.ErrConfigIncorrect, err)
+	}
+	signer, err := ssh.ParsePrivateKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parse private key: %v", types.ErrConfigIncorrect, err)
+	}
+	return signer, nil
+}
+
+// copyAndClose will copy from r into f, and f's close error will be returned if copy succeed.
+func copyAndClose(f *sftp.File, r io.Reader) (err error) {
+	_, err = f.ReadFrom(r)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,357 @@
+package sftp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+type testServer struct {
+	dir  string
+	port int
+
+	knownHosts string
+	privateKey string
+
+	listener net.Listener
+}
+
+func newTestServer(t *testing.T) *testServer {
+	dir, err := ioutil.TempDir("", "sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := &testServer{dir: dir}
+
+	hostKey := newTestKey(t, filepath.Join(dir, "host_key"))
+	userKey := newTestKey(t, filepath.Join(dir, "user_key"))
+	ts.privateKey = filepath.Join(dir, "user_key")
+
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "test_user" && string(pass) == "test_password" {
+				return nil, nil
+			}
+			return nil, errors.New("password rejected")
+		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if c.User() == "test_user" && bytes.Equal(key.Marshal(), userKey.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("public key rejected")
+		},
+	}
+	cfg.AddHostKey(hostKey)
+
+	ts.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.port = ts.listener.Addr().(*net.TCPAddr).Port
+
+	ts.knownHosts = filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{ts.listener.Addr().String()}, hostKey.PublicKey())
+	err = ioutil.WriteFile(ts.knownHosts, []byte(line+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Objects will be stored under the work dir.
+	err = os.Mkdir(filepath.Join(dir, "work"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := ts.listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, cfg)
+		}
+	}()
+	return ts
+}
+
+func (ts *testServer) Close() {
+	_ = ts.listener.Close()
+	_ = os.RemoveAll(ts.dir)
+}
+
+// serveSSH will serve sftp subsystem over an accepted connection.
+func serveSSH(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for req := range requests {
+				// Payload of subsystem request is a ssh string: uint32 length + "sftp".
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					_ = channel.Close()
+					return
+				}
+				_ = server.Serve()
+				_ = channel.Close()
+			}
+		}()
+	}
+}
+
+// newTestKey will generate a rsa key and write it into name in PEM format.
+func newTestKey(t *testing.T, name string) ssh.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	err = ioutil.WriteFile(name, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func (ts *testServer) newStorage(cred *credential.Provider, ps ...*types.Pair) (*Storage, error) {
+	ep, err := endpoint.Parse("sftp:127.0.0.1:" + strconv.Itoa(ts.port))
+	if err != nil {
+		return nil, err
+	}
+
+	store := New()
+	err = store.Init(append([]*types.Pair{
+		pairs.WithEndpoint(ep),
+		pairs.WithCredential(cred),
+		pairs.WithKnownHosts(ts.knownHosts),
+		pairs.WithWorkDir(filepath.Join(ts.dir, "work")),
+	}, ps...)...)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func TestStorage(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	store, err := ts.newStorage(credential.MustNewHmac("test_user", "test_password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("0123456789")
+	workDir := filepath.Join(ts.dir, "work")
+
+	t.Run("write", func(t *testing.T) {
+		err := store.Write("a/b/test_file", bytes.NewReader(content), pairs.WithSize(int64(len(content))))
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(workDir, "a", "b", "test_file"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("write with size", func(t *testing.T) {
+		err := store.Write("short", bytes.NewReader(content), pairs.WithSize(4))
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(workDir, "short"))
+		assert.NoError(t, err)
+		assert.Equal(t, "0123", string(b))
+	})
+
+	t.Run("write with short input", func(t *testing.T) {
+		err := store.Write("short_input", bytes.NewReader(content), pairs.WithSize(20))
+		assert.True(t, errors.Is(err, iowrap.ErrShortInput))
+
+		// Partial content could be left as fs does.
+		_ = store.Delete("short_input")
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		o, err := store.Stat("a/b/test_file")
+		assert.NoError(t, err)
+		assert.Equal(t, "a/b/test_file", o.Name)
+		assert.Equal(t, types.ObjectTypeFile, o.Type)
+		assert.Equal(t, int64(len(content)), o.Size)
+
+		o, err = store.Stat("a")
+		assert.NoError(t, err)
+		assert.Equal(t, types.ObjectTypeDir, o.Type)
+
+		_, err = store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			pairs  []*types.Pair
+			expect string
+		}{
+			{"full", nil, "0123456789"},
+			{"offset", []*types.Pair{pairs.WithOffset(7)}, "789"},
+			{"size", []*types.Pair{pairs.WithSize(3)}, "012"},
+			{"offset and size", []*types.Pair{pairs.WithOffset(2), pairs.WithSize(3)}, "234"},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				r, err := store.Read("a/b/test_file", tt.pairs...)
+				assert.NoError(t, err)
+				defer r.Close()
+
+				b, err := ioutil.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, string(b))
+			})
+		}
+
+		_, err := store.Read("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		err := store.Copy("a/b/test_file", "c/copied")
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(workDir, "c", "copied"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		// dst will be replaced.
+		err := store.Move("c/copied", "short")
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(workDir, "c", "copied"))
+		assert.True(t, os.IsNotExist(err))
+		b, err := ioutil.ReadFile(filepath.Join(workDir, "short"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := make([]string, 0), make([]string, 0)
+		err := store.List("",
+			pairs.WithFileFunc(func(o *types.Object) {
+				files = append(files, o.Name)
+			}),
+			pairs.WithDirFunc(func(o *types.Object) {
+				dirs = append(dirs, o.Name)
+			}),
+		)
+		assert.NoError(t, err)
+		sort.Strings(dirs)
+		assert.Equal(t, []string{"short"}, files)
+		assert.Equal(t, []string{"a", "c"}, dirs)
+
+		files = files[:0]
+		err = store.List("a/b", pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a/b/test_file"}, files)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := store.Delete("short")
+		assert.NoError(t, err)
+
+		err = store.Delete("short")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+		// Empty dir could be deleted too.
+		err = store.Delete("c")
+		assert.NoError(t, err)
+	})
+}
+
+func TestStorage_Init(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	t.Run("private key", func(t *testing.T) {
+		store, err := ts.newStorage(credential.MustNewFile(ts.privateKey), pairs.WithUser("test_user"))
+		assert.NoError(t, err)
+
+		err = store.Write("test_file", bytes.NewReader([]byte("content")))
+		assert.NoError(t, err)
+	})
+
+	t.Run("private key without user", func(t *testing.T) {
+		_, err := ts.newStorage(credential.MustNewFile(ts.privateKey))
+		assert.True(t, errors.Is(err, types.ErrPairRequired))
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		_, err := ts.newStorage(credential.MustNewHmac("test_user", "invalid_password"))
+		assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+	})
+
+	t.Run("unknown host key", func(t *testing.T) {
+		knownHosts := filepath.Join(ts.dir, "empty_known_hosts")
+		err := ioutil.WriteFile(knownHosts, nil, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ts.newStorage(credential.MustNewHmac("test_user", "test_password"), pairs.WithKnownHosts(knownHosts))
+		assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+	})
+
+	t.Run("unsupported credential", func(t *testing.T) {
+		_, err := ts.newStorage(credential.MustNewAPIKey("test_key"))
+		assert.True(t, errors.Is(err, credential.ErrUnsupportedProtocol))
+	})
+}
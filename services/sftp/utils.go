@@ -0,0 +1,81 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/sftp"
+
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+func (s *Storage) getAbsPath(p string) string {
+	return path.Join(s.workDir, p)
+}
+
+func formatFileInfo(fi os.FileInfo) *types.Object {
+	o := &types.Object{
+		Size:      fi.Size(),
+		UpdatedAt: fi.ModTime(),
+		Metadata:  make(metadata.Metadata),
+	}
+	if fi.IsDir() {
+		o.Type = types.ObjectTypeDir
+	} else {
+		o.Type = types.ObjectTypeFile
+	}
+	return o
+}
+
+func handleSftpError(err error) error {
+	if err == nil {
+		panic("error must not be nil")
+	}
+	// Short input is caller's fault, which should not be treated as connection lost.
+	if errors.Is(err, iowrap.ErrShortInput) {
+		return err
+	}
+
+	// sftp client will convert SSH_FX_NO_SUCH_FILE into os.ErrNotExist.
+	if errors.Is(err, os.ErrNotExist) || os.IsNotExist(err) {
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	}
+
+	var e *sftp.StatusError
+	if errors.As(err, &e) {
+		switch e.FxCode() {
+		case sftp.ErrSSHFxNoSuchFile:
+			return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+		case sftp.ErrSSHFxPermissionDenied:
+			return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+		case sftp.ErrSSHFxOpUnsupported:
+			return fmt.Errorf("%w: %v", types.ErrNotSupported, err)
+		case sftp.ErrSSHFxNoConnection, sftp.ErrSSHFxConnectionLost:
+			return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+		}
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+	return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+}
+
+// handleSSHError will convert errors returned while connecting.
+func handleSSHError(err error) error {
+	if err == nil {
+		panic("error must not be nil")
+	}
+
+	// Handshake errors are formatted into string by x/crypto/ssh, so we have to check the message.
+	msg := err.Error()
+	if strings.Contains(msg, "unable to authenticate") || strings.Contains(msg, "knownhosts:") {
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+}
@@ -0,0 +1,12 @@
+/*
+Package shard provided a storager which distributes objects across several storagers by consistent hashing.
+
+Every shard will be placed on a hash ring with virtual nodes, and an object belongs to the first virtual node
+after its path's hash. Read, Write, Stat and Delete will only touch the shard which the object belongs to, while
+List will merge results from all shards.
+
+Shards are identified by their position, so new shards must be appended to the end. After shards have been
+added, objects which now belong to new shards will be unreachable until Rebalance has moved them, and only
+those objects will be moved.
+*/
+package shard
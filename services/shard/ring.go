@@ -0,0 +1,53 @@
+package shard
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// ring is a consistent hash ring which maps keys to shard indexes.
+type ring struct {
+	hashes []uint32
+	owners map[uint32]int
+}
+
+func newRing(shards, vnodes int) *ring {
+	r := &ring{
+		hashes: make([]uint32, 0, shards*vnodes),
+		owners: make(map[uint32]int, shards*vnodes),
+	}
+	for i := 0; i < shards; i++ {
+		for v := 0; v < vnodes; v++ {
+			h := hash(strconv.Itoa(i) + "-" + strconv.Itoa(v))
+			// Keep the earlier shard on collision, so that appending shards will not move its keys.
+			if _, ok := r.owners[h]; ok {
+				continue
+			}
+			r.owners[h] = i
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool {
+		return r.hashes[i] < r.hashes[j]
+	})
+	return r
+}
+
+// get will return the index of the shard which key belongs to.
+func (r *ring) get(key string) int {
+	h := hash(key)
+	idx := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= h
+	})
+	if idx == len(r.hashes) {
+		idx = 0
+	}
+	return r.owners[r.hashes[idx]]
+}
+
+func hash(key string) uint32 {
+	sum := md5.Sum([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
@@ -0,0 +1,37 @@
+package shard
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "prefix/" + strconv.Itoa(i)
+	}
+
+	old := newRing(4, DefaultVirtualNodes)
+	counts := make([]int, 4)
+	for _, k := range keys {
+		counts[old.get(k)]++
+	}
+	for _, v := range counts {
+		// Every shard should own a fair part of keys.
+		assert.InDelta(t, len(keys)/4, v, float64(len(keys))/10)
+	}
+
+	// Keys should only be moved into the new shard.
+	r := newRing(5, DefaultVirtualNodes)
+	moved := 0
+	for _, k := range keys {
+		if r.get(k) == old.get(k) {
+			continue
+		}
+		assert.Equal(t, 4, r.get(k))
+		moved++
+	}
+	assert.InDelta(t, len(keys)/5, moved, float64(len(keys))/10)
+}
@@ -0,0 +1,241 @@
+package shard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// DefaultVirtualNodes is the default count of virtual nodes for every shard.
+const DefaultVirtualNodes = 128
+
+// Storage is the shard storager.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	shards []storage.Storager
+	ring   *ring
+}
+
+// New will create a shard storager.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager shard {Shards: %v}", s.shards)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	if len(opt.Storagers) == 0 {
+		return types.NewError(s, "Init", types.NewErrPairRequired(ps.Storagers))
+	}
+	s.shards = opt.Storagers
+
+	vnodes := DefaultVirtualNodes
+	if opt.HasVirtualNodes {
+		if opt.VirtualNodes < 1 {
+			err = fmt.Errorf("%w: virtual nodes %d", types.ErrConfigIncorrect, opt.VirtualNodes)
+			return types.NewError(s, "Init", err)
+		}
+		vnodes = opt.VirtualNodes
+	}
+	s.ring = newRing(len(s.shards), vnodes)
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     "",
+		WorkDir:  "",
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+//
+// Objects will be merged from all shards by name, ErrObjectNotExist will only be returned if path doesn't exist
+// in all shards.
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	seen := make(map[string]struct{})
+	visit := func(fn types.ObjectFunc) types.ObjectFunc {
+		return func(o *types.Object) {
+			if _, ok := seen[o.Name]; ok {
+				return
+			}
+			seen[o.Name] = struct{}{}
+			fn(o)
+		}
+	}
+
+	listPairs := make([]*types.Pair, 0, 2)
+	if opt.HasFileFunc {
+		listPairs = append(listPairs, ps.WithFileFunc(visit(opt.FileFunc)))
+	}
+	if opt.HasDirFunc {
+		listPairs = append(listPairs, ps.WithDirFunc(visit(opt.DirFunc)))
+	}
+
+	found := false
+	for _, store := range s.shards {
+		err = store.List(path, listPairs...)
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return types.NewError(s, "List", err, path)
+		}
+		found = found || err == nil
+	}
+	if !found {
+		return types.NewError(s, "List", types.ErrObjectNotExist, path)
+	}
+	return nil
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	r, err = s.shard(path).Read(path, pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	err = s.shard(path).Write(path, r, pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	o, err = s.shard(path).Stat(path, pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	err = s.shard(path).Delete(path, pairs...)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// Copy implements Storager.Copy
+//
+// Object will be copied by reading and writing if src and dst belong to different shards.
+func (s *Storage) Copy(src, dst string, pairs ...*types.Pair) (err error) {
+	i, j := s.locate(src), s.locate(dst)
+	from, to := s.shards[i], s.shards[j]
+	if c, ok := from.(storage.Copier); ok && i == j {
+		err = c.Copy(src, dst, pairs...)
+	} else {
+		_, err = storageutil.CopyObject(from, src, to, dst)
+	}
+	if err != nil {
+		return types.NewError(s, "Copy", err, src, dst)
+	}
+	return nil
+}
+
+// Move implements Storager.Move
+//
+// Object will be copied and then deleted if src and dst belong to different shards.
+func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
+	i, j := s.locate(src), s.locate(dst)
+	from, to := s.shards[i], s.shards[j]
+	if m, ok := from.(storage.Mover); ok && i == j {
+		err = m.Move(src, dst, pairs...)
+	} else {
+		err = moveObject(from, src, to, dst)
+	}
+	if err != nil {
+		return types.NewError(s, "Move", err, src, dst)
+	}
+	return nil
+}
+
+// Rebalance will move objects under path into the shards they belong to, file_func will be called for every
+// moved object.
+//
+// Rebalance should be called after shards have been added, objects already in the right shard will not be
+// touched.
+func (s *Storage) Rebalance(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairRebalance(pairs...)
+	if err != nil {
+		return types.NewError(s, "Rebalance", err, path)
+	}
+
+	for idx := range s.shards {
+		err = s.rebalance(idx, path, opt)
+		if err != nil {
+			return types.NewError(s, "Rebalance", err, path)
+		}
+	}
+	return nil
+}
+
+// rebalance will walk path in shard idx recursively, and move objects which belong to other shards.
+func (s *Storage) rebalance(idx int, path string, opt *pairStorageRebalance) (err error) {
+	store := s.shards[idx]
+
+	moved := make([]*types.Object, 0)
+	err = storageutil.Walk(store, path, func(o *types.Object) {
+		if s.locate(o.Name) != idx {
+			moved = append(moved, o)
+		}
+	}, nil)
+	// Not all shards have objects under path.
+	if errors.Is(err, types.ErrObjectNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, o := range moved {
+		err = moveObject(store, o.Name, s.shard(o.Name), o.Name)
+		if err != nil {
+			return err
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return nil
+}
+
+// locate will return the index of the shard which path belongs to.
+func (s *Storage) locate(path string) int {
+	return s.ring.get(storageutil.CleanPath(path))
+}
+
+// shard will return the storager which path belongs to.
+func (s *Storage) shard(path string) storage.Storager {
+	return s.shards[s.locate(path)]
+}
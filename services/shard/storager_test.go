@@ -0,0 +1,146 @@
+package shard
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storagetest"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func newTestShards(t *testing.T, n int) ([]string, []storage.Storager) {
+	dirs := make([]string, n)
+	shards := make([]storage.Storager, n)
+	for i := range shards {
+		dir, err := ioutil.TempDir("", "shard")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		store := fs.New()
+		err = store.Init(pairs.WithWorkDir(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs[i], shards[i] = dir, store
+	}
+	return dirs, shards
+}
+
+func newTestStorager(t *testing.T, shards []storage.Storager) *Storage {
+	store := New()
+	err := store.Init(pairs.WithStoragers(shards))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStorage(t *testing.T) {
+	dirs, shards := newTestShards(t, 3)
+	for _, v := range dirs {
+		defer os.RemoveAll(v)
+	}
+	store := newTestStorager(t, shards)
+
+	names := make([]string, 0)
+	for i := 0; i < 30; i++ {
+		name := "dir/" + strconv.Itoa(i)
+		names = append(names, name)
+
+		err := store.Write(name, bytes.NewReader([]byte(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(names)
+
+	t.Run("distribute", func(t *testing.T) {
+		for _, v := range dirs {
+			fi, err := ioutil.ReadDir(v + "/dir")
+			assert.NoError(t, err)
+			assert.NotEmpty(t, fi)
+		}
+		for _, v := range names {
+			assert.Equal(t, v, storagetest.Read(t, store, v))
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := storagetest.List(t, store, "")
+		assert.Equal(t, []string{}, files)
+		assert.Equal(t, []string{"dir"}, dirs)
+
+		files, _ = storagetest.List(t, store, "dir")
+		assert.Equal(t, names, files)
+
+		err := store.List("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("copy and move", func(t *testing.T) {
+		for i, v := range names[:10] {
+			err := store.Copy(v, "copy/"+strconv.Itoa(i))
+			assert.NoError(t, err)
+			assert.Equal(t, v, storagetest.Read(t, store, "copy/"+strconv.Itoa(i)))
+
+			err = store.Move("copy/"+strconv.Itoa(i), "move/"+strconv.Itoa(i))
+			assert.NoError(t, err)
+			assert.Equal(t, v, storagetest.Read(t, store, "move/"+strconv.Itoa(i)))
+			_, err = store.Stat("copy/" + strconv.Itoa(i))
+			assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		}
+	})
+
+	t.Run("rebalance", func(t *testing.T) {
+		newDirs, newShards := newTestShards(t, 1)
+		defer os.RemoveAll(newDirs[0])
+
+		store := newTestStorager(t, append(shards, newShards...))
+
+		moved := make([]string, 0)
+		err := store.Rebalance("dir", pairs.WithFileFunc(func(o *types.Object) {
+			moved = append(moved, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, moved)
+
+		// Only objects which belong to the new shard should be moved.
+		for _, v := range moved {
+			assert.Equal(t, 3, store.locate(v))
+		}
+		files, _ := storagetest.List(t, newShards[0], "dir")
+		assert.Equal(t, len(moved), len(files))
+		for _, v := range names {
+			assert.Equal(t, v, storagetest.Read(t, store, v))
+		}
+
+		moved = moved[:0]
+		err = store.Rebalance("", pairs.WithFileFunc(func(o *types.Object) {
+			moved = append(moved, o.Name)
+		}))
+		assert.NoError(t, err)
+		// Objects under move/ are moved in this round, objects under dir/ should stay.
+		for _, v := range moved {
+			assert.NotContains(t, v, "dir/")
+		}
+	})
+}
+
+func TestStorage_Init(t *testing.T) {
+	err := New().Init(pairs.WithStoragers([]storage.Storager{}))
+	assert.True(t, errors.Is(err, types.ErrPairRequired))
+
+	err = New().Init(pairs.WithStoragers([]storage.Storager{fs.New()}), pairs.WithVirtualNodes(0))
+	assert.True(t, errors.Is(err, types.ErrConfigIncorrect))
+}
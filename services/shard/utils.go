@@ -0,0 +1,15 @@
+package shard
+
+import (
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+)
+
+// moveObject will copy object from src to dst, and then delete it from src.
+func moveObject(src storage.Storager, srcPath string, dst storage.Storager, dstPath string) (err error) {
+	_, err = storageutil.CopyObject(src, srcPath, dst, dstPath)
+	if err != nil {
+		return err
+	}
+	return src.Delete(srcPath)
+}
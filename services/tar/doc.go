@@ -0,0 +1,11 @@
+/*
+Package tar provided read-only support for tar archives.
+
+The archive could be read from any Storager via pair storager, or from local file system if storager not given.
+Only tar headers will be fetched while Init, and Read will fetch the entry's data via ranged Read, so archive in
+remote storage doesn't need to be downloaded.
+
+Only regular files and dirs will be exposed, other entries like links and devices will be skipped. Compressed
+archives like tar.gz are not seekable, so they are not supported.
+*/
+package tar
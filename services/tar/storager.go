@@ -0,0 +1,236 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/archive"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// Storage is the tar archive client.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	// store is the storager which holds the archive at path.
+	store storage.Storager
+	path  string
+	// index will be built while Init, and the entry's Value is the offset of its data in archive.
+	index *archive.Index
+
+	workDir string
+}
+
+// New will create a tar archive client.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager tar {Path: %s, WorkDir: %s}", s.path, s.workDir)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.path = opt.Path
+	if opt.HasStorager {
+		s.store = opt.Storager
+	} else {
+		s.store, s.path, err = openLocal(opt.Path)
+		if err != nil {
+			return types.NewError(s, "Init", err)
+		}
+	}
+
+	s.workDir = "/"
+	if opt.HasWorkDir {
+		s.workDir = path.Join("/", opt.WorkDir)
+	}
+
+	o, err := s.store.Stat(s.path)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+	s.index, err = buildIndex(archive.NewReaderAt(s.store, s.path, o.Size))
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     s.path,
+		WorkDir:  s.workDir,
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// Statistical implements Storager.Statistical
+func (s *Storage) Statistical() (m metadata.Metadata, err error) {
+	var size, count int64
+	s.index.Walk(s.workDir, func(e *archive.Entry) {
+		size += e.Size
+		count++
+	})
+
+	m = make(metadata.Metadata)
+	m.SetSize(size)
+	m.SetCount(count)
+	return m, nil
+}
+
+// List implements Storager.List
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	es, ok := s.index.List(s.getAbsPath(path))
+	if !ok {
+		return types.NewError(s, "List", types.ErrObjectNotExist, path)
+	}
+
+	for _, e := range es {
+		o := e.Format()
+		o.Name = joinPath(path, e.Name)
+
+		if o.Type == types.ObjectTypeDir {
+			if opt.HasDirFunc {
+				opt.DirFunc(o)
+			}
+			continue
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	e, err := s.getFile(path)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	size := e.Size - opt.Offset
+	if opt.HasSize && opt.Size < size {
+		size = opt.Size
+	}
+	if size <= 0 {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	r, err = s.store.Read(s.path, ps.WithOffset(e.Value.(int64)+opt.Offset), ps.WithSize(size))
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// tar storager is read-only, types.ErrReadOnly will always be returned.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	return types.NewError(s, "Write", types.ErrReadOnly, path)
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	e, ok := s.index.Get(s.getAbsPath(path))
+	if !ok {
+		return nil, types.NewError(s, "Stat", types.ErrObjectNotExist, path)
+	}
+
+	o = e.Format()
+	o.Name = path
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// tar storager is read-only, types.ErrReadOnly will always be returned.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	return types.NewError(s, "Delete", types.ErrReadOnly, path)
+}
+
+// getFile will return the file entry for path.
+func (s *Storage) getFile(path string) (*archive.Entry, error) {
+	e, ok := s.index.Get(s.getAbsPath(path))
+	if !ok {
+		return nil, types.ErrObjectNotExist
+	}
+	if e.Dir {
+		return nil, fmt.Errorf("%s is a dir: %w", path, types.ErrInvalidPath)
+	}
+	return e, nil
+}
+
+// buildIndex will scan all tar headers in r, data will be skipped via Seek.
+func buildIndex(r *archive.ReaderAt) (*archive.Index, error) {
+	index := archive.NewIndex()
+
+	sr := io.NewSectionReader(r, 0, r.Size())
+	tr := tar.NewReader(sr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar header: %w", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			index.Add(&archive.Entry{Name: hdr.Name, Dir: true, UpdatedAt: hdr.ModTime})
+		case tar.TypeReg:
+			// tar reader reads headers without buffering, so current offset is where the data starts.
+			offset, err := sr.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			index.Add(&archive.Entry{Name: hdr.Name, Size: hdr.Size, UpdatedAt: hdr.ModTime, Value: offset})
+		}
+	}
+}
+
+// openLocal will open local file p via a fs storager.
+func openLocal(p string) (store storage.Storager, name string, err error) {
+	p, err = filepath.Abs(p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fsStore := fs.New()
+	err = fsStore.Init(ps.WithWorkDir(filepath.Dir(p)))
+	if err != nil {
+		return nil, "", err
+	}
+	return fsStore, filepath.Base(p), nil
+}
@@ -0,0 +1,111 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/pkg/archive/archivetest"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// newTestArchive will create a tar archive in dir with following entries:
+//
+//	a/
+//	a/b/test_file
+//	c
+//	link -> c
+func newTestArchive(t *testing.T, dir string) string {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	headers := []*tar.Header{
+		{Name: "a/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: archivetest.ModTime},
+		{Name: "a/b/test_file", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(archivetest.Content)), ModTime: archivetest.ModTime},
+		{Name: "./c", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(archivetest.Content)), ModTime: archivetest.ModTime},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "c", ModTime: archivetest.ModTime},
+	}
+	for _, hdr := range headers {
+		err := tw.WriteHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			_, err = tw.Write(archivetest.Content)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(dir, "test.tar")
+	err = ioutil.WriteFile(p, buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newTestArchive(t, dir)
+
+	fsStore := fs.New()
+	err = fsStore.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		pairs []*types.Pair
+	}{
+		{"local", []*types.Pair{pairs.WithPath(p)}},
+		{"storager", []*types.Pair{pairs.WithStorager(fsStore), pairs.WithPath("test.tar")}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			store := New()
+			err := store.Init(tt.pairs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			archivetest.TestStorager(t, store)
+
+			// Symlinks are skipped while building index.
+			_, err = store.Stat("link")
+			assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+		})
+	}
+}
+
+func TestStorage_Init(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newTestArchive(t, dir)
+
+	archivetest.TestInit(t, func() archivetest.Storager { return New() }, p, filepath.Join(dir, "not_exist.tar"))
+}
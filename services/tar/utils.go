@@ -0,0 +1,15 @@
+package tar
+
+import (
+	"path"
+	"strings"
+)
+
+func (s *Storage) getAbsPath(p string) string {
+	return path.Join(s.workDir, p)
+}
+
+// joinPath will join dir and entry's base name into an object name which is relative to work dir.
+func joinPath(dir, name string) string {
+	return strings.TrimPrefix(path.Join(dir, path.Base(name)), "/")
+}
@@ -0,0 +1,11 @@
+/*
+Package tier provided a storager which places objects into a hot tier or a cold tier.
+
+Objects will always be written into the hot tier, and Policy will move them between tiers in Run, which could
+be called periodically by Serve as a background job. Paths will not change after objects have been moved.
+
+Tiers of cold objects and last access time of objects will be recorded in a small index, which could be
+persisted into a local file via index_path. Read, Stat and Delete will try the indexed tier first, and fall
+back to the other one, so objects can still be found if the index is lost.
+*/
+package tier
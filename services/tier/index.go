@@ -0,0 +1,131 @@
+package tier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// entry is the record of an object in index.
+type entry struct {
+	Cold       bool      `json:"cold,omitempty"`
+	AccessedAt time.Time `json:"accessed_at,omitempty"`
+}
+
+// index will record tiers and last access time of objects, it will only be kept in memory if path is empty.
+type index struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func loadIndex(path string) (idx *index, err error) {
+	idx = &index{
+		path:    path,
+		entries: make(map[string]*entry),
+	}
+	if path == "" {
+		return idx, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, &idx.entries)
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// save will write index into a temp file and rename it, so that the index file is always complete.
+func (idx *index) save() (err error) {
+	if idx.path == "" {
+		return nil
+	}
+
+	idx.mu.Lock()
+	content, err := json.Marshal(idx.entries)
+	idx.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(idx.path), filepath.Base(idx.path))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), idx.path)
+}
+
+// get will return a copy of entry for path, zero value will be returned if not exist.
+func (idx *index) get(path string) entry {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if e, ok := idx.entries[path]; ok {
+		return *e
+	}
+	return entry{}
+}
+
+func (idx *index) setCold(path string, cold bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	e, ok := idx.entries[path]
+	if !ok {
+		e = &entry{}
+		idx.entries[path] = e
+	}
+	e.Cold = cold
+}
+
+func (idx *index) touch(path string, t time.Time) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	e, ok := idx.entries[path]
+	if !ok {
+		e = &entry{}
+		idx.entries[path] = e
+	}
+	e.AccessedAt = t
+}
+
+func (idx *index) remove(path string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	delete(idx.entries, path)
+}
+
+// cold will return paths of all cold objects.
+func (idx *index) cold() []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	paths := make([]string, 0)
+	for k, v := range idx.entries {
+		if v.Cold {
+			paths = append(paths, k)
+		}
+	}
+	return paths
+}
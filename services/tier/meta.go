@@ -0,0 +1,119 @@
+// Code generated by go generate via internal/cmd/meta; DO NOT EDIT.
+package tier
+
+import (
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/segment"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+var _ credential.Provider
+var _ endpoint.Provider
+var _ segment.Segment
+var _ storage.Storager
+var _ time.Duration
+
+// Type is the type for tier
+const Type = "tier"
+
+var allowedStoragePairs = map[string]map[string]struct{}{
+	"init": {
+		"cold_storager": struct{}{},
+		"index_path":    struct{}{},
+		"storager":      struct{}{},
+	},
+	"list": {
+		"dir_func":  struct{}{},
+		"file_func": struct{}{},
+	},
+}
+
+var allowedServicePairs = map[string]map[string]struct{}{}
+
+type pairStorageInit struct {
+	HasColdStorager bool
+	ColdStorager    storage.Storager
+	HasIndexPath    bool
+	IndexPath       string
+	HasStorager     bool
+	Storager        storage.Storager
+}
+
+func parseStoragePairInit(opts ...*types.Pair) (*pairStorageInit, error) {
+	result := &pairStorageInit{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["init"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["init"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.ColdStorager]
+	if !ok {
+		return nil, types.NewErrPairRequired(pairs.ColdStorager)
+	}
+	if ok {
+		result.HasColdStorager = true
+		result.ColdStorager = v.(storage.Storager)
+	}
+	v, ok = values[pairs.IndexPath]
+	if ok {
+		result.HasIndexPath = true
+		result.IndexPath = v.(string)
+	}
+	v, ok = values[pairs.Storager]
+	if !ok {
+		return nil, types.NewErrPairRequired(pairs.Storager)
+	}
+	if ok {
+		result.HasStorager = true
+		result.Storager = v.(storage.Storager)
+	}
+	return result, nil
+}
+
+type pairStorageList struct {
+	HasDirFunc  bool
+	DirFunc     types.ObjectFunc
+	HasFileFunc bool
+	FileFunc    types.ObjectFunc
+}
+
+func parseStoragePairList(opts ...*types.Pair) (*pairStorageList, error) {
+	result := &pairStorageList{}
+
+	values := make(map[string]interface{})
+	for _, v := range opts {
+		if _, ok := allowedStoragePairs["list"]; !ok {
+			continue
+		}
+		if _, ok := allowedStoragePairs["list"][v.Key]; !ok {
+			continue
+		}
+		values[v.Key] = v.Value
+	}
+	var v interface{}
+	var ok bool
+	v, ok = values[pairs.DirFunc]
+	if ok {
+		result.HasDirFunc = true
+		result.DirFunc = v.(types.ObjectFunc)
+	}
+	v, ok = values[pairs.FileFunc]
+	if ok {
+		result.HasFileFunc = true
+		result.FileFunc = v.(types.ObjectFunc)
+	}
+	return result, nil
+}
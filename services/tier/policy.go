@@ -0,0 +1,55 @@
+package tier
+
+import (
+	"time"
+
+	"github.com/Xuanwo/storage/types"
+)
+
+// Policy decides which tier an object should be placed in.
+//
+// Hot objects will be moved into cold tier only if all enabled conditions are satisfied, nothing will be moved
+// if no condition is enabled.
+type Policy struct {
+	// UpdatedBefore will move objects which have not been updated for it into cold tier, 0 means disabled.
+	UpdatedBefore time.Duration
+	// AccessedBefore will move objects which have not been read for it into cold tier, 0 means disabled.
+	//
+	// Objects which have never been read since written will use UpdatedAt as last access time.
+	AccessedBefore time.Duration
+	// MinSize will keep objects smaller than it in hot tier.
+	MinSize int64
+
+	// AccessedWithin will move cold objects which have been read within it back into hot tier, 0 means disabled.
+	//
+	// It should be shorter than AccessedBefore, or objects could be moved back and forth.
+	AccessedWithin time.Duration
+}
+
+// cold will check whether a hot object should be moved into cold tier.
+func (p *Policy) cold(o *types.Object, accessedAt, now time.Time) bool {
+	if p.UpdatedBefore <= 0 && p.AccessedBefore <= 0 {
+		return false
+	}
+	if o.Size < p.MinSize {
+		return false
+	}
+	if p.UpdatedBefore > 0 && now.Sub(o.UpdatedAt) < p.UpdatedBefore {
+		return false
+	}
+	if accessedAt.IsZero() {
+		accessedAt = o.UpdatedAt
+	}
+	if p.AccessedBefore > 0 && now.Sub(accessedAt) < p.AccessedBefore {
+		return false
+	}
+	return true
+}
+
+// hot will check whether a cold object should be moved back into hot tier.
+func (p *Policy) hot(accessedAt, now time.Time) bool {
+	if p.AccessedWithin <= 0 || accessedAt.IsZero() {
+		return false
+	}
+	return now.Sub(accessedAt) < p.AccessedWithin
+}
@@ -0,0 +1,43 @@
+package tier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/types"
+)
+
+func TestPolicy(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	o := &types.Object{Size: 100, UpdatedAt: now.Add(-10 * day)}
+
+	cases := []struct {
+		name       string
+		policy     Policy
+		accessedAt time.Time
+		expected   bool
+	}{
+		{"no condition", Policy{}, time.Time{}, false},
+		{"updated before", Policy{UpdatedBefore: 7 * day}, time.Time{}, true},
+		{"updated recently", Policy{UpdatedBefore: 30 * day}, time.Time{}, false},
+		{"never accessed", Policy{AccessedBefore: 7 * day}, time.Time{}, true},
+		{"accessed recently", Policy{AccessedBefore: 7 * day}, now.Add(-day), false},
+		{"too small", Policy{UpdatedBefore: 7 * day, MinSize: 1000}, time.Time{}, false},
+		{"all conditions", Policy{UpdatedBefore: 7 * day, AccessedBefore: 7 * day}, now.Add(-day), false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.cold(o, tt.accessedAt, now))
+		})
+	}
+
+	p := Policy{AccessedWithin: day}
+	assert.True(t, p.hot(now.Add(-time.Hour), now))
+	assert.False(t, p.hot(now.Add(-2*day), now))
+	assert.False(t, p.hot(time.Time{}, now))
+	assert.False(t, (&Policy{}).hot(now, now))
+}
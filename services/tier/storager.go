@@ -0,0 +1,323 @@
+package tier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+const (
+	hot = iota
+	cold
+)
+
+// Storage is the tier storager.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	// tiers are indexed by hot and cold.
+	tiers [2]storage.Storager
+	index *index
+
+	// locks will serialize Write, Delete and move on the same key, keys are hashed into them.
+	locks [64]sync.Mutex
+}
+
+// New will create a tier storager.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager tier {Hot: %s, Cold: %s}", s.tiers[hot], s.tiers[cold])
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.tiers[hot], s.tiers[cold] = opt.Storager, opt.ColdStorager
+
+	indexPath := ""
+	if opt.HasIndexPath {
+		indexPath = opt.IndexPath
+	}
+	s.index, err = loadIndex(indexPath)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+//
+// Hot tier's metadata will be returned.
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m, err = s.tiers[hot].Metadata()
+	if err != nil {
+		return m, types.NewError(s, "Metadata", err)
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+//
+// Objects will be merged from both tiers by name.
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	seen := make(map[string]struct{})
+	visit := func(fn types.ObjectFunc) types.ObjectFunc {
+		return func(o *types.Object) {
+			if _, ok := seen[o.Name]; ok {
+				return
+			}
+			seen[o.Name] = struct{}{}
+			fn(o)
+		}
+	}
+
+	listPairs := make([]*types.Pair, 0, 2)
+	if opt.HasFileFunc {
+		listPairs = append(listPairs, ps.WithFileFunc(visit(opt.FileFunc)))
+	}
+	if opt.HasDirFunc {
+		listPairs = append(listPairs, ps.WithDirFunc(visit(opt.DirFunc)))
+	}
+
+	found := false
+	for _, store := range s.tiers {
+		err = store.List(path, listPairs...)
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return types.NewError(s, "List", err, path)
+		}
+		found = found || err == nil
+	}
+	if !found {
+		return types.NewError(s, "List", types.ErrObjectNotExist, path)
+	}
+	return nil
+}
+
+// Read implements Storager.Read
+//
+// Access time will be recorded in index, and persisted in the next Run.
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	err = s.lookup(path, func(store storage.Storager) (err error) {
+		r, err = store.Read(path, pairs...)
+		return
+	})
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	s.index.touch(storageutil.CleanPath(path), time.Now())
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// Object will always be written into hot tier, and the old one in cold tier will be deleted.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	key := storageutil.CleanPath(path)
+	defer s.lock(key)()
+
+	err = s.tiers[hot].Write(path, r, pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	if !s.index.get(key).Cold {
+		return nil
+	}
+
+	s.index.setCold(key, false)
+	err = s.index.save()
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	err = deleteIfExists(s.tiers[cold], path)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	err = s.lookup(path, func(store storage.Storager) (err error) {
+		o, err = store.Stat(path, pairs...)
+		return
+	})
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// Object will be deleted from both tiers, ErrObjectNotExist will only be returned if it doesn't exist in both.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	key := storageutil.CleanPath(path)
+	defer s.lock(key)()
+
+	found := false
+	for _, store := range s.tiers {
+		err = store.Delete(path, pairs...)
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return types.NewError(s, "Delete", err, path)
+		}
+		found = found || err == nil
+	}
+
+	s.index.remove(key)
+	err = s.index.save()
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+
+	if !found {
+		return types.NewError(s, "Delete", types.ErrObjectNotExist, path)
+	}
+	return nil
+}
+
+// Run will move objects between tiers by policy once, and persist the index.
+func (s *Storage) Run(p Policy) (err error) {
+	now := time.Now()
+
+	objects := make([]*types.Object, 0)
+	err = storageutil.Walk(s.tiers[hot], "", func(o *types.Object) {
+		objects = append(objects, o)
+	}, nil)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return types.NewError(s, "Run", err)
+	}
+
+	for _, o := range objects {
+		e := s.index.get(storageutil.CleanPath(o.Name))
+		// Object is already in cold tier, the one in hot tier is left by an interrupted move.
+		if e.Cold || !p.cold(o, e.AccessedAt, now) {
+			continue
+		}
+		err = s.move(o.Name, hot, cold, o)
+		if err != nil {
+			return types.NewError(s, "Run", err, o.Name)
+		}
+	}
+
+	for _, path := range s.index.cold() {
+		if !p.hot(s.index.get(path).AccessedAt, now) {
+			continue
+		}
+		err = s.move(path, cold, hot, nil)
+		if err != nil {
+			return types.NewError(s, "Run", err, path)
+		}
+	}
+
+	err = s.index.save()
+	if err != nil {
+		return types.NewError(s, "Run", err)
+	}
+	return nil
+}
+
+// Serve will call Run every interval until ctx is done, errors returned by Run will be passed to fn if not nil.
+func (s *Storage) Serve(ctx context.Context, interval time.Duration, p Policy, fn func(err error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			err := s.Run(p)
+			if err != nil && fn != nil {
+				fn(err)
+			}
+		}
+	}
+}
+
+// move will copy object into tier to, record it in index, and then delete it from tier from.
+//
+// Index will be saved before deleting, so that the object can always be found via index. If o is not nil,
+// object will be skipped if it has been changed or deleted since o was listed.
+func (s *Storage) move(path string, from, to int, o *types.Object) (err error) {
+	key := storageutil.CleanPath(path)
+	defer s.lock(key)()
+
+	if o != nil {
+		cur, err := s.tiers[from].Stat(path)
+		if errors.Is(err, types.ErrObjectNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if cur.Size != o.Size || !cur.UpdatedAt.Equal(o.UpdatedAt) {
+			return nil
+		}
+	} else if !s.index.get(key).Cold {
+		// Object has been written into hot tier or deleted since index was read.
+		return nil
+	}
+
+	_, err = storageutil.CopyObject(s.tiers[from], path, s.tiers[to], path)
+	if err != nil {
+		return err
+	}
+
+	s.index.setCold(key, to == cold)
+	err = s.index.save()
+	if err != nil {
+		return err
+	}
+	return deleteIfExists(s.tiers[from], path)
+}
+
+// lock will lock the mutex of key and return the unlock func.
+func (s *Storage) lock(key string) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	l := &s.locks[h.Sum32()%uint32(len(s.locks))]
+	l.Lock()
+	return l.Unlock
+}
+
+// lookup will call fn on the indexed tier first, and fall back to the other tier if object doesn't exist.
+func (s *Storage) lookup(path string, fn func(store storage.Storager) error) (err error) {
+	first, second := hot, cold
+	if s.index.get(storageutil.CleanPath(path)).Cold {
+		first, second = cold, hot
+	}
+
+	err = fn(s.tiers[first])
+	if !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+	if serr := fn(s.tiers[second]); !errors.Is(serr, types.ErrObjectNotExist) {
+		return serr
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+package tier
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storagetest"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func newTestTier(t *testing.T) (string, storage.Storager) {
+	dir, err := ioutil.TempDir("", "tier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, store
+}
+
+func exists(dir, path string) bool {
+	_, err := os.Stat(filepath.Join(dir, path))
+	return err == nil
+}
+
+func TestStorage(t *testing.T) {
+	hotDir, hotStore := newTestTier(t)
+	defer os.RemoveAll(hotDir)
+	coldDir, coldStore := newTestTier(t)
+	defer os.RemoveAll(coldDir)
+	indexDir, err := ioutil.TempDir("", "tier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(indexDir)
+	indexPath := filepath.Join(indexDir, "index.json")
+
+	newStorage := func() *Storage {
+		store := New()
+		err := store.Init(
+			pairs.WithStorager(hotStore),
+			pairs.WithColdStorager(coldStore),
+			pairs.WithIndexPath(indexPath),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+	store := newStorage()
+
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for _, v := range []string{"old", "dir/old", "new"} {
+		err = store.Write(v, bytes.NewReader([]byte(v)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "new" {
+			err = os.Chtimes(filepath.Join(hotDir, v), old, old)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	p := Policy{
+		UpdatedBefore:  7 * 24 * time.Hour,
+		AccessedWithin: time.Hour,
+	}
+
+	t.Run("move to cold", func(t *testing.T) {
+		err := store.Run(p)
+		assert.NoError(t, err)
+
+		for _, v := range []string{"old", "dir/old"} {
+			assert.False(t, exists(hotDir, v))
+			assert.True(t, exists(coldDir, v))
+		}
+		assert.True(t, exists(hotDir, "new"))
+		assert.False(t, exists(coldDir, "new"))
+	})
+
+	t.Run("find", func(t *testing.T) {
+		// A new storager should find cold objects via persisted index.
+		store := newStorage()
+		assert.True(t, store.index.get("dir/old").Cold)
+
+		o, err := store.Stat("dir/old")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len("dir/old")), o.Size)
+
+		files := make([]string, 0)
+		err = store.List("", pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"old", "new"}, files)
+
+		_, err = store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("fall back", func(t *testing.T) {
+		store := New()
+		err := store.Init(pairs.WithStorager(hotStore), pairs.WithColdStorager(coldStore))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "old", storagetest.Read(t, store, "old"))
+	})
+
+	t.Run("move to hot", func(t *testing.T) {
+		assert.Equal(t, "dir/old", storagetest.Read(t, store, "dir/old"))
+
+		err := store.Run(p)
+		assert.NoError(t, err)
+		assert.True(t, exists(hotDir, "dir/old"))
+		assert.False(t, exists(coldDir, "dir/old"))
+		assert.False(t, store.index.get("dir/old").Cold)
+	})
+
+	t.Run("write and delete", func(t *testing.T) {
+		err := store.Write("old", bytes.NewReader([]byte("updated")))
+		assert.NoError(t, err)
+		assert.False(t, exists(coldDir, "old"))
+		assert.Equal(t, "updated", storagetest.Read(t, store, "old"))
+
+		err = store.Delete("old")
+		assert.NoError(t, err)
+		assert.False(t, exists(hotDir, "old"))
+
+		err = store.Delete("old")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("write while moving", func(t *testing.T) {
+		err := store.Write("stale", bytes.NewReader([]byte("stale")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Chtimes(filepath.Join(hotDir, "stale"), old, old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listed, err := hotStore.Stat("stale")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Object is rewritten after it has been listed by Run.
+		err = store.Write("stale", bytes.NewReader([]byte("updated")))
+		assert.NoError(t, err)
+		err = store.move("stale", hot, cold, listed)
+		assert.NoError(t, err)
+		assert.True(t, exists(hotDir, "stale"))
+		assert.False(t, exists(coldDir, "stale"))
+		assert.Equal(t, "updated", storagetest.Read(t, store, "stale"))
+
+		// Object is deleted after it has been listed by Run.
+		err = store.Delete("stale")
+		assert.NoError(t, err)
+		err = store.move("stale", hot, cold, listed)
+		assert.NoError(t, err)
+		assert.False(t, exists(coldDir, "stale"))
+	})
+}
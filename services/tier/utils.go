@@ -0,0 +1,16 @@
+package tier
+
+import (
+	"errors"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/types"
+)
+
+func deleteIfExists(store storage.Storager, path string) (err error) {
+	err = store.Delete(path)
+	if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+/*
+Package trash provided a storager which turns Delete into a move into the trash.
+
+Deleted objects will be moved into ".trash/<timestamp>/<path>" of the underlying storager, via Mover if it's
+implemented, or by copying and deleting if not. ListTrash, Restore and Purge could be used to list, undelete
+and clean up trashed objects, so that all services could have a uniform undelete even without native
+versioning.
+
+The trash dir will be hidden while listing the root dir.
+*/
+package trash
@@ -0,0 +1,300 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// trashDir is the dir in underlying storager to keep deleted objects.
+const trashDir = ".trash"
+
+// Entry is an object in the trash.
+type Entry struct {
+	// ID is the timestamp dir which the object is kept in.
+	ID        string
+	Path      string
+	DeletedAt time.Time
+	Size      int64
+}
+
+// Storage is the trash storager.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	store storage.Storager
+}
+
+// New will create a trash storager.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager trash {Storager: %s}", s.store)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.store = opt.Storager
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m, err = s.store.Metadata()
+	if err != nil {
+		return m, types.NewError(s, "Metadata", err)
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+//
+// The trash dir will be skipped while listing the root dir.
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	err = checkPath(path)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	root := storageutil.CleanPath(path) == ""
+	visit := func(fn types.ObjectFunc) types.ObjectFunc {
+		return func(o *types.Object) {
+			if root && storageutil.CleanPath(o.Name) == trashDir {
+				return
+			}
+			fn(o)
+		}
+	}
+
+	listPairs := make([]*types.Pair, 0, 2)
+	if opt.HasFileFunc {
+		listPairs = append(listPairs, ps.WithFileFunc(visit(opt.FileFunc)))
+	}
+	if opt.HasDirFunc {
+		listPairs = append(listPairs, ps.WithDirFunc(visit(opt.DirFunc)))
+	}
+
+	err = s.store.List(path, listPairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+	return nil
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	err = checkPath(path)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	r, err = s.store.Read(path, pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	err = checkPath(path)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	err = s.store.Write(path, r, pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	err = checkPath(path)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+
+	o, err = s.store.Stat(path, pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// File will be moved into the trash, dir will be deleted directly for there is nothing to keep.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	err = checkPath(path)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+
+	o, err := s.store.Stat(path)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	if o.Type == types.ObjectTypeDir {
+		err = s.store.Delete(path, pairs...)
+		if err != nil {
+			return types.NewError(s, "Delete", err, path)
+		}
+		return nil
+	}
+
+	err = moveObject(s.store, path, s.getTrashPath(newID(time.Now()), path))
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	return nil
+}
+
+// ListTrash will call fn on all entries in the trash, entries deleted earlier will be visited first.
+func (s *Storage) ListTrash(fn func(e *Entry)) (err error) {
+	entries, _, err := s.entries()
+	if err != nil {
+		return types.NewError(s, "ListTrash", err)
+	}
+
+	for _, e := range entries {
+		fn(e)
+	}
+	return nil
+}
+
+// Restore will move the entry with id and path back, existing object at path will be overwritten.
+func (s *Storage) Restore(id, path string) (err error) {
+	if _, err = parseID(id); err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+	err = checkPath(path)
+	if err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+
+	err = moveObject(s.store, s.getTrashPath(id, path), path)
+	if err != nil {
+		return types.NewError(s, "Restore", err, path)
+	}
+	return nil
+}
+
+// Purge will delete entries which have been kept in the trash longer than retention permanently.
+func (s *Storage) Purge(retention time.Duration) (err error) {
+	entries, dirs, err := s.entries()
+	if err != nil {
+		return types.NewError(s, "Purge", err)
+	}
+
+	deadline := time.Now().Add(-retention)
+	purged := make(map[string]bool)
+	for _, e := range entries {
+		if !e.DeletedAt.Before(deadline) {
+			continue
+		}
+
+		err = s.store.Delete(s.getTrashPath(e.ID, e.Path))
+		if err != nil && !errors.Is(err, types.ErrObjectNotExist) {
+			return types.NewError(s, "Purge", err, e.Path)
+		}
+		purged[e.ID] = true
+	}
+
+	// Dirs only exist in services like fs, they will be deleted from the deepest one, errors will be ignored
+	// for they will be cleaned up in the next Purge.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		id := strings.SplitN(strings.TrimPrefix(storageutil.CleanPath(dirs[i]), trashDir+"/"), "/", 2)[0]
+		if purged[id] {
+			_ = s.store.Delete(dirs[i])
+		}
+	}
+	return nil
+}
+
+// entries will walk the trash and return all entries ordered by deleted time, and all dirs visited.
+func (s *Storage) entries() (entries []*Entry, dirs []string, err error) {
+	entries = make([]*Entry, 0)
+
+	dirs = []string{trashDir}
+	err = storageutil.Walk(s.store, trashDir, func(o *types.Object) {
+		parts := strings.SplitN(strings.TrimPrefix(storageutil.CleanPath(o.Name), trashDir+"/"), "/", 2)
+		if len(parts) != 2 {
+			return
+		}
+		t, err := parseID(parts[0])
+		if err != nil {
+			return
+		}
+		entries = append(entries, &Entry{
+			ID:        parts[0],
+			Path:      parts[1],
+			DeletedAt: t,
+			Size:      o.Size,
+		})
+	}, func(o *types.Object) {
+		dirs = append(dirs, o.Name)
+	})
+	// Trash will not exist before anything deleted.
+	if errors.Is(err, types.ErrObjectNotExist) {
+		return entries, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, dirs, nil
+}
+
+// checkPath will reject paths under the trash dir, so that entries could only be operated via trash methods.
+func checkPath(p string) (err error) {
+	p = storageutil.CleanPath(p)
+	if p == trashDir || strings.HasPrefix(p, trashDir+"/") {
+		return fmt.Errorf("%w: %s is reserved for trash", types.ErrInvalidPath, p)
+	}
+	return nil
+}
+
+func (s *Storage) getTrashPath(id, p string) string {
+	return path.Join(trashDir, id, storageutil.CleanPath(p))
+}
+
+// newID will format t as an entry id, ids have the same width so that they can be ordered by string.
+func newID(t time.Time) string {
+	return fmt.Sprintf("%019d", t.UnixNano())
+}
+
+func parseID(id string) (t time.Time, err error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || len(id) != 19 {
+		return t, fmt.Errorf("%w: invalid trash id %s", types.ErrObjectNotExist, id)
+	}
+	return time.Unix(0, n), nil
+}
@@ -0,0 +1,153 @@
+package trash
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// plainStorager hides Mover of the underlying storager.
+type plainStorager struct {
+	storage.Storager
+}
+
+func newTestStore(t *testing.T) (string, *fs.Storage) {
+	dir, err := ioutil.TempDir("", "trash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := fs.New()
+	err = store.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, store
+}
+
+func TestStorage(t *testing.T) {
+	dir, fsStore := newTestStore(t)
+	defer os.RemoveAll(dir)
+
+	for _, v := range []struct {
+		name  string
+		store storage.Storager
+	}{
+		{"mover", fsStore},
+		{"copy and delete", plainStorager{fsStore}},
+	} {
+		v := v
+
+		t.Run(v.name, func(t *testing.T) {
+			store := New()
+			err := store.Init(pairs.WithStorager(v.store))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = store.Write("a/b", bytes.NewReader([]byte("b")))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = store.Delete("a/b")
+			assert.NoError(t, err)
+			_, err = store.Stat("a/b")
+			assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+			names := make([]string, 0)
+			err = store.List("", pairs.WithDirFunc(func(o *types.Object) {
+				names = append(names, o.Name)
+			}))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"a"}, names)
+
+			entries := make([]*Entry, 0)
+			err = store.ListTrash(func(e *Entry) {
+				entries = append(entries, e)
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(entries))
+			assert.Equal(t, "a/b", entries[0].Path)
+			assert.Equal(t, int64(1), entries[0].Size)
+
+			err = store.Restore(entries[0].ID, "a/b")
+			assert.NoError(t, err)
+			o, err := store.Stat("a/b")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), o.Size)
+
+			err = store.Delete("a/b")
+			assert.NoError(t, err)
+
+			err = store.Purge(time.Hour)
+			assert.NoError(t, err)
+			_, err = os.Stat(filepath.Join(dir, trashDir))
+			assert.NoError(t, err)
+
+			err = store.Purge(0)
+			assert.NoError(t, err)
+			err = store.ListTrash(func(e *Entry) {
+				t.Errorf("entry %s should be purged", e.Path)
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestStorage_Restore(t *testing.T) {
+	dir, fsStore := newTestStore(t)
+	defer os.RemoveAll(dir)
+
+	store := New()
+	err := store.Init(pairs.WithStorager(fsStore))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Restore("invalid", "a")
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+	err = store.Restore(newID(time.Now()), "a")
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+
+	err = store.Delete("a")
+	assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+}
+
+func TestStorage_TrashPath(t *testing.T) {
+	dir, fsStore := newTestStore(t)
+	defer os.RemoveAll(dir)
+
+	store := New()
+	err := store.Init(pairs.WithStorager(fsStore))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []string{".trash", "/.trash/0/a", "a/../.trash/0/a"} {
+		err = store.Write(v, bytes.NewReader([]byte("a")))
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		_, err = store.Read(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		_, err = store.Stat(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = store.Delete(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = store.List(v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+		err = store.Restore(newID(time.Now()), v)
+		assert.True(t, errors.Is(err, types.ErrInvalidPath), v)
+	}
+}
@@ -0,0 +1,19 @@
+package trash
+
+import (
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+)
+
+// moveObject will move object via Mover if store implements it, or copy and delete it if not.
+func moveObject(store storage.Storager, src, dst string) (err error) {
+	if m, ok := store.(storage.Mover); ok {
+		return m.Move(src, dst)
+	}
+
+	_, err = storageutil.CopyObject(store, src, store, dst)
+	if err != nil {
+		return err
+	}
+	return store.Delete(src)
+}
@@ -0,0 +1,7 @@
+/*
+Package webdav provided support for WebDAV servers like Nextcloud.
+
+Credential is optional: hmac credential will be sent via basic auth, and apikey credential will be sent as
+bearer token.
+*/
+package webdav
@@ -0,0 +1,345 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xuanwo/storage/pkg/httputil"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// Storage is the webdav client.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	client *httputil.Client
+}
+
+// New will create a webdav client.
+func New() *Storage {
+	return &Storage{
+		client: httputil.NewClient(),
+	}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager webdav {Endpoint: %s, WorkDir: %s}", s.client.Endpoint, s.client.WorkDir)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.client.Endpoint, err = url.Parse(opt.Endpoint.Value().String())
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+	if opt.HasWorkDir {
+		s.client.WorkDir = opt.WorkDir
+	}
+
+	if opt.HasCredential {
+		s.client.Authorization, err = httputil.Authorization(opt.Credential)
+		if err != nil {
+			return types.NewError(s, "Init", err)
+		}
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     "",
+		WorkDir:  s.client.WorkDir,
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// List implements Storager.List
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	responses, err := s.propfind(s.client.DirURL(path), "1")
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	for _, v := range responses {
+		o, err := s.formatObject(v)
+		if err != nil {
+			return types.NewError(s, "List", err, path)
+		}
+		// PROPFIND will return the dir itself.
+		if o.Name == strings.Trim(path, "/") {
+			continue
+		}
+
+		if o.Type == types.ObjectTypeDir {
+			if opt.HasDirFunc {
+				opt.DirFunc(o)
+			}
+			continue
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	r, err = s.client.Read(s.client.FileURL(path), opt.Offset, opt.Size)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairWrite(pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	err = s.createDir(parentDir(path))
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	if opt.HasSize {
+		r = iowrap.SizeReader(r, opt.Size)
+	}
+	req, err := s.client.NewRequest(http.MethodPut, s.client.FileURL(path), r)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	if opt.HasSize {
+		req.ContentLength = opt.Size
+	}
+	if opt.HasContentType {
+		req.Header.Set("Content-Type", opt.ContentType)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	if err = httputil.CheckResponse(resp, http.StatusOK, http.StatusCreated, http.StatusNoContent); err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	responses, err := s.propfind(s.client.FileURL(path), "0")
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	if len(responses) == 0 {
+		return nil, types.NewError(s, "Stat", types.ErrObjectNotExist, path)
+	}
+
+	o, err = s.formatObject(responses[0])
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+	o.Name = path
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	req, err := s.client.NewRequest(http.MethodDelete, s.client.FileURL(path), nil)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	if err = httputil.CheckResponse(resp, http.StatusOK, http.StatusNoContent); err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// Copy implements Storager.Copy
+func (s *Storage) Copy(src, dst string, pairs ...*types.Pair) (err error) {
+	err = s.transfer("COPY", src, dst)
+	if err != nil {
+		return types.NewError(s, "Copy", err, src, dst)
+	}
+	return nil
+}
+
+// Move implements Storager.Move
+func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
+	err = s.transfer("MOVE", src, dst)
+	if err != nil {
+		return types.NewError(s, "Move", err, src, dst)
+	}
+	return nil
+}
+
+// transfer will send a COPY or MOVE request from src to dst, dst will be overwritten if exists.
+func (s *Storage) transfer(method, src, dst string) (err error) {
+	err = s.createDir(parentDir(dst))
+	if err != nil {
+		return
+	}
+
+	req, err := s.client.NewRequest(method, s.client.FileURL(src), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Destination", s.client.FileURL(dst))
+	req.Header.Set("Overwrite", "T")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return
+	}
+	if err = httputil.CheckResponse(resp, http.StatusCreated, http.StatusNoContent); err != nil {
+		return
+	}
+	return resp.Body.Close()
+}
+
+// createDir will create dir and all its parents via MKCOL.
+func (s *Storage) createDir(dir string) (err error) {
+	if dir == "" {
+		return nil
+	}
+
+	resp, err := s.mkcol(dir)
+	if err != nil {
+		return
+	}
+	// Parent dir is missing, create it and try again.
+	if resp.StatusCode == http.StatusConflict && parentDir(dir) != "" {
+		err = s.createDir(parentDir(dir))
+		if err != nil {
+			return
+		}
+		resp, err = s.mkcol(dir)
+		if err != nil {
+			return
+		}
+	}
+
+	// MKCOL on an existing resource will return 405.
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil
+	}
+	return httputil.CheckResponse(resp, http.StatusCreated)
+}
+
+// mkcol will send a MKCOL request for dir, response's body has been closed.
+func (s *Storage) mkcol(dir string) (*http.Response, error) {
+	req, err := s.client.NewRequest("MKCOL", s.client.DirURL(dir), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+// propfind will send a PROPFIND request with depth and parse the multistatus response.
+func (s *Storage) propfind(url, depth string) ([]*response, error) {
+	req, err := s.client.NewRequest("PROPFIND", url, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", depth)
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = httputil.CheckResponse(resp, http.StatusMultiStatus); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	ms := &multistatus{}
+	err = xml.NewDecoder(resp.Body).Decode(ms)
+	if err != nil {
+		return nil, fmt.Errorf("decode multistatus: %w", err)
+	}
+	return ms.Responses, nil
+}
+
+// formatObject will convert a PROPFIND response into object, name will be relative to work dir.
+func (s *Storage) formatObject(v *response) (o *types.Object, err error) {
+	href, err := url.Parse(v.Href)
+	if err != nil {
+		return nil, fmt.Errorf("parse href [%s]: %w", v.Href, err)
+	}
+
+	base := strings.TrimSuffix(s.client.AbsPath(""), "/") + "/"
+	o = &types.Object{
+		Name:     strings.Trim(strings.TrimPrefix(href.Path, base), "/"),
+		Type:     types.ObjectTypeFile,
+		Metadata: make(metadata.Metadata),
+	}
+
+	p, ok := v.prop()
+	if !ok {
+		return o, nil
+	}
+	if p.ResourceType.Collection != nil {
+		o.Type = types.ObjectTypeDir
+	}
+	if p.ContentLength != "" {
+		o.Size, err = strconv.ParseInt(p.ContentLength, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse content length [%s]: %w", p.ContentLength, err)
+		}
+	}
+	if p.LastModified != "" {
+		o.UpdatedAt, err = time.Parse(http.TimeFormat, p.LastModified)
+		if err != nil {
+			return nil, fmt.Errorf("parse last modified [%s]: %w", p.LastModified, err)
+		}
+	}
+	if p.ContentType != "" {
+		o.SetType(p.ContentType)
+	}
+	if p.ETag != "" {
+		o.SetChecksum(p.ETag)
+	}
+	return o, nil
+}
@@ -0,0 +1,217 @@
+package webdav
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/webdav"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+func newTestServer(t *testing.T) (dir string, srv *httptest.Server) {
+	dir, err := ioutil.TempDir("", "webdav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Work dir should be created before using.
+	err = os.Mkdir(filepath.Join(dir, "work"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.Dir(dir),
+		LockSystem: webdav.NewMemLS(),
+	}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "test_user" || pass != "test_password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	return dir, srv
+}
+
+func newTestStorage(t *testing.T, url, password string) *Storage {
+	ep, err := endpoint.ParseURL(url + "/dav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, err := credential.NewHmac("test_user", password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := New()
+	err = store.Init(
+		pairs.WithEndpoint(ep),
+		pairs.WithCredential(cred),
+		pairs.WithWorkDir("/work"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStorage(t *testing.T) {
+	dir, srv := newTestServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Close()
+
+	store := newTestStorage(t, srv.URL, "test_password")
+	content := []byte("0123456789")
+
+	t.Run("write", func(t *testing.T) {
+		err := store.Write("a/b/test file", bytes.NewReader(content), pairs.WithSize(int64(len(content))))
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "work", "a", "b", "test file"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("write with short input", func(t *testing.T) {
+		err := store.Write("short_input", bytes.NewReader(content), pairs.WithSize(20))
+		assert.True(t, errors.Is(err, iowrap.ErrShortInput))
+
+		// Partial content could be left as fs does.
+		_ = store.Delete("short_input")
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		o, err := store.Stat("a/b/test file")
+		assert.NoError(t, err)
+		assert.Equal(t, types.ObjectTypeFile, o.Type)
+		assert.Equal(t, int64(len(content)), o.Size)
+		assert.False(t, o.UpdatedAt.IsZero())
+
+		o, err = store.Stat("a")
+		assert.NoError(t, err)
+		assert.Equal(t, types.ObjectTypeDir, o.Type)
+	})
+
+	t.Run("stat not exist", func(t *testing.T) {
+		_, err := store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		r, err := store.Read("a/b/test file")
+		assert.NoError(t, err)
+		defer r.Close()
+
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("read with range", func(t *testing.T) {
+		r, err := store.Read("a/b/test file", pairs.WithOffset(2), pairs.WithSize(3))
+		assert.NoError(t, err)
+		defer r.Close()
+
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "234", string(b))
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		err := store.Copy("a/b/test file", "c/copied")
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "work", "c", "copied"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		err := store.Move("c/copied", "moved")
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, "work", "c", "copied"))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(dir, "work", "moved"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := make([]string, 0), make([]string, 0)
+		err := store.List("",
+			pairs.WithFileFunc(func(o *types.Object) {
+				files = append(files, o.Name)
+			}),
+			pairs.WithDirFunc(func(o *types.Object) {
+				dirs = append(dirs, o.Name)
+			}),
+		)
+		assert.NoError(t, err)
+		sort.Strings(dirs)
+		assert.Equal(t, []string{"moved"}, files)
+		assert.Equal(t, []string{"a", "c"}, dirs)
+
+		files = files[:0]
+		err = store.List("a/b", pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a/b/test file"}, files)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := store.Delete("moved")
+		assert.NoError(t, err)
+
+		err = store.Delete("moved")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		store := newTestStorage(t, srv.URL, "invalid_password")
+
+		_, err := store.Stat("a/b/test file")
+		assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+	})
+}
+
+func TestStorage_Init(t *testing.T) {
+	ep := endpoint.NewHTTP("127.0.0.1", 8080)
+
+	t.Run("apikey", func(t *testing.T) {
+		cred, err := credential.NewAPIKey("test_token")
+		assert.NoError(t, err)
+
+		store := New()
+		err = store.Init(pairs.WithEndpoint(ep), pairs.WithCredential(cred))
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer test_token", store.client.Authorization)
+	})
+
+	t.Run("without endpoint", func(t *testing.T) {
+		store := New()
+		err := store.Init()
+		assert.True(t, errors.Is(err, types.ErrPairRequired))
+	})
+}
+
+func TestParentDir(t *testing.T) {
+	assert.Equal(t, "", parentDir("a"))
+	assert.Equal(t, "a", parentDir("a/b"))
+	assert.Equal(t, "a/b", parentDir("/a/b/c/"))
+}
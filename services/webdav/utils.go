@@ -0,0 +1,64 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"path"
+	"strings"
+)
+
+// propfindBody will request all props we need.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:">
+  <d:prop>
+    <d:resourcetype/>
+    <d:getcontentlength/>
+    <d:getlastmodified/>
+    <d:getcontenttype/>
+    <d:getetag/>
+  </d:prop>
+</d:propfind>`
+
+type multistatus struct {
+	XMLName   xml.Name    `xml:"DAV: multistatus"`
+	Responses []*response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href     string     `xml:"DAV: href"`
+	Propstat []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"DAV: prop"`
+	Status string `xml:"DAV: status"`
+}
+
+type prop struct {
+	ResourceType struct {
+		Collection *struct{} `xml:"DAV: collection"`
+	} `xml:"DAV: resourcetype"`
+	ContentLength string `xml:"DAV: getcontentlength"`
+	LastModified  string `xml:"DAV: getlastmodified"`
+	ContentType   string `xml:"DAV: getcontenttype"`
+	ETag          string `xml:"DAV: getetag"`
+}
+
+// prop will return the prop which has been found successfully.
+func (r *response) prop() (*prop, bool) {
+	for _, v := range r.Propstat {
+		// Status is formatted as "HTTP/1.1 200 OK".
+		if strings.Contains(v.Status, " 200 ") {
+			return &v.Prop, true
+		}
+	}
+	return nil, false
+}
+
+// parentDir will return the parent dir of p, "" will be returned if p is at top level.
+func parentDir(p string) string {
+	dir := path.Dir(strings.Trim(p, "/"))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,10 @@
+/*
+Package webhdfs provided support for HDFS via WebHDFS REST API.
+
+Endpoint should be the address of namenode like "http:namenode:9870", and "/webhdfs/v1" will be used as
+the path prefix if endpoint's path is empty.
+
+Pair user will be sent as "user.name" for simple authentication, and apikey credential will be sent as
+delegation token.
+*/
+package webhdfs
@@ -0,0 +1,300 @@
+package webhdfs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/pkg/storageutil"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// defaultPathPrefix will be used if endpoint's path is empty.
+const defaultPathPrefix = "/webhdfs/v1"
+
+// Storage is the webhdfs client.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	// client will not follow redirects, so that we can send data to datanode by ourselves.
+	client   *http.Client
+	endpoint *url.URL
+
+	user       string
+	delegation string
+
+	workDir string
+}
+
+// New will create a webhdfs client.
+func New() *Storage {
+	return &Storage{
+		client: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager webhdfs {Endpoint: %s, WorkDir: %s}", s.endpoint, s.workDir)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.endpoint, err = url.Parse(opt.Endpoint.Value().String())
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+	if s.endpoint.Path == "" {
+		s.endpoint.Path = defaultPathPrefix
+	}
+
+	if opt.HasUser {
+		s.user = opt.User
+	}
+	if opt.HasCredential {
+		credProtocol, cred := opt.Credential.Protocol(), opt.Credential.Value()
+		if credProtocol != credential.ProtocolAPIKey {
+			return types.NewError(s, "Init", credential.ErrUnsupportedProtocol)
+		}
+		s.delegation = cred[0]
+	}
+
+	s.workDir = "/"
+	if opt.HasWorkDir {
+		s.workDir = path.Join("/", opt.WorkDir)
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     "",
+		WorkDir:  s.workDir,
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// Statistical implements Storager.Statistical
+func (s *Storage) Statistical() (m metadata.Metadata, err error) {
+	output := &contentSummaryOutput{}
+	err = s.do(http.MethodGet, s.workDir, "GETCONTENTSUMMARY", nil, output)
+	if err != nil {
+		return nil, types.NewError(s, "Statistical", err)
+	}
+
+	m = make(metadata.Metadata)
+	m.SetSize(output.ContentSummary.Length)
+	m.SetCount(output.ContentSummary.FileCount)
+	return m, nil
+}
+
+// List implements Storager.List
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	output := &listStatusOutput{}
+	err = s.do(http.MethodGet, s.getAbsPath(path), "LISTSTATUS", nil, output)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	for _, v := range output.FileStatuses.FileStatus {
+		o := formatFileStatus(v)
+		o.Name = storageutil.JoinPath(path, v.PathSuffix)
+
+		if o.Type == types.ObjectTypeDir {
+			if opt.HasDirFunc {
+				opt.DirFunc(o)
+			}
+			continue
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	params := url.Values{}
+	if opt.HasOffset {
+		params.Set("offset", strconv.FormatInt(opt.Offset, 10))
+	}
+	if opt.HasSize {
+		params.Set("length", strconv.FormatInt(opt.Size, 10))
+	}
+
+	resp, err := s.send(http.MethodGet, s.getURL(s.getAbsPath(path), "OPEN", params), nil)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	// Namenode will redirect us to a datanode which holds the data.
+	if resp.StatusCode == http.StatusTemporaryRedirect {
+		_ = resp.Body.Close()
+		resp, err = s.send(http.MethodGet, resp.Header.Get("Location"), nil)
+		if err != nil {
+			return nil, types.NewError(s, "Read", err, path)
+		}
+	}
+	if err = checkResponse(resp, http.StatusOK); err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	r = resp.Body
+	if opt.HasSize {
+		r = iowrap.LimitReadCloser(r, opt.Size)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// Data will be sent in two steps: namenode will redirect CREATE to a datanode, and then data will be sent to
+// the datanode. Parent dirs will be created by HDFS automatically.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairWrite(pairs...)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+
+	params := url.Values{}
+	params.Set("overwrite", "true")
+
+	resp, err := s.send(http.MethodPut, s.getURL(s.getAbsPath(path), "CREATE", params), nil)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	if err = checkResponse(resp, http.StatusTemporaryRedirect); err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	_ = resp.Body.Close()
+
+	if opt.HasSize {
+		r = iowrap.SizeReader(r, opt.Size)
+	}
+	req, err := http.NewRequest(http.MethodPut, resp.Header.Get("Location"), r)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	if opt.HasSize {
+		req.ContentLength = opt.Size
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err = s.client.Do(req)
+	if err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	if err = checkResponse(resp, http.StatusCreated, http.StatusOK); err != nil {
+		return types.NewError(s, "Write", err, path)
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	output := &fileStatusOutput{}
+	err = s.do(http.MethodGet, s.getAbsPath(path), "GETFILESTATUS", nil, output)
+	if err != nil {
+		return nil, types.NewError(s, "Stat", err, path)
+	}
+
+	o = formatFileStatus(output.FileStatus)
+	o.Name = path
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// Dir will be deleted recursively.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	params := url.Values{}
+	params.Set("recursive", "true")
+
+	output := &booleanOutput{}
+	err = s.do(http.MethodDelete, s.getAbsPath(path), "DELETE", params, output)
+	if err != nil {
+		return types.NewError(s, "Delete", err, path)
+	}
+	// DELETE will return false if path doesn't exist.
+	if !output.Boolean {
+		return types.NewError(s, "Delete", types.ErrObjectNotExist, path)
+	}
+	return nil
+}
+
+// Move implements Storager.Move
+//
+// Rename option OVERWRITE is used so that dst will be replaced if exists.
+func (s *Storage) Move(src, dst string, pairs ...*types.Pair) (err error) {
+	rd := s.getAbsPath(dst)
+
+	// RENAME requires dst's parent dir exists.
+	output := &booleanOutput{}
+	err = s.do(http.MethodPut, path.Dir(rd), "MKDIRS", nil, output)
+	if err != nil {
+		return types.NewError(s, "Move", err, src, dst)
+	}
+
+	params := url.Values{}
+	params.Set("destination", rd)
+	params.Set("renameoptions", "OVERWRITE")
+
+	err = s.do(http.MethodPut, s.getAbsPath(src), "RENAME", params, nil)
+	if err != nil {
+		return types.NewError(s, "Move", err, src, dst)
+	}
+	return nil
+}
+
+// do will send an operation to namenode and decode the JSON response into output if not nil.
+func (s *Storage) do(method, p, op string, params url.Values, output interface{}) (err error) {
+	resp, err := s.send(method, s.getURL(p, op, params), nil)
+	if err != nil {
+		return err
+	}
+	if err = checkResponse(resp, http.StatusOK); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if output == nil {
+		return nil
+	}
+	return decodeJSON(resp.Body, output)
+}
+
+func (s *Storage) send(method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
@@ -0,0 +1,412 @@
+package webhdfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xuanwo/storage/pkg/credential"
+	"github.com/Xuanwo/storage/pkg/endpoint"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// testServer is a WebHDFS stand-in which serves a local dir as both namenode and datanode.
+type testServer struct {
+	*httptest.Server
+	root string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	dir, err := ioutil.TempDir("", "webhdfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := &testServer{root: dir}
+	ts.Server = httptest.NewServer(ts)
+	return ts
+}
+
+func (ts *testServer) Close() {
+	ts.Server.Close()
+	_ = os.RemoveAll(ts.root)
+}
+
+func (ts *testServer) writeException(w http.ResponseWriter, code int, exception string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"RemoteException": map[string]string{
+			"exception": exception,
+			"message":   exception + " from test server",
+		},
+	})
+}
+
+func (ts *testServer) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func fileStatusOf(fi os.FileInfo, suffix string) map[string]interface{} {
+	typ := "FILE"
+	if fi.IsDir() {
+		typ = "DIRECTORY"
+	}
+	return map[string]interface{}{
+		"pathSuffix":       suffix,
+		"type":             typ,
+		"length":           fi.Size(),
+		"modificationTime": fi.ModTime().UnixNano() / int64(1e6),
+	}
+}
+
+func (ts *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("user.name") != "test_user" {
+		ts.writeException(w, http.StatusUnauthorized, "SecurityException")
+		return
+	}
+
+	// Datanode only receives data for CREATE and OPEN.
+	if strings.HasPrefix(r.URL.Path, "/datanode/") {
+		lp := filepath.Join(ts.root, strings.TrimPrefix(r.URL.Path, "/datanode/"))
+		switch q.Get("op") {
+		case "CREATE":
+			if err := os.MkdirAll(filepath.Dir(lp), 0755); err != nil {
+				ts.writeException(w, http.StatusForbidden, "IOException")
+				return
+			}
+			content, _ := ioutil.ReadAll(r.Body)
+			if err := ioutil.WriteFile(lp, content, 0644); err != nil {
+				ts.writeException(w, http.StatusForbidden, "IOException")
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case "OPEN":
+			f, err := os.Open(lp)
+			if err != nil {
+				ts.writeException(w, http.StatusNotFound, "FileNotFoundException")
+				return
+			}
+			defer f.Close()
+			offset, _ := strconv.ParseInt(q.Get("offset"), 10, 64)
+			_, _ = f.Seek(offset, io.SeekStart)
+			var reader io.Reader = f
+			if length := q.Get("length"); length != "" {
+				n, _ := strconv.ParseInt(length, 10, 64)
+				reader = io.LimitReader(f, n)
+			}
+			_, _ = io.Copy(w, reader)
+		}
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/webhdfs/v1/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	lp := filepath.Join(ts.root, p)
+	datanodeURL := ts.URL + "/datanode" + p + "?" + r.URL.RawQuery
+
+	switch q.Get("op") {
+	case "GETFILESTATUS":
+		fi, err := os.Stat(lp)
+		if err != nil {
+			ts.writeException(w, http.StatusNotFound, "FileNotFoundException")
+			return
+		}
+		ts.writeJSON(w, map[string]interface{}{"FileStatus": fileStatusOf(fi, "")})
+	case "LISTSTATUS":
+		fis, err := ioutil.ReadDir(lp)
+		if err != nil {
+			ts.writeException(w, http.StatusNotFound, "FileNotFoundException")
+			return
+		}
+		statuses := make([]interface{}, 0, len(fis))
+		for _, fi := range fis {
+			statuses = append(statuses, fileStatusOf(fi, fi.Name()))
+		}
+		ts.writeJSON(w, map[string]interface{}{
+			"FileStatuses": map[string]interface{}{"FileStatus": statuses},
+		})
+	case "OPEN":
+		if _, err := os.Stat(lp); err != nil {
+			ts.writeException(w, http.StatusNotFound, "FileNotFoundException")
+			return
+		}
+		http.Redirect(w, r, datanodeURL, http.StatusTemporaryRedirect)
+	case "CREATE":
+		// Data should never be sent to namenode.
+		if r.ContentLength > 0 {
+			ts.writeException(w, http.StatusBadRequest, "IllegalArgumentException")
+			return
+		}
+		if _, err := os.Stat(lp); err == nil && q.Get("overwrite") != "true" {
+			ts.writeException(w, http.StatusForbidden, "FileAlreadyExistsException")
+			return
+		}
+		http.Redirect(w, r, datanodeURL, http.StatusTemporaryRedirect)
+	case "MKDIRS":
+		ts.writeJSON(w, map[string]bool{"boolean": os.MkdirAll(lp, 0755) == nil})
+	case "RENAME":
+		if q.Get("renameoptions") != "OVERWRITE" {
+			ts.writeJSON(w, map[string]bool{"boolean": false})
+			return
+		}
+		if _, err := os.Stat(lp); err != nil {
+			ts.writeException(w, http.StatusNotFound, "FileNotFoundException")
+			return
+		}
+		if err := os.Rename(lp, filepath.Join(ts.root, q.Get("destination"))); err != nil {
+			ts.writeException(w, http.StatusForbidden, "IOException")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+	case "DELETE":
+		_, err := os.Stat(lp)
+		if err == nil && q.Get("recursive") == "true" {
+			err = os.RemoveAll(lp)
+		} else if err == nil {
+			err = os.Remove(lp)
+		}
+		ts.writeJSON(w, map[string]bool{"boolean": err == nil})
+	case "GETCONTENTSUMMARY":
+		var size, files, dirs int64
+		err := filepath.Walk(lp, func(_ string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				dirs++
+				return nil
+			}
+			files++
+			size += fi.Size()
+			return nil
+		})
+		if err != nil {
+			ts.writeException(w, http.StatusNotFound, "FileNotFoundException")
+			return
+		}
+		ts.writeJSON(w, map[string]interface{}{
+			"ContentSummary": map[string]int64{"directoryCount": dirs, "fileCount": files, "length": size},
+		})
+	default:
+		ts.writeException(w, http.StatusBadRequest, "UnsupportedOperationException")
+	}
+}
+
+func newTestStorage(t *testing.T, ts *testServer, user string) *Storage {
+	ep, err := endpoint.ParseURL(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := New()
+	err = store.Init(
+		pairs.WithEndpoint(ep),
+		pairs.WithUser(user),
+		pairs.WithWorkDir("/work"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStorage(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	store := newTestStorage(t, ts, "test_user")
+	content := []byte("0123456789")
+	workDir := filepath.Join(ts.root, "work")
+
+	t.Run("write", func(t *testing.T) {
+		err := store.Write("a/b/test_file", bytes.NewReader(content), pairs.WithSize(int64(len(content))))
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(workDir, "a", "b", "test_file"))
+		assert.NoError(t, err)
+		assert.Equal(t, content, b)
+
+		// Existing file will be overwritten.
+		err = store.Write("a/b/test_file", bytes.NewReader(content))
+		assert.NoError(t, err)
+	})
+
+	t.Run("write with short input", func(t *testing.T) {
+		err := store.Write("short_input", bytes.NewReader(content), pairs.WithSize(20))
+		assert.True(t, errors.Is(err, iowrap.ErrShortInput))
+
+		// Partial content could be left as fs does.
+		_ = store.Delete("short_input")
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		o, err := store.Stat("a/b/test_file")
+		assert.NoError(t, err)
+		assert.Equal(t, "a/b/test_file", o.Name)
+		assert.Equal(t, types.ObjectTypeFile, o.Type)
+		assert.Equal(t, int64(len(content)), o.Size)
+		assert.False(t, o.UpdatedAt.IsZero())
+
+		o, err = store.Stat("a")
+		assert.NoError(t, err)
+		assert.Equal(t, types.ObjectTypeDir, o.Type)
+
+		_, err = store.Stat("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			pairs  []*types.Pair
+			expect string
+		}{
+			{"full", nil, "0123456789"},
+			{"offset", []*types.Pair{pairs.WithOffset(7)}, "789"},
+			{"size", []*types.Pair{pairs.WithSize(3)}, "012"},
+			{"offset and size", []*types.Pair{pairs.WithOffset(2), pairs.WithSize(3)}, "234"},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				r, err := store.Read("a/b/test_file", tt.pairs...)
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer r.Close()
+
+				b, err := ioutil.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, string(b))
+			})
+		}
+
+		_, err := store.Read("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		err := store.Write("to_move", bytes.NewReader(content))
+		assert.NoError(t, err)
+
+		err = store.Move("to_move", "c/moved")
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(workDir, "to_move"))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(workDir, "c", "moved"))
+		assert.NoError(t, err)
+
+		err = store.Move("not_exist", "moved")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		files, dirs := make([]string, 0), make([]string, 0)
+		err := store.List("",
+			pairs.WithFileFunc(func(o *types.Object) {
+				files = append(files, o.Name)
+			}),
+			pairs.WithDirFunc(func(o *types.Object) {
+				dirs = append(dirs, o.Name)
+			}),
+		)
+		assert.NoError(t, err)
+		sort.Strings(dirs)
+		assert.Equal(t, []string{}, files)
+		assert.Equal(t, []string{"a", "c"}, dirs)
+
+		err = store.List("a/b", pairs.WithFileFunc(func(o *types.Object) {
+			files = append(files, o.Name)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a/b/test_file"}, files)
+
+		err = store.List("not_exist")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("statistical", func(t *testing.T) {
+		m, err := store.Statistical()
+		assert.NoError(t, err)
+
+		size, ok := m.GetSize()
+		assert.True(t, ok)
+		assert.Equal(t, int64(2*len(content)), size)
+		count, ok := m.GetCount()
+		assert.True(t, ok)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// Dir will be deleted recursively.
+		err := store.Delete("c")
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(workDir, "c"))
+		assert.True(t, os.IsNotExist(err))
+
+		err = store.Delete("c")
+		assert.True(t, errors.Is(err, types.ErrObjectNotExist))
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		store := newTestStorage(t, ts, "invalid_user")
+
+		_, err := store.Stat("a/b/test_file")
+		assert.True(t, errors.Is(err, types.ErrPermissionDenied))
+	})
+}
+
+func TestStorage_Init(t *testing.T) {
+	ep := endpoint.NewHTTP("127.0.0.1", 9870)
+
+	t.Run("default", func(t *testing.T) {
+		store := New()
+		err := store.Init(pairs.WithEndpoint(ep))
+		assert.NoError(t, err)
+		assert.Equal(t, "/", store.workDir)
+		assert.Equal(t,
+			"http://127.0.0.1:9870/webhdfs/v1/a?op=OPEN",
+			store.getURL(store.getAbsPath("a"), "OPEN", nil),
+		)
+	})
+
+	t.Run("delegation", func(t *testing.T) {
+		store := New()
+		err := store.Init(
+			pairs.WithEndpoint(ep),
+			pairs.WithCredential(credential.MustNewAPIKey("test_token")),
+			pairs.WithWorkDir("/work"),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t,
+			"http://127.0.0.1:9870/webhdfs/v1/work/a?delegation=test_token&offset=1&op=OPEN",
+			store.getURL(store.getAbsPath("a"), "OPEN", map[string][]string{"offset": {"1"}}),
+		)
+	})
+
+	t.Run("unsupported credential", func(t *testing.T) {
+		store := New()
+		err := store.Init(pairs.WithEndpoint(ep), pairs.WithCredential(credential.MustNewHmac("ak", "sk")))
+		assert.True(t, errors.Is(err, credential.ErrUnsupportedProtocol))
+	})
+}
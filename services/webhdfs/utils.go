@@ -0,0 +1,156 @@
+package webhdfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+)
+
+// fileStatus is the FileStatus JSON object in WebHDFS.
+type fileStatus struct {
+	PathSuffix       string `json:"pathSuffix"`
+	Type             string `json:"type"`
+	Length           int64  `json:"length"`
+	ModificationTime int64  `json:"modificationTime"`
+}
+
+type fileStatusOutput struct {
+	FileStatus fileStatus `json:"FileStatus"`
+}
+
+type listStatusOutput struct {
+	FileStatuses struct {
+		FileStatus []fileStatus `json:"FileStatus"`
+	} `json:"FileStatuses"`
+}
+
+type contentSummaryOutput struct {
+	ContentSummary struct {
+		DirectoryCount int64 `json:"directoryCount"`
+		FileCount      int64 `json:"fileCount"`
+		Length         int64 `json:"length"`
+	} `json:"ContentSummary"`
+}
+
+type booleanOutput struct {
+	Boolean bool `json:"boolean"`
+}
+
+// remoteException is the error returned by WebHDFS.
+type remoteException struct {
+	RemoteException struct {
+		Exception string `json:"exception"`
+		Message   string `json:"message"`
+	} `json:"RemoteException"`
+}
+
+func (s *Storage) getAbsPath(p string) string {
+	return path.Join(s.workDir, p)
+}
+
+// getURL will return the url for operation op on absolute path p.
+func (s *Storage) getURL(p, op string, params url.Values) string {
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	q.Set("op", op)
+	if s.user != "" {
+		q.Set("user.name", s.user)
+	}
+	if s.delegation != "" {
+		q.Set("delegation", s.delegation)
+	}
+
+	u := *s.endpoint
+	u.Path = path.Join(s.endpoint.Path, p)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func formatFileStatus(v fileStatus) *types.Object {
+	o := &types.Object{
+		Size:      v.Length,
+		UpdatedAt: time.Unix(0, v.ModificationTime*int64(time.Millisecond)),
+		Metadata:  make(metadata.Metadata),
+	}
+	if v.Type == "DIRECTORY" {
+		o.Type = types.ObjectTypeDir
+	} else {
+		o.Type = types.ObjectTypeFile
+	}
+	return o
+}
+
+func decodeJSON(r io.Reader, v interface{}) error {
+	err := json.NewDecoder(r).Decode(v)
+	if err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// checkResponse will check whether response's status code is expected, and convert it into error if not.
+//
+// Response's body will be closed if error returned.
+func checkResponse(resp *http.Response, expected ...int) error {
+	for _, v := range expected {
+		if resp.StatusCode == v {
+			return nil
+		}
+	}
+	defer resp.Body.Close()
+
+	// Try to parse RemoteException which could tell us more details.
+	e := &remoteException{}
+	content, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(content, e) == nil && e.RemoteException.Exception != "" {
+		err := fmt.Errorf("%s: %s", e.RemoteException.Exception, e.RemoteException.Message)
+		return formatException(e.RemoteException.Exception, resp.StatusCode, err)
+	}
+
+	err := fmt.Errorf("%s %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+	return formatException("", resp.StatusCode, err)
+}
+
+// formatException will convert exception or status code into sentinel errors.
+func formatException(exception string, code int, err error) error {
+	switch exception {
+	case "FileNotFoundException":
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case "AccessControlException", "SecurityException", "AuthorizationException":
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case "FileAlreadyExistsException":
+		return fmt.Errorf("%w: %v", types.ErrObjectAlreadyExist, err)
+	case "PathIsNotEmptyDirectoryException":
+		return fmt.Errorf("%w: %v", types.ErrDirNotEmpty, err)
+	case "DSQuotaExceededException", "NSQuotaExceededException", "QuotaExceededException":
+		return fmt.Errorf("%w: %v", types.ErrQuotaExceeded, err)
+	case "InvalidPathException":
+		return fmt.Errorf("%w: %v", types.ErrInvalidPath, err)
+	case "StandbyException", "SafeModeException", "RetriableException":
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	case "UnsupportedOperationException":
+		return fmt.Errorf("%w: %v", types.ErrNotSupported, err)
+	}
+
+	switch code {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", types.ErrObjectNotExist, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %v", types.ErrPermissionDenied, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", types.ErrRateLimited, err)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: %v", types.ErrServiceUnavailable, err)
+	}
+	return fmt.Errorf("%w: %v", types.ErrUnhandledError, err)
+}
@@ -0,0 +1,11 @@
+/*
+Package tar provided read-only support for zip archives.
+
+The archive could be read from any Storager via pair storager, or from local file system if storager not given.
+Only the central directory will be fetched while Init, and Read will fetch the entry's data via ranged Read, so
+archive in remote storage doesn't need to be downloaded.
+
+Only regular files and dirs will be exposed, and only store and deflate compression methods are supported.
+Checksum of entry will not be verified while Read.
+*/
+package zip
@@ -0,0 +1,262 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Xuanwo/storage"
+	"github.com/Xuanwo/storage/pkg/archive"
+	"github.com/Xuanwo/storage/pkg/iowrap"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/metadata"
+	ps "github.com/Xuanwo/storage/types/pairs"
+)
+
+// Storage is the zip archive client.
+//
+//go:generate ../../internal/bin/meta
+type Storage struct {
+	// store is the storager which holds the archive at path.
+	store storage.Storager
+	path  string
+	// index will be built while Init, and the entry's Value is the *zip.File.
+	index *archive.Index
+
+	workDir string
+}
+
+// New will create a zip archive client.
+func New() *Storage {
+	return &Storage{}
+}
+
+// String implements Storager.String
+func (s *Storage) String() string {
+	return fmt.Sprintf("Storager zip {Path: %s, WorkDir: %s}", s.path, s.workDir)
+}
+
+// Init implements Storager.Init
+func (s *Storage) Init(pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairInit(pairs...)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+
+	s.path = opt.Path
+	if opt.HasStorager {
+		s.store = opt.Storager
+	} else {
+		s.store, s.path, err = openLocal(opt.Path)
+		if err != nil {
+			return types.NewError(s, "Init", err)
+		}
+	}
+
+	s.workDir = "/"
+	if opt.HasWorkDir {
+		s.workDir = path.Join("/", opt.WorkDir)
+	}
+
+	o, err := s.store.Stat(s.path)
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+	s.index, err = buildIndex(archive.NewReaderAt(s.store, s.path, o.Size))
+	if err != nil {
+		return types.NewError(s, "Init", err)
+	}
+	return nil
+}
+
+// Metadata implements Storager.Metadata
+func (s *Storage) Metadata() (m metadata.Storage, err error) {
+	m = metadata.Storage{
+		Name:     s.path,
+		WorkDir:  s.workDir,
+		Metadata: make(metadata.Metadata),
+	}
+	return m, nil
+}
+
+// Statistical implements Storager.Statistical
+func (s *Storage) Statistical() (m metadata.Metadata, err error) {
+	var size, count int64
+	s.index.Walk(s.workDir, func(e *archive.Entry) {
+		size += e.Size
+		count++
+	})
+
+	m = make(metadata.Metadata)
+	m.SetSize(size)
+	m.SetCount(count)
+	return m, nil
+}
+
+// List implements Storager.List
+func (s *Storage) List(path string, pairs ...*types.Pair) (err error) {
+	opt, err := parseStoragePairList(pairs...)
+	if err != nil {
+		return types.NewError(s, "List", err, path)
+	}
+
+	es, ok := s.index.List(s.getAbsPath(path))
+	if !ok {
+		return types.NewError(s, "List", types.ErrObjectNotExist, path)
+	}
+
+	for _, e := range es {
+		o := e.Format()
+		o.Name = joinPath(path, e.Name)
+
+		if o.Type == types.ObjectTypeDir {
+			if opt.HasDirFunc {
+				opt.DirFunc(o)
+			}
+			continue
+		}
+		if opt.HasFileFunc {
+			opt.FileFunc(o)
+		}
+	}
+	return
+}
+
+// Read implements Storager.Read
+func (s *Storage) Read(path string, pairs ...*types.Pair) (r io.ReadCloser, err error) {
+	opt, err := parseStoragePairRead(pairs...)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	e, err := s.getFile(path)
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+	f := e.Value.(*zip.File)
+
+	// DataOffset will read the local file header which is small enough to be cached.
+	offset, err := f.DataOffset()
+	if err != nil {
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	switch f.Method {
+	case zip.Store:
+		size := e.Size - opt.Offset
+		if opt.HasSize && opt.Size < size {
+			size = opt.Size
+		}
+		if size <= 0 {
+			return ioutil.NopCloser(bytes.NewReader(nil)), nil
+		}
+		r, err = s.store.Read(s.path, ps.WithOffset(offset+opt.Offset), ps.WithSize(size))
+		if err != nil {
+			return nil, types.NewError(s, "Read", err, path)
+		}
+		return r, nil
+	case zip.Deflate:
+		rc, err := s.store.Read(s.path, ps.WithOffset(offset), ps.WithSize(int64(f.CompressedSize64)))
+		if err != nil {
+			return nil, types.NewError(s, "Read", err, path)
+		}
+		r = &deflateReadCloser{ReadCloser: flate.NewReader(rc), rc: rc}
+	default:
+		err = fmt.Errorf("compression method %d: %w", f.Method, types.ErrNotSupported)
+		return nil, types.NewError(s, "Read", err, path)
+	}
+
+	// Compressed data is not seekable, so we need to skip the offset by ourselves.
+	if opt.HasOffset {
+		_, err = io.CopyN(ioutil.Discard, r, opt.Offset)
+		if err != nil && err != io.EOF {
+			_ = r.Close()
+			return nil, types.NewError(s, "Read", err, path)
+		}
+	}
+	if opt.HasSize {
+		r = iowrap.LimitReadCloser(r, opt.Size)
+	}
+	return r, nil
+}
+
+// Write implements Storager.Write
+//
+// zip storager is read-only, types.ErrReadOnly will always be returned.
+func (s *Storage) Write(path string, r io.Reader, pairs ...*types.Pair) (err error) {
+	return types.NewError(s, "Write", types.ErrReadOnly, path)
+}
+
+// Stat implements Storager.Stat
+func (s *Storage) Stat(path string, pairs ...*types.Pair) (o *types.Object, err error) {
+	e, ok := s.index.Get(s.getAbsPath(path))
+	if !ok {
+		return nil, types.NewError(s, "Stat", types.ErrObjectNotExist, path)
+	}
+
+	o = e.Format()
+	o.Name = path
+	return o, nil
+}
+
+// Delete implements Storager.Delete
+//
+// zip storager is read-only, types.ErrReadOnly will always be returned.
+func (s *Storage) Delete(path string, pairs ...*types.Pair) (err error) {
+	return types.NewError(s, "Delete", types.ErrReadOnly, path)
+}
+
+// getFile will return the file entry for path.
+func (s *Storage) getFile(path string) (*archive.Entry, error) {
+	e, ok := s.index.Get(s.getAbsPath(path))
+	if !ok {
+		return nil, types.ErrObjectNotExist
+	}
+	if e.Dir {
+		return nil, fmt.Errorf("%s is a dir: %w", path, types.ErrInvalidPath)
+	}
+	return e, nil
+}
+
+// buildIndex will read zip's central directory in r.
+func buildIndex(r *archive.ReaderAt) (*archive.Index, error) {
+	index := archive.NewIndex()
+
+	zr, err := zip.NewReader(r, r.Size())
+	if err != nil {
+		return nil, fmt.Errorf("read zip central directory: %w", err)
+	}
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") || f.FileInfo().IsDir() {
+			index.Add(&archive.Entry{Name: f.Name, Dir: true, UpdatedAt: f.Modified})
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		index.Add(&archive.Entry{Name: f.Name, Size: int64(f.UncompressedSize64), UpdatedAt: f.Modified, Value: f})
+	}
+	return index, nil
+}
+
+// openLocal will open local file p via a fs storager.
+func openLocal(p string) (store storage.Storager, name string, err error) {
+	p, err = filepath.Abs(p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fsStore := fs.New()
+	err = fsStore.Init(ps.WithWorkDir(filepath.Dir(p)))
+	if err != nil {
+		return nil, "", err
+	}
+	return fsStore, filepath.Base(p), nil
+}
@@ -0,0 +1,102 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Xuanwo/storage/pkg/archive/archivetest"
+	"github.com/Xuanwo/storage/services/fs"
+	"github.com/Xuanwo/storage/types"
+	"github.com/Xuanwo/storage/types/pairs"
+)
+
+// newTestArchive will create a zip archive in dir with following entries:
+//
+//	a/
+//	a/b/test_file (deflated)
+//	c (stored)
+func newTestArchive(t *testing.T, dir string) string {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+
+	headers := []*zip.FileHeader{
+		{Name: "a/", Method: zip.Store, Modified: archivetest.ModTime},
+		{Name: "a/b/test_file", Method: zip.Deflate, Modified: archivetest.ModTime},
+		{Name: "c", Method: zip.Store, Modified: archivetest.ModTime},
+	}
+	for _, hdr := range headers {
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name[len(hdr.Name)-1] != '/' {
+			_, err = w.Write(archivetest.Content)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(dir, "test.zip")
+	err = ioutil.WriteFile(p, buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newTestArchive(t, dir)
+
+	fsStore := fs.New()
+	err = fsStore.Init(pairs.WithWorkDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		pairs []*types.Pair
+	}{
+		{"local", []*types.Pair{pairs.WithPath(p)}},
+		{"storager", []*types.Pair{pairs.WithStorager(fsStore), pairs.WithPath("test.zip")}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			store := New()
+			err := store.Init(tt.pairs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			archivetest.TestStorager(t, store)
+		})
+	}
+}
+
+func TestStorage_Init(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newTestArchive(t, dir)
+
+	archivetest.TestInit(t, func() archivetest.Storager { return New() }, p, filepath.Join(dir, "not_exist.zip"))
+}
@@ -0,0 +1,28 @@
+package zip
+
+import (
+	"io"
+	"path"
+	"strings"
+)
+
+func (s *Storage) getAbsPath(p string) string {
+	return path.Join(s.workDir, p)
+}
+
+// joinPath will join dir and entry's base name into an object name which is relative to work dir.
+func joinPath(dir, name string) string {
+	return strings.TrimPrefix(path.Join(dir, path.Base(name)), "/")
+}
+
+// deflateReadCloser will close both the decompressor and underlying reader.
+type deflateReadCloser struct {
+	io.ReadCloser
+	rc io.ReadCloser
+}
+
+// Close implements io.Closer
+func (r *deflateReadCloser) Close() error {
+	_ = r.ReadCloser.Close()
+	return r.rc.Close()
+}
@@ -120,6 +120,10 @@ type Reacher interface {
 	//
 	// Implementer:
 	//   - SHOULD return a publicly reachable http url.
+	//   - SHOULD sign for GET by default, and sign for PUT upload if pair method is "PUT".
+	//   - MAY sign for a segment part upload if pair segment_id is given with offset and size.
+	//   - SHOULD return types.ErrNotSupported if method is not supported.
+	//   - SHOULD return types.ErrNotSupported if content_type or size is given but couldn't be enforced by the url.
 	Reach(path string, pairs ...*types.Pair) (url string, err error)
 }
 
@@ -173,3 +177,22 @@ type Segmenter interface {
 	//   - SHOULD call InitSegment before AbortSegment.
 	AbortSegment(id string, pairs ...*types.Pair) (err error)
 }
+
+// Versioner is the interface for object versions.
+//
+// Implementer which implements Versioner SHOULD accept pair version_id in Read, Stat and Delete to operate a
+// specific version of the object.
+type Versioner interface {
+	// ListVersions will list all versions of an object from newest to oldest.
+	//
+	// Implementer:
+	//   - MUST call file_func for every version with version_id set in object's metadata.
+	//   - MAY leave version_id empty for the current version if it can't be addressed by version id.
+	//   - SHOULD skip delete markers.
+	ListVersions(path string, pairs ...*types.Pair) (err error)
+	// Restore will make a version to be the current version of an object.
+	//
+	// Implementer:
+	//   - SHOULD keep the current version as a version instead of overwriting it.
+	Restore(path, version string, pairs ...*types.Pair) (err error)
+}
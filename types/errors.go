@@ -10,12 +10,22 @@ var (
 	// user handleable error
 	ErrConfigIncorrect  = errors.New("config incorrect")
 	ErrPermissionDenied = errors.New("permission denied")
+	ErrQuotaExceeded    = errors.New("quota exceeded")
 
 	// caller handleable error
-	ErrPairRequired    = errors.New("pair required")
-	ErrObjectNotExist  = errors.New("object not exist")
-	ErrDirAlreadyExist = errors.New("dir already exist")
-	ErrDirNotEmpty     = errors.New("dir not empty")
+	ErrPairRequired       = errors.New("pair required")
+	ErrInvalidPath        = errors.New("invalid path")
+	ErrObjectNotExist     = errors.New("object not exist")
+	ErrObjectAlreadyExist = errors.New("object already exist")
+	ErrDirAlreadyExist    = errors.New("dir already exist")
+	ErrDirNotEmpty        = errors.New("dir not empty")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrNotSupported       = errors.New("not supported")
+	ErrReadOnly           = errors.New("read only")
+
+	// retryable error
+	ErrRateLimited        = errors.New("rate limited")
+	ErrServiceUnavailable = errors.New("service unavailable")
 
 	// unhandleable error
 	ErrUnhandledError = errors.New("unhandled error")
@@ -25,3 +35,54 @@ var (
 func NewErrPairRequired(pair string) error {
 	return fmt.Errorf("%s is required but missing: %w", pair, ErrPairRequired)
 }
+
+// Error is the error returned by all storagers and servicers.
+//
+// Caller could use errors.As to extract the failed operation and path, and errors.Is
+// to check the underlying error against the sentinel errors above.
+type Error struct {
+	// Op is the failed operation, like "Stat" or "Write".
+	Op string
+	// Path is the path that operation applied on, it will be the bucket name for servicer,
+	// and the source path for Copy and Move.
+	Path string
+	// Dst is the destination path for Copy and Move, it will be empty for other operations.
+	Dst string
+	// Storager is the string representation of the storager or servicer which returned this error.
+	Storager string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	switch {
+	case e.Dst != "":
+		return fmt.Sprintf("%s %s from [%s] to [%s]: %v", e.Storager, e.Op, e.Path, e.Dst, e.Err)
+	case e.Path != "":
+		return fmt.Sprintf("%s %s [%s]: %v", e.Storager, e.Op, e.Path, e.Err)
+	default:
+		return fmt.Sprintf("%s %s: %v", e.Storager, e.Op, e.Err)
+	}
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError will create a new Error, path will be filled as Path and Dst in order.
+func NewError(storager fmt.Stringer, op string, err error, path ...string) error {
+	e := &Error{
+		Op:       op,
+		Storager: fmt.Sprint(storager),
+		Err:      err,
+	}
+	if len(path) > 0 {
+		e.Path = path[0]
+	}
+	if len(path) > 1 {
+		e.Dst = path[1]
+	}
+	return e
+}
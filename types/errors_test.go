@@ -0,0 +1,56 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stringer string
+
+func (s stringer) String() string {
+	return string(s)
+}
+
+func TestError_Error(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			"without path",
+			NewError(stringer("Storager test"), "Statistical", ErrUnhandledError),
+			"Storager test Statistical: unhandled error",
+		},
+		{
+			"with path",
+			NewError(stringer("Storager test"), "Stat", ErrObjectNotExist, "abc"),
+			"Storager test Stat [abc]: object not exist",
+		},
+		{
+			"with src and dst",
+			NewError(stringer("Storager test"), "Copy", ErrPermissionDenied, "abc", "def"),
+			"Storager test Copy from [abc] to [def]: permission denied",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.err.Error())
+		})
+	}
+}
+
+func TestError_Unwrap(t *testing.T) {
+	err := NewError(stringer("Storager test"), "Read", fmt.Errorf("%w: %v", ErrRateLimited, "slow down"), "abc")
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	var e *Error
+	assert.True(t, errors.As(fmt.Errorf("wrapped: %w", err), &e))
+	assert.Equal(t, "Read", e.Op)
+	assert.Equal(t, "abc", e.Path)
+	assert.Equal(t, "Storager test", e.Storager)
+}
@@ -3,17 +3,41 @@ package metadata
 
 // All available metadata.
 const (
-	Checksum = "checksum"
-	Class    = "class"
-	Count    = "count"
-	Expire   = "expire"
-	Host     = "host"
-	Location = "location"
-	Size     = "size"
-	Type     = "type"
-	WorkDir  = "work_dir"
+	CacheControl       = "cache_control"
+	Checksum           = "checksum"
+	Class              = "class"
+	ContentDisposition = "content_disposition"
+	ContentEncoding    = "content_encoding"
+	Count              = "count"
+	Expire             = "expire"
+	Host               = "host"
+	Location           = "location"
+	Size               = "size"
+	Type               = "type"
+	UserMetadata       = "user_metadata"
+	VersionId          = "version_id"
+	WorkDir            = "work_dir"
 )
 
+// GetCacheControl will get cache_control value from metadata.
+func (m Metadata) GetCacheControl() (string, bool) {
+	v, ok := m[CacheControl]
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// MustGetCacheControl will get cache_control value from metadata.
+func (m Metadata) MustGetCacheControl() string {
+	return m[CacheControl].(string)
+}
+
+// SetCacheControl will set cache_control value into metadata.
+func (m Metadata) SetCacheControl(v string) {
+	m[CacheControl] = v
+}
+
 // GetChecksum will get checksum value from metadata.
 func (m Metadata) GetChecksum() (string, bool) {
 	v, ok := m[Checksum]
@@ -52,6 +76,44 @@ func (m Metadata) SetClass(v string) {
 	m[Class] = v
 }
 
+// GetContentDisposition will get content_disposition value from metadata.
+func (m Metadata) GetContentDisposition() (string, bool) {
+	v, ok := m[ContentDisposition]
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// MustGetContentDisposition will get content_disposition value from metadata.
+func (m Metadata) MustGetContentDisposition() string {
+	return m[ContentDisposition].(string)
+}
+
+// SetContentDisposition will set content_disposition value into metadata.
+func (m Metadata) SetContentDisposition(v string) {
+	m[ContentDisposition] = v
+}
+
+// GetContentEncoding will get content_encoding value from metadata.
+func (m Metadata) GetContentEncoding() (string, bool) {
+	v, ok := m[ContentEncoding]
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// MustGetContentEncoding will get content_encoding value from metadata.
+func (m Metadata) MustGetContentEncoding() string {
+	return m[ContentEncoding].(string)
+}
+
+// SetContentEncoding will set content_encoding value into metadata.
+func (m Metadata) SetContentEncoding(v string) {
+	m[ContentEncoding] = v
+}
+
 // GetCount will get count value from metadata.
 func (m Metadata) GetCount() (int64, bool) {
 	v, ok := m[Count]
@@ -166,6 +228,44 @@ func (m Metadata) SetType(v string) {
 	m[Type] = v
 }
 
+// GetUserMetadata will get user_metadata value from metadata.
+func (m Metadata) GetUserMetadata() (map[string]string, bool) {
+	v, ok := m[UserMetadata]
+	if !ok {
+		return map[string]string{}, false
+	}
+	return v.(map[string]string), true
+}
+
+// MustGetUserMetadata will get user_metadata value from metadata.
+func (m Metadata) MustGetUserMetadata() map[string]string {
+	return m[UserMetadata].(map[string]string)
+}
+
+// SetUserMetadata will set user_metadata value into metadata.
+func (m Metadata) SetUserMetadata(v map[string]string) {
+	m[UserMetadata] = v
+}
+
+// GetVersionId will get version_id value from metadata.
+func (m Metadata) GetVersionId() (string, bool) {
+	v, ok := m[VersionId]
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// MustGetVersionId will get version_id value from metadata.
+func (m Metadata) MustGetVersionId() string {
+	return m[VersionId].(string)
+}
+
+// SetVersionId will set version_id value into metadata.
+func (m Metadata) SetVersionId(v string) {
+	m[VersionId] = v
+}
+
 // GetWorkDir will get work_dir value from metadata.
 func (m Metadata) GetWorkDir() (string, bool) {
 	v, ok := m[WorkDir]
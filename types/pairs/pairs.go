@@ -2,6 +2,8 @@
 package pairs
 
 import (
+	"time"
+
 	"github.com/Xuanwo/storage"
 	"github.com/Xuanwo/storage/pkg/credential"
 	"github.com/Xuanwo/storage/pkg/endpoint"
@@ -11,25 +13,57 @@ import (
 
 // All available pairs.
 const (
-	Checksum     = "checksum"
-	Credential   = "credential"
-	DirFunc      = "dir_func"
-	Endpoint     = "endpoint"
-	Expire       = "expire"
-	FileFunc     = "file_func"
-	Location     = "location"
-	Name         = "name"
-	Offset       = "offset"
-	PartSize     = "part_size"
-	Project      = "project"
-	SegmentFunc  = "segment_func"
-	Size         = "size"
-	StorageClass = "storage_class"
-	StoragerFunc = "storager_func"
-	Type         = "type"
-	WorkDir      = "work_dir"
+	CacheControl       = "cache_control"
+	Checksum           = "checksum"
+	ColdStorager       = "cold_storager"
+	ContentDisposition = "content_disposition"
+	ContentEncoding    = "content_encoding"
+	ContentType        = "content_type"
+	Credential         = "credential"
+	DetectContentType  = "detect_content_type"
+	DirFunc            = "dir_func"
+	Endpoint           = "endpoint"
+	Expire             = "expire"
+	FileFunc           = "file_func"
+	IfMatch            = "if_match"
+	IfModifiedSince    = "if_modified_since"
+	IfNoneMatch        = "if_none_match"
+	IfUnmodifiedSince  = "if_unmodified_since"
+	IndexPath          = "index_path"
+	KnownHosts         = "known_hosts"
+	Location           = "location"
+	Method             = "method"
+	Name               = "name"
+	Offset             = "offset"
+	PartSize           = "part_size"
+	Path               = "path"
+	Project            = "project"
+	QueuePath          = "queue_path"
+	SegmentFunc        = "segment_func"
+	SegmentId          = "segment_id"
+	Size               = "size"
+	StorageClass       = "storage_class"
+	Storager           = "storager"
+	StoragerFunc       = "storager_func"
+	Storagers          = "storagers"
+	Type               = "type"
+	User               = "user"
+	UserMetadata       = "user_metadata"
+	VersionId          = "version_id"
+	Versioning         = "versioning"
+	VirtualNodes       = "virtual_nodes"
+	WorkDir            = "work_dir"
+	WriteQuorum        = "write_quorum"
 )
 
+// WithCacheControl will apply cache_control value to Options
+func WithCacheControl(v string) *types.Pair {
+	return &types.Pair{
+		Key:   CacheControl,
+		Value: v,
+	}
+}
+
 // WithChecksum will apply checksum value to Options
 func WithChecksum(v string) *types.Pair {
 	return &types.Pair{
@@ -38,6 +72,38 @@ func WithChecksum(v string) *types.Pair {
 	}
 }
 
+// WithColdStorager will apply cold_storager value to Options
+func WithColdStorager(v storage.Storager) *types.Pair {
+	return &types.Pair{
+		Key:   ColdStorager,
+		Value: v,
+	}
+}
+
+// WithContentDisposition will apply content_disposition value to Options
+func WithContentDisposition(v string) *types.Pair {
+	return &types.Pair{
+		Key:   ContentDisposition,
+		Value: v,
+	}
+}
+
+// WithContentEncoding will apply content_encoding value to Options
+func WithContentEncoding(v string) *types.Pair {
+	return &types.Pair{
+		Key:   ContentEncoding,
+		Value: v,
+	}
+}
+
+// WithContentType will apply content_type value to Options
+func WithContentType(v string) *types.Pair {
+	return &types.Pair{
+		Key:   ContentType,
+		Value: v,
+	}
+}
+
 // WithCredential will apply credential value to Options
 func WithCredential(v *credential.Provider) *types.Pair {
 	return &types.Pair{
@@ -46,6 +112,14 @@ func WithCredential(v *credential.Provider) *types.Pair {
 	}
 }
 
+// WithDetectContentType will apply detect_content_type value to Options
+func WithDetectContentType(v bool) *types.Pair {
+	return &types.Pair{
+		Key:   DetectContentType,
+		Value: v,
+	}
+}
+
 // WithDirFunc will apply dir_func value to Options
 func WithDirFunc(v types.ObjectFunc) *types.Pair {
 	return &types.Pair{
@@ -78,6 +152,54 @@ func WithFileFunc(v types.ObjectFunc) *types.Pair {
 	}
 }
 
+// WithIfMatch will apply if_match value to Options
+func WithIfMatch(v string) *types.Pair {
+	return &types.Pair{
+		Key:   IfMatch,
+		Value: v,
+	}
+}
+
+// WithIfModifiedSince will apply if_modified_since value to Options
+func WithIfModifiedSince(v time.Time) *types.Pair {
+	return &types.Pair{
+		Key:   IfModifiedSince,
+		Value: v,
+	}
+}
+
+// WithIfNoneMatch will apply if_none_match value to Options
+func WithIfNoneMatch(v string) *types.Pair {
+	return &types.Pair{
+		Key:   IfNoneMatch,
+		Value: v,
+	}
+}
+
+// WithIfUnmodifiedSince will apply if_unmodified_since value to Options
+func WithIfUnmodifiedSince(v time.Time) *types.Pair {
+	return &types.Pair{
+		Key:   IfUnmodifiedSince,
+		Value: v,
+	}
+}
+
+// WithIndexPath will apply index_path value to Options
+func WithIndexPath(v string) *types.Pair {
+	return &types.Pair{
+		Key:   IndexPath,
+		Value: v,
+	}
+}
+
+// WithKnownHosts will apply known_hosts value to Options
+func WithKnownHosts(v string) *types.Pair {
+	return &types.Pair{
+		Key:   KnownHosts,
+		Value: v,
+	}
+}
+
 // WithLocation will apply location value to Options
 func WithLocation(v string) *types.Pair {
 	return &types.Pair{
@@ -86,6 +208,14 @@ func WithLocation(v string) *types.Pair {
 	}
 }
 
+// WithMethod will apply method value to Options
+func WithMethod(v string) *types.Pair {
+	return &types.Pair{
+		Key:   Method,
+		Value: v,
+	}
+}
+
 // WithName will apply name value to Options
 func WithName(v string) *types.Pair {
 	return &types.Pair{
@@ -110,6 +240,14 @@ func WithPartSize(v int64) *types.Pair {
 	}
 }
 
+// WithPath will apply path value to Options
+func WithPath(v string) *types.Pair {
+	return &types.Pair{
+		Key:   Path,
+		Value: v,
+	}
+}
+
 // WithProject will apply project value to Options
 func WithProject(v string) *types.Pair {
 	return &types.Pair{
@@ -118,6 +256,14 @@ func WithProject(v string) *types.Pair {
 	}
 }
 
+// WithQueuePath will apply queue_path value to Options
+func WithQueuePath(v string) *types.Pair {
+	return &types.Pair{
+		Key:   QueuePath,
+		Value: v,
+	}
+}
+
 // WithSegmentFunc will apply segment_func value to Options
 func WithSegmentFunc(v segment.Func) *types.Pair {
 	return &types.Pair{
@@ -126,6 +272,14 @@ func WithSegmentFunc(v segment.Func) *types.Pair {
 	}
 }
 
+// WithSegmentId will apply segment_id value to Options
+func WithSegmentId(v string) *types.Pair {
+	return &types.Pair{
+		Key:   SegmentId,
+		Value: v,
+	}
+}
+
 // WithSize will apply size value to Options
 func WithSize(v int64) *types.Pair {
 	return &types.Pair{
@@ -142,6 +296,14 @@ func WithStorageClass(v string) *types.Pair {
 	}
 }
 
+// WithStorager will apply storager value to Options
+func WithStorager(v storage.Storager) *types.Pair {
+	return &types.Pair{
+		Key:   Storager,
+		Value: v,
+	}
+}
+
 // WithStoragerFunc will apply storager_func value to Options
 func WithStoragerFunc(v storage.StoragerFunc) *types.Pair {
 	return &types.Pair{
@@ -150,6 +312,14 @@ func WithStoragerFunc(v storage.StoragerFunc) *types.Pair {
 	}
 }
 
+// WithStoragers will apply storagers value to Options
+func WithStoragers(v []storage.Storager) *types.Pair {
+	return &types.Pair{
+		Key:   Storagers,
+		Value: v,
+	}
+}
+
 // WithType will apply type value to Options
 func WithType(v string) *types.Pair {
 	return &types.Pair{
@@ -158,6 +328,46 @@ func WithType(v string) *types.Pair {
 	}
 }
 
+// WithUser will apply user value to Options
+func WithUser(v string) *types.Pair {
+	return &types.Pair{
+		Key:   User,
+		Value: v,
+	}
+}
+
+// WithUserMetadata will apply user_metadata value to Options
+func WithUserMetadata(v map[string]string) *types.Pair {
+	return &types.Pair{
+		Key:   UserMetadata,
+		Value: v,
+	}
+}
+
+// WithVersionId will apply version_id value to Options
+func WithVersionId(v string) *types.Pair {
+	return &types.Pair{
+		Key:   VersionId,
+		Value: v,
+	}
+}
+
+// WithVersioning will apply versioning value to Options
+func WithVersioning(v bool) *types.Pair {
+	return &types.Pair{
+		Key:   Versioning,
+		Value: v,
+	}
+}
+
+// WithVirtualNodes will apply virtual_nodes value to Options
+func WithVirtualNodes(v int) *types.Pair {
+	return &types.Pair{
+		Key:   VirtualNodes,
+		Value: v,
+	}
+}
+
 // WithWorkDir will apply work_dir value to Options
 func WithWorkDir(v string) *types.Pair {
 	return &types.Pair{
@@ -165,3 +375,11 @@ func WithWorkDir(v string) *types.Pair {
 		Value: v,
 	}
 }
+
+// WithWriteQuorum will apply write_quorum value to Options
+func WithWriteQuorum(v int) *types.Pair {
+	return &types.Pair{
+		Key:   WriteQuorum,
+		Value: v,
+	}
+}